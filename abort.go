@@ -0,0 +1,63 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrAborted is returned when the read blocked past the deadline is aborted.
+var ErrAborted = errors.New("protoscan: read aborted on deadline")
+
+// WithTotalTimeout sets maximum duration of the scan since the creation of
+// the Protoscan. It is enforced by the read deadline of the connection
+// scanned by the ScanConn and by the callback of the WithAbort option.
+// Zero means no limit.
+func WithTotalTimeout(d time.Duration) Option {
+	return func(s *Protoscan) { s.totalTimeout = d }
+}
+
+// WithAbort sets the function called when the deadline of the token set by
+// the WithTokenTimeout or the total deadline set by the WithTotalTimeout
+// passes while a read is blocked. The function is expected to unblock the
+// read, typically by closing the connection, which gives a way to cancel the
+// reads from the readers that have no read deadlines. The scan stops with
+// the ErrAborted wrapping the error of the read.
+func WithAbort(fn func()) Option {
+	return func(s *Protoscan) { s.abort = fn }
+}
+
+// deadline returns the earliest of the deadlines of the token and the scan.
+func (s *Protoscan) deadline() time.Time {
+	var deadline time.Time
+	if s.tokenTimeout > 0 {
+		deadline = s.tokenStart.Add(s.tokenTimeout)
+	}
+	if s.totalTimeout > 0 {
+		t := s.created.Add(s.totalTimeout)
+		if deadline.IsZero() || t.Before(deadline) {
+			deadline = t
+		}
+	}
+	return deadline
+}
+
+// abortTimer arms the timer calling the abort function at the deadline.
+// It returns nil if there is no abort function or deadline.
+func (s *Protoscan) abortTimer() *time.Timer {
+	if s.abort == nil {
+		return nil
+	}
+	deadline := s.deadline()
+	if deadline.IsZero() {
+		return nil
+	}
+	s.aborted.Store(false)
+	return time.AfterFunc(time.Until(deadline), func() {
+		s.aborted.Store(true)
+		s.abort()
+	})
+}
@@ -0,0 +1,43 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/protoscan/protoscan"
+)
+
+func TestAbort(t *testing.T) {
+	for _, opt := range []protoscan.Option{
+		protoscan.WithTokenTimeout(20 * time.Millisecond),
+		protoscan.WithTotalTimeout(20 * time.Millisecond),
+	} {
+		r, w := io.Pipe()
+		go w.Write([]byte("abc\nde"))
+		var aborted int
+		s := protoscan.New(
+			r,
+			protoscan.WithSplit(protoscan.ScanLines),
+			protoscan.WithAbort(func() {
+				aborted++
+				r.Close()
+			}),
+			opt,
+		)
+		if !s.Scan() || string(s.Token()) != "abc" {
+			t.Fatalf("expected the first line; got %q, %v", s.Token(), s.Err())
+		}
+		if s.Scan() {
+			t.Fatalf("unexpected token %q", s.Token())
+		}
+		if !errors.Is(s.Err(), protoscan.ErrAborted) || !errors.Is(s.Err(), io.ErrClosedPipe) || aborted != 1 {
+			t.Fatalf("expected the read aborted once; got %v after %d aborts", s.Err(), aborted)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+// WithAdaptiveBuffer makes the Protoscan track the moving average of the
+// size of the frames, and size the buffer and the reads to hold the next
+// frame of the average size at once. For the workloads with stable sizes of
+// the messages it cuts the reallocations of the buffer and the calls to the
+// split function which hints less than the whole frame. A read never waits
+// for more data than hinted.
+func WithAdaptiveBuffer() Option {
+	return func(s *Protoscan) { s.adaptive = true }
+}
+
+// averageWeight is the weight of the last frame in the moving average.
+const averageWeight = 0.125
+
+// average adds the size of the frame to the moving average.
+func (s *Protoscan) average(size int) {
+	if s.avgFrame == 0 {
+		s.avgFrame = float64(size)
+		return
+	}
+	s.avgFrame += averageWeight * (float64(size) - s.avgFrame)
+}
+
+// window returns the end of the buffer to be filled by the reads for the
+// claim, which holds the rest of the frame of the average size.
+func (s *Protoscan) window(claim int) int {
+	w := s.start + int(s.avgFrame+0.5)
+	if w > s.maxBuffer {
+		w = s.maxBuffer
+	}
+	if w < claim {
+		return claim
+	}
+	return w
+}
@@ -0,0 +1,49 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+)
+
+// countingReader counts the reads.
+type countingReader struct {
+	reads int
+	r     io.Reader
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	r.reads++
+	return r.r.Read(p)
+}
+
+func TestAdaptiveBuffer(t *testing.T) {
+	text := strings.Repeat(strings.Repeat("x", 99)+"\n", 100)
+	scan := func(opts ...protoscan.Option) int {
+		r := &countingReader{r: strings.NewReader(text)}
+		s := protoscan.New(r, append(opts, protoscan.WithSplit(protoscan.ScanLines))...)
+		var n int
+		for ; s.Scan(); n++ {
+			if len(s.Token()) != 99 {
+				t.Fatalf("%d: unexpected token %q", n, s.Token())
+			}
+		}
+		if s.Err() != nil {
+			t.Fatal(s.Err())
+		}
+		if n != 100 {
+			t.Fatalf("expected 100 tokens; got %d", n)
+		}
+		return r.reads
+	}
+	fixed, adaptive := scan(), scan(protoscan.WithAdaptiveBuffer())
+	if adaptive*10 > fixed {
+		t.Fatalf("expected far less reads than %d; got %d", fixed, adaptive)
+	}
+}
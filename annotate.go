@@ -0,0 +1,94 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Annotation describes a field of a token for the dissection output.
+type Annotation struct {
+	Offset int    // Offset of the field in the token.
+	Length int    // Length of the field.
+	Field  string // Name of the field.
+	Value  string // Decoded value of the field, the raw bytes if empty.
+}
+
+// AnnotateFunc returns the annotations of the fields of a token. The field
+// aware split functions may drive it, by the Indexes of the TokenInfo, see
+// the AnnotateISO8583.
+type AnnotateFunc func(token []byte, info TokenInfo) []Annotation
+
+// WithAnnotator sets the function which annotates each token generated by
+// a call to Scan, see Annotations.
+func WithAnnotator(fn AnnotateFunc) Option {
+	return func(s *Protoscan) { s.annotator = fn }
+}
+
+// Annotations returns the annotations of the last token generated by a call
+// to Scan, or nil without WithAnnotator.
+func (s *Protoscan) Annotations() []Annotation {
+	return s.annotations
+}
+
+// annotate annotates the token.
+func (s *Protoscan) annotate() {
+	if s.annotator != nil {
+		s.annotations = s.annotator(s.token, s.info)
+	}
+}
+
+// field returns the bytes of the annotated field of the token, clipped to
+// the token.
+func (a Annotation) field(token []byte) []byte {
+	start := min(max(a.Offset, 0), len(token))
+	end := min(max(start+a.Length, start), len(token))
+	return token[start:end]
+}
+
+// RenderText writes the Wireshark-style text dissection of the token, the
+// frame line followed by a line for each of the annotations.
+func RenderText(w io.Writer, token []byte, info TokenInfo, annotations []Annotation) error {
+	if _, err := fmt.Fprintf(w, "Frame %d-%d (%d bytes), token %d bytes\n",
+		info.Start, info.End, info.End-info.Start, len(token)); err != nil {
+		return err
+	}
+	for _, a := range annotations {
+		value := a.Value
+		if value == "" {
+			value = "0x" + hex.EncodeToString(a.field(token))
+		}
+		if _, err := fmt.Fprintf(w, "    [%d:%d] %s: %s\n",
+			a.Offset, a.Offset+a.Length, a.Field, strconv.Quote(value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenderJSON writes the JSON line of the dissection of the token.
+func RenderJSON(w io.Writer, token []byte, info TokenInfo, annotations []Annotation) error {
+	type field struct {
+		Offset int    `json:"offset"`
+		Length int    `json:"length"`
+		Field  string `json:"field"`
+		Value  string `json:"value,omitempty"`
+		Hex    string `json:"hex"`
+	}
+	fields := make([]field, 0, len(annotations))
+	for _, a := range annotations {
+		fields = append(fields, field{a.Offset, a.Length, a.Field, a.Value, hex.EncodeToString(a.field(token))})
+	}
+	return json.NewEncoder(w).Encode(struct {
+		Offset int64   `json:"offset"`
+		Length int64   `json:"length"`
+		Hex    string  `json:"hex"`
+		Fields []field `json:"fields"`
+	}{info.Start, info.End - info.Start, hex.EncodeToString(token), fields})
+}
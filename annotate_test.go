@@ -0,0 +1,64 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+)
+
+// annotateKeyValue annotates the key and the value of the "key=value" line.
+func annotateKeyValue(token []byte, _ protoscan.TokenInfo) []protoscan.Annotation {
+	i := bytes.IndexByte(token, '=')
+	if i < 0 {
+		return nil
+	}
+	return []protoscan.Annotation{
+		{Offset: 0, Length: i, Field: "Key", Value: string(token[:i])},
+		{Offset: i + 1, Length: len(token) - i - 1, Field: "Value"},
+	}
+}
+
+func TestAnnotator(t *testing.T) {
+	s := protoscan.New(
+		strings.NewReader("a=1\nnone\nbc=23\n"),
+		protoscan.WithSplit(protoscan.ScanLines),
+		protoscan.WithAnnotator(annotateKeyValue),
+	)
+	var text, json bytes.Buffer
+	for s.Scan() {
+		if err := protoscan.RenderText(&text, s.Token(), s.TokenInfo(), s.Annotations()); err != nil {
+			t.Fatal(err)
+		}
+		if err := protoscan.RenderJSON(&json, s.Token(), s.TokenInfo(), s.Annotations()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	if s.Annotations() != nil {
+		t.Fatalf("unexpected annotations %v after the scan stopped", s.Annotations())
+	}
+	wantText := "Frame 0-4 (4 bytes), token 3 bytes\n" +
+		"    [0:1] Key: \"a\"\n" +
+		"    [2:3] Value: \"0x31\"\n" +
+		"Frame 4-9 (5 bytes), token 4 bytes\n" +
+		"Frame 9-15 (6 bytes), token 5 bytes\n" +
+		"    [0:2] Key: \"bc\"\n" +
+		"    [3:5] Value: \"0x3233\"\n"
+	if text.String() != wantText {
+		t.Errorf("expected text dissection\n%s\ngot\n%s", wantText, text.String())
+	}
+	wantJSON := `{"offset":0,"length":4,"hex":"613d31","fields":[{"offset":0,"length":1,"field":"Key","value":"a","hex":"61"},{"offset":2,"length":1,"field":"Value","hex":"31"}]}` + "\n" +
+		`{"offset":4,"length":5,"hex":"6e6f6e65","fields":[]}` + "\n" +
+		`{"offset":9,"length":6,"hex":"62633d3233","fields":[{"offset":0,"length":2,"field":"Key","value":"bc","hex":"6263"},{"offset":3,"length":2,"field":"Value","hex":"3233"}]}` + "\n"
+	if json.String() != wantJSON {
+		t.Errorf("expected JSON dissection\n%s\ngot\n%s", wantJSON, json.String())
+	}
+}
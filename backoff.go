@@ -0,0 +1,56 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import "time"
+
+// BackoffPolicy defines the exponentially growing sleeps between the
+// successive attempts, for instance, the successive empty reads.
+type BackoffPolicy struct {
+	Initial    time.Duration // Sleep after the first attempt.
+	Max        time.Duration // Maximum single sleep, zero means no limit.
+	Multiplier float64       // Growth factor of the sleep, 2 if zero.
+	Total      time.Duration // Maximum total sleep of the successive attempts, zero means no limit.
+}
+
+// Delay returns the sleep after the attempt, counted from 1.
+func (p BackoffPolicy) Delay(attempt int) time.Duration {
+	m := p.Multiplier
+	if m == 0 {
+		m = 2
+	}
+	d := float64(p.Initial)
+	for i := 1; i < attempt; i++ {
+		d *= m
+		if p.Max > 0 && d >= float64(p.Max) {
+			return p.Max
+		}
+	}
+	if p.Max > 0 && d > float64(p.Max) {
+		return p.Max
+	}
+	return time.Duration(d)
+}
+
+// WithIdleBackoff makes the Protoscan sleep after each of the successive
+// empty reads according to the policy, rather than counting the reads
+// toward the limit set by the WithMaxIdle. The scan stops with the
+// io.ErrNoProgress once the total sleep of the successive empty reads
+// exceeds the Total of the policy.
+func WithIdleBackoff(p BackoffPolicy) Option {
+	return func(s *Protoscan) { s.idleBackoff = &p }
+}
+
+// backoff sleeps after the successive empty read. It reports whether the
+// total sleep is within the limit of the policy.
+func (s *Protoscan) backoff() bool {
+	d := s.idleBackoff.Delay(s.empties)
+	if total := s.idleBackoff.Total; total > 0 && s.idleSlept+d > total {
+		return false
+	}
+	s.idleSlept += d
+	time.Sleep(d)
+	return true
+}
@@ -0,0 +1,62 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/protoscan/protoscan"
+)
+
+func TestBackoffPolicyDelay(t *testing.T) {
+	p := protoscan.BackoffPolicy{Initial: time.Millisecond, Max: 10 * time.Millisecond}
+	for _, test := range []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Millisecond},
+		{2, 2 * time.Millisecond},
+		{3, 4 * time.Millisecond},
+		{4, 8 * time.Millisecond},
+		{5, 10 * time.Millisecond},
+		{100, 10 * time.Millisecond},
+	} {
+		if got := p.Delay(test.attempt); got != test.want {
+			t.Errorf("attempt %d: expected %v; got %v", test.attempt, test.want, got)
+		}
+	}
+}
+
+func TestIdleBackoff(t *testing.T) {
+	p := protoscan.BackoffPolicy{Initial: time.Microsecond, Max: time.Millisecond, Total: 50 * time.Millisecond}
+	s := protoscan.New(
+		&zerosThenReader{n: 20, r: strings.NewReader("abc\n")},
+		protoscan.WithSplit(protoscan.ScanLines),
+		protoscan.WithIdleBackoff(p),
+	)
+	start := time.Now()
+	if !s.Scan() || string(s.Token()) != "abc" {
+		t.Fatalf("expected line after the empty reads; got %q, %v", s.Token(), s.Err())
+	}
+	if time.Since(start) < 10*time.Millisecond {
+		t.Fatalf("empty reads without sleeps")
+	}
+
+	s = protoscan.New(
+		&zerosThenReader{n: 1 << 30, r: strings.NewReader("abc\n")},
+		protoscan.WithSplit(protoscan.ScanLines),
+		protoscan.WithIdleBackoff(protoscan.BackoffPolicy{Initial: time.Millisecond, Total: 20 * time.Millisecond}),
+	)
+	if s.Scan() {
+		t.Fatal("expected no tokens")
+	}
+	if !errors.Is(s.Err(), io.ErrNoProgress) {
+		t.Fatalf("expected io.ErrNoProgress; got %v", s.Err())
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+// ScanN advances the Protoscan over up to max tokens, which will then be
+// available through the Batch method, and returns the count of the tokens.
+// It reads from the reader only until the first token, the rest of the
+// tokens are split from the data already buffered, so all the tokens of the
+// batch stay valid until the next call to Scan or ScanN. It returns zero
+// when the scan stops, the Err method returns the error as after Scan; the
+// error met after the first token is reported by the next call.
+// The Protoscan reads no more than hinted by the split function, so the
+// batches of the split functions hinting less than the whole frame, such as
+// ScanLines, need the reads sized by the WithAdaptiveBuffer option.
+func (s *Protoscan) ScanN(max int) int {
+	s.batch = s.batch[:0]
+	if max > 0 {
+		s.batching, s.batchMax = true, max
+		s.Scan()
+		s.batching = false
+	}
+	return len(s.batch)
+}
+
+// Batch returns the tokens generated by the last call to ScanN.
+func (s *Protoscan) Batch() [][]byte {
+	return s.batch
+}
+
+// batched reports whether the batch in progress holds tokens, so that the
+// scan stopping is left to the next call to ScanN.
+func (s *Protoscan) batched() bool {
+	return s.batching && len(s.batch) > 0
+}
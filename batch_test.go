@@ -0,0 +1,66 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanN(t *testing.T) {
+	var lines []string
+	for i := 0; i < 100; i++ {
+		lines = append(lines, strings.Repeat("x", i%7))
+	}
+	text := strings.Join(lines, "\n") + "\n"
+	s := protoscan.New(
+		&protoscantest.SlowReader{Max: 17, R: strings.NewReader(text)},
+		protoscan.WithSplit(protoscan.ScanLines),
+		protoscan.WithAdaptiveBuffer(),
+	)
+	var got []string
+	var batches int
+	for n := s.ScanN(5); n > 0; n = s.ScanN(5) {
+		if n != len(s.Batch()) {
+			t.Fatalf("batch of %d tokens; got %d", n, len(s.Batch()))
+		}
+		for _, token := range s.Batch() {
+			got = append(got, string(token))
+		}
+		batches++
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	if strings.Join(got, "|") != strings.Join(lines, "|") {
+		t.Fatalf("unexpected tokens %q", got)
+	}
+	if batches >= len(lines) {
+		t.Fatalf("expected batches of several tokens; got %d batches", batches)
+	}
+}
+
+func TestScanNBuffered(t *testing.T) {
+	r := &countingReader{r: strings.NewReader(strings.Repeat("x", 39) + "\n" + strings.Repeat("a\n", 50))}
+	s := protoscan.New(r, protoscan.WithSplit(protoscan.ScanLines), protoscan.WithAdaptiveBuffer())
+	if n := s.ScanN(10); n != 1 {
+		t.Fatalf("expected the first line alone; got %d tokens", n)
+	}
+	reads := r.reads
+	if n := s.ScanN(10); n != 10 {
+		t.Fatalf("expected a full batch; got %d tokens", n)
+	}
+	if r.reads-reads != 1 {
+		t.Fatalf("expected the batch from a single read; got %d reads", r.reads-reads)
+	}
+	for i, token := range s.Batch() {
+		if string(token) != "a" {
+			t.Fatalf("unexpected token %d %q", i, token)
+		}
+	}
+}
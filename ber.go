@@ -0,0 +1,114 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"fmt"
+	"io"
+)
+
+// Limits of the BER elements.
+const (
+	berMaxTag    = 4  // Maximum count of the subsequent octets of the tag.
+	berMaxLength = 8  // Maximum count of the octets of the long form length.
+	berMaxDepth  = 64 // Maximum nesting of the indefinite lengths.
+)
+
+// berParser walks the BER element.
+type berParser struct {
+	cursor
+}
+
+// element skips the element of the identifier octets, the length octets
+// and the contents octets, nested in depth elements of the indefinite
+// length.
+func (p *berParser) element(depth int) error {
+	tag, err := p.readByte()
+	if err != nil {
+		return err
+	}
+	if tag&0x1f == 0x1f {
+		for i := 0; ; i++ {
+			if i == berMaxTag {
+				return fmt.Errorf("%w: BER tag longer than %d bytes", ErrProtocolViolation, berMaxTag)
+			}
+			b, err := p.readByte()
+			if err != nil {
+				return err
+			}
+			if b&0x80 == 0 {
+				break
+			}
+		}
+	}
+	l, err := p.readByte()
+	if err != nil {
+		return err
+	}
+	switch {
+	case l < 0x80:
+		return p.skip(uint64(l))
+	case l == 0x80:
+		if tag&0x20 == 0 {
+			return fmt.Errorf("%w: BER primitive element of indefinite length", ErrProtocolViolation)
+		}
+		if depth == berMaxDepth {
+			return fmt.Errorf("%w: BER elements nested deeper than %d", ErrProtocolViolation, berMaxDepth)
+		}
+		for {
+			// The end-of-contents octets.
+			if err := p.skip(2); err != nil {
+				return err
+			}
+			if p.data[p.pos-2] == 0 && p.data[p.pos-1] == 0 {
+				return nil
+			}
+			p.pos -= 2
+			if err := p.element(depth + 1); err != nil {
+				return err
+			}
+		}
+	case l == 0xff || l&0x7f > berMaxLength:
+		return fmt.Errorf("%w: bad BER length 0x%02x", ErrProtocolViolation, l)
+	}
+	if err := p.skip(uint64(l & 0x7f)); err != nil {
+		return err
+	}
+	var n uint64
+	for _, b := range p.data[p.pos-int(l&0x7f) : p.pos] {
+		n = n<<8 | uint64(b)
+	}
+	return p.skip(n)
+}
+
+// ScanBERTLV is a split function for a Protoscan that returns each element
+// of the ASN.1 Basic Encoding Rules, or of the Distinguished Encoding
+// Rules, as a token: the tag, the length of the short or the long form,
+// and the contents, as used by the LDAP, the SNMP or the EMV. The elements
+// of the indefinite length are walked up to their end-of-contents octets,
+// hinting the exact count of the bytes missing. The reserved lengths, the
+// primitive elements of the indefinite length, and the tags, the lengths
+// or the nesting beyond the limits stop the scan with the
+// ErrProtocolViolation.
+func ScanBERTLV(data []byte, atEOF bool) (int, int, []byte, error) {
+	if len(data) == 0 {
+		if atEOF {
+			return 0, 0, nil, nil
+		}
+		return 1, 0, nil, nil
+	}
+	p := berParser{cursor: cursor{data: data, proto: "BER"}}
+	err := p.element(0)
+	if err == errShort {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return p.need, 0, nil, nil
+	}
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	return 0, p.pos, data[:p.pos], nil
+}
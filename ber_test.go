@@ -0,0 +1,46 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanBERTLV(t *testing.T) {
+	// The LDAP unbind request, the long form OCTET STRING, the indefinite
+	// SEQUENCE nesting another, and the EMV tag of two octets.
+	unbind := "\x30\x05\x02\x01\x03\x42\x00"
+	long := "\x04\x81\x80" + strings.Repeat("x", 128)
+	indefinite := "\x30\x80\x02\x01\x01\x30\x80\x04\x01a\x00\x00\x00\x00"
+	emv := "\x9f\x02\x06\x00\x00\x00\x00\x01\x00"
+	protoscantest.TestSplitFunc(t, protoscan.ScanBERTLV, []protoscantest.Case{
+		{Name: "empty", Input: "", Tokens: nil},
+		{
+			Name:   "elements",
+			Input:  unbind + long + indefinite + emv,
+			Tokens: []string{unbind, long, indefinite, emv},
+		},
+		{Name: "short tag", Input: "\x9f", Err: io.ErrUnexpectedEOF},
+		{Name: "short length", Input: "\x04\x82\x01", Err: io.ErrUnexpectedEOF},
+		{Name: "short contents", Input: "\x04\x03ab", Err: io.ErrUnexpectedEOF},
+		{Name: "no end-of-contents", Input: "\x30\x80\x02\x01\x01", Err: io.ErrUnexpectedEOF},
+		{Name: "indefinite primitive", Input: "\x04\x80a\x00\x00", Err: protoscan.ErrProtocolViolation},
+		{Name: "reserved length", Input: "\x04\xff", Err: protoscan.ErrProtocolViolation},
+		{Name: "long length", Input: "\x04\x89", Err: protoscan.ErrProtocolViolation},
+		{Name: "huge length", Input: "\x04\x88\xff\xff\xff\xff\xff\xff\xff\xff", Err: protoscan.ErrProtocolViolation},
+		{Name: "long tag", Input: "\x1f\x81\x81\x81\x81\x01", Err: protoscan.ErrProtocolViolation},
+		{Name: "deep", Input: strings.Repeat("\x30\x80", 100), Err: protoscan.ErrProtocolViolation},
+	})
+}
+
+func FuzzScanBERTLV(f *testing.F) {
+	f.Add([]byte("\x30\x80\x04\x01a\x00\x00\x04\x81\x01b"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanBERTLV))
+}
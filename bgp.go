@@ -0,0 +1,63 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	bgpHeader     = 19
+	bgpMaxMessage = 4096
+)
+
+// bgpMinLengths are the minimum lengths of the BGP messages of the types
+// OPEN, UPDATE, NOTIFICATION, KEEPALIVE and ROUTE-REFRESH.
+var bgpMinLengths = [...]int{1: 29, 2: 23, 3: 21, 4: 19, 5: 23}
+
+// ScanBGP is a split function for a Protoscan that returns each BGP-4
+// message as a token: the 19-byte header of the 16-byte marker of all
+// ones, the 2-byte length and the 1-byte type, followed by the body. The
+// bad marker is reported as the ErrNeedResync, advancing past its first
+// byte which is not all ones, and the length out of the 19 to 4096 bytes
+// as the ErrNeedResync advancing over the marker. The message of an
+// unknown type, or shorter than its type requires, is reported as the
+// ErrCorruptFrame.
+func ScanBGP(data []byte, atEOF bool) (int, int, []byte, error) {
+	if len(data) == 0 && atEOF {
+		return 0, 0, nil, nil
+	}
+	for i, c := range data[:min(len(data), 16)] {
+		if c != 0xff {
+			return 0, i + 1, nil, fmt.Errorf("%w: bad BGP marker", ErrNeedResync)
+		}
+	}
+	if len(data) < bgpHeader {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return bgpHeader - len(data), 0, nil, nil
+	}
+	n := int(binary.BigEndian.Uint16(data[16:]))
+	if n < bgpHeader || n > bgpMaxMessage {
+		return 0, 16, nil, fmt.Errorf("%w: BGP message length %d", ErrNeedResync, n)
+	}
+	if n > len(data) {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return n - len(data), 0, nil, nil
+	}
+	typ := int(data[18])
+	if typ == 0 || typ >= len(bgpMinLengths) {
+		return 0, n, nil, fmt.Errorf("%w: unknown BGP message type %d", ErrCorruptFrame, typ)
+	}
+	if n < bgpMinLengths[typ] {
+		return 0, n, nil, fmt.Errorf("%w: BGP message of type %d too short", ErrCorruptFrame, typ)
+	}
+	return 0, n, data[:n], nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+// bgpMsg returns the BGP message of the type and the body.
+func bgpMsg(typ byte, body string) string {
+	return strings.Repeat("\xff", 16) + be16(19+len(body)) + string(typ) + body
+}
+
+func TestScanBGP(t *testing.T) {
+	open := bgpMsg(1, "\x04\xfd\xe8\x00\xb4\x0a\x00\x00\x01\x00")
+	keepalive := bgpMsg(4, "")
+	update := bgpMsg(2, "\x00\x00\x00\x00")
+	protoscantest.TestSplitFunc(t, protoscan.ScanBGP, []protoscantest.Case{
+		{Name: "empty", Input: "", Tokens: nil},
+		{Name: "messages", Input: open + keepalive + update, Tokens: []string{open, keepalive, update}},
+		{Name: "short", Input: open + keepalive[:10], Tokens: []string{open}, Err: io.ErrUnexpectedEOF},
+		{Name: "marker", Input: "\xff\xff\x00" + keepalive, Err: protoscan.ErrNeedResync},
+		{Name: "length", Input: strings.Repeat("\xff", 16) + be16(4097) + "\x02", Err: protoscan.ErrNeedResync},
+		{Name: "type", Input: bgpMsg(9, ""), Err: protoscan.ErrCorruptFrame},
+		{Name: "too short for type", Input: bgpMsg(1, ""), Err: protoscan.ErrCorruptFrame},
+	})
+}
+
+func TestScanBGPRecovery(t *testing.T) {
+	keepalive := bgpMsg(4, "")
+	s := protoscan.New(
+		strings.NewReader("\xff\x01"+keepalive+bgpMsg(9, "x")+keepalive),
+		protoscan.WithSplit(protoscan.ScanBGP),
+		protoscan.WithRecovery(func(error, []byte) {}),
+	)
+	var n int
+	for s.Scan() {
+		if string(s.Token()) != keepalive {
+			t.Fatalf("unexpected token %q", s.Token())
+		}
+		n++
+	}
+	if s.Err() != nil || n != 2 {
+		t.Fatalf("expected 2 messages; got %d, %v", n, s.Err())
+	}
+}
+
+func FuzzScanBGP(f *testing.F) {
+	f.Add([]byte(bgpMsg(4, "") + bgpMsg(2, "\x00\x00\x00\x00")))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanBGP))
+}
@@ -0,0 +1,168 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// cborMaxDepth is the maximum nesting of the CBOR data items.
+const cborMaxDepth = 64
+
+// cborBreak is the "break" stop code of the indefinite-length items.
+const cborBreak = 0xff
+
+// cborParser walks the CBOR data item.
+type cborParser struct {
+	cursor
+}
+
+// head returns the major type, the additional information and the
+// argument of the initial byte of the data item.
+func (p *cborParser) head() (byte, byte, uint64, error) {
+	b, err := p.readByte()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	major, info := b>>5, b&0x1f
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info == 31:
+		return major, info, 0, nil
+	case info > 27:
+		return 0, 0, 0, fmt.Errorf("%w: reserved CBOR additional information %d", ErrProtocolViolation, info)
+	}
+	size := 1 << (info - 24)
+	if err := p.skip(uint64(size)); err != nil {
+		return 0, 0, 0, err
+	}
+	arg := p.data[p.pos-size : p.pos]
+	switch size {
+	case 1:
+		return major, info, uint64(arg[0]), nil
+	case 2:
+		return major, info, uint64(binary.BigEndian.Uint16(arg)), nil
+	case 4:
+		return major, info, uint64(binary.BigEndian.Uint32(arg)), nil
+	}
+	return major, info, binary.BigEndian.Uint64(arg), nil
+}
+
+// atBreak reports whether the next byte is the break stop code, skipping
+// it.
+func (p *cborParser) atBreak() (bool, error) {
+	b, err := p.readByte()
+	if err != nil {
+		return false, err
+	}
+	if b == cborBreak {
+		return true, nil
+	}
+	p.pos--
+	return false, nil
+}
+
+// item skips the data item nested in depth items.
+func (p *cborParser) item(depth int) error {
+	if depth == cborMaxDepth {
+		return fmt.Errorf("%w: CBOR data items nested deeper than %d", ErrProtocolViolation, cborMaxDepth)
+	}
+	major, info, arg, err := p.head()
+	if err != nil {
+		return err
+	}
+	if info == 31 {
+		return p.indefinite(major, depth)
+	}
+	switch major {
+	case 2, 3:
+		return p.skip(arg)
+	case 4, 5:
+		for ; arg > 0; arg-- {
+			if err := p.item(depth + 1); err != nil {
+				return err
+			}
+			if major == 5 {
+				if err := p.item(depth + 1); err != nil {
+					return err
+				}
+			}
+		}
+	case 6:
+		return p.item(depth + 1)
+	}
+	return nil
+}
+
+// indefinite skips the indefinite-length data item of the major type up to
+// its break stop code.
+func (p *cborParser) indefinite(major byte, depth int) error {
+	switch major {
+	case 2, 3, 4, 5:
+	case 7:
+		return fmt.Errorf("%w: unexpected CBOR break", ErrProtocolViolation)
+	default:
+		return fmt.Errorf("%w: CBOR major type %d of indefinite length", ErrProtocolViolation, major)
+	}
+	for {
+		stop, err := p.atBreak()
+		if err != nil || stop {
+			return err
+		}
+		if major == 2 || major == 3 {
+			// The chunks are the definite-length strings of the type.
+			m, info, arg, err := p.head()
+			if err != nil {
+				return err
+			}
+			if m != major || info == 31 {
+				return fmt.Errorf("%w: bad CBOR string chunk", ErrProtocolViolation)
+			}
+			if err := p.skip(arg); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := p.item(depth + 1); err != nil {
+			return err
+		}
+		if major == 5 {
+			if err := p.item(depth + 1); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ScanCBOR is a split function for a Protoscan that returns each top-level
+// CBOR data item, RFC 8949, as a token, the arrays, the maps and the tags
+// with their nested items. The strings, the arrays and the maps of the
+// indefinite length are walked up to their break stop code, hinting the
+// exact count of the bytes missing. The reserved additional information,
+// the misplaced break stop codes and the items nested deeper than 64
+// stop the scan with the ErrProtocolViolation.
+func ScanCBOR(data []byte, atEOF bool) (int, int, []byte, error) {
+	if len(data) == 0 {
+		if atEOF {
+			return 0, 0, nil, nil
+		}
+		return 1, 0, nil, nil
+	}
+	p := cborParser{cursor: cursor{data: data, proto: "CBOR"}}
+	err := p.item(0)
+	if err == errShort {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return p.need, 0, nil, nil
+	}
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	return 0, p.pos, data[:p.pos], nil
+}
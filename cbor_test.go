@@ -0,0 +1,54 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanCBOR(t *testing.T) {
+	// The examples of the RFC 8949 appendix A.
+	items := []string{
+		"\x00",
+		"\x1b\x00\x00\x00\xe8\xd4\xa5\x10\x00",
+		"\x39\x03\xe7",
+		"\xf9\x3c\x00",
+		"\xfb\x3f\xf1\x99\x99\x99\x99\x99\x9a",
+		"\xf5",
+		"\xc1\x1a\x51\x4b\x67\xb0",
+		"\x44\x01\x02\x03\x04",
+		"\x62\x22\x5c",
+		"\x83\x01\x82\x02\x03\x82\x04\x05",
+		"\xa2\x61a\x01\x61b\x82\x02\x03",
+		"\x5f\x42\x01\x02\x43\x03\x04\x05\xff",
+		"\x7f\x65strea\x64ming\xff",
+		"\x9f\x01\x82\x02\x03\x9f\x04\x05\xff\xff",
+		"\xbf\x63Fun\xf5\x63Amt\x21\xff",
+	}
+	protoscantest.TestSplitFunc(t, protoscan.ScanCBOR, []protoscantest.Case{
+		{Name: "empty", Input: "", Tokens: nil},
+		{Name: "items", Input: strings.Join(items, ""), Tokens: items},
+		{Name: "short argument", Input: "\x19\x01", Err: io.ErrUnexpectedEOF},
+		{Name: "short string", Input: "\x63ab", Err: io.ErrUnexpectedEOF},
+		{Name: "short array", Input: "\x82\x01", Err: io.ErrUnexpectedEOF},
+		{Name: "no break", Input: "\x9f\x01\x02", Err: io.ErrUnexpectedEOF},
+		{Name: "reserved", Input: "\x1c", Err: protoscan.ErrProtocolViolation},
+		{Name: "break", Input: "\xff", Err: protoscan.ErrProtocolViolation},
+		{Name: "indefinite integer", Input: "\x1f", Err: protoscan.ErrProtocolViolation},
+		{Name: "bad chunk", Input: "\x5f\x61a\xff", Err: protoscan.ErrProtocolViolation},
+		{Name: "huge string", Input: "\x5b\xff\xff\xff\xff\xff\xff\xff\xff", Err: protoscan.ErrProtocolViolation},
+		{Name: "deep", Input: strings.Repeat("\x81", 100), Err: protoscan.ErrProtocolViolation},
+	})
+}
+
+func FuzzScanCBOR(f *testing.F) {
+	f.Add([]byte("\x9f\x01\x82\x02\x03\xff\xbf\x61a\x5f\x41b\xff\xff\xc1\x00"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanCBOR))
+}
@@ -0,0 +1,112 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Markers of the CEF and the LEEF events, and the count of the pipes
+// ending their headers.
+var (
+	cefMarker  = []byte("CEF:")
+	leefMarker = []byte("LEEF:")
+)
+
+const (
+	cefPipes  = 7
+	leefPipes = 5
+)
+
+// cefStart returns the offset of the marker of the event starting in the
+// line and the count of the pipes ending its header, or -1 if the line does
+// not start an event.
+func cefStart(line []byte, leef bool) (int, int) {
+	if i := bytes.Index(line, cefMarker); i >= 0 {
+		return i, cefPipes
+	}
+	if i := bytes.Index(line, leefMarker); leef && i >= 0 {
+		return i, leefPipes
+	}
+	return -1, 0
+}
+
+// ScanCEF returns the split function for a Protoscan that returns each
+// ArcSight CEF event, or the LEEF event if leef, as a token, with the
+// syslog header preceding the "CEF:" or the "LEEF:" marker in its line.
+// The event continues on the next line while its header is incomplete,
+// the pipes escaped by the backslash not counted, unless the next line
+// starts another event, and after the newline escaped by the backslash in
+// the extension. The final newline is dropped, as are the empty lines. The
+// lines outside of the events, and the events of an incomplete header, are
+// reported as the ErrCorruptFrame, advancing over them.
+func ScanCEF(leef bool) SplitFunc {
+	return func(data []byte, atEOF bool) (int, int, []byte, error) {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 && !atEOF {
+			return 1, 0, nil, nil
+		}
+		if i < 0 {
+			if len(data) == 0 {
+				return 0, 0, nil, nil
+			}
+			i = len(data)
+		}
+		line := dropCR(data[:i])
+		advance := min(i+1, len(data))
+		if len(bytes.TrimSpace(line)) == 0 {
+			return 0, advance, nil, nil
+		}
+		m, need := cefStart(line, leef)
+		if m < 0 {
+			return 0, advance, nil, fmt.Errorf("%w: line outside of the CEF events", ErrCorruptFrame)
+		}
+		pipes, escaped := 0, false
+		scan := line[m:]
+		for pos := 0; ; {
+			for _, c := range scan {
+				switch {
+				case escaped:
+					escaped = false
+				case c == '\\':
+					escaped = true
+				case c == '|' && pipes < need:
+					pipes++
+				}
+			}
+			end := pos + len(line)
+			if pipes == need && !escaped {
+				return 0, advance, data[:end], nil
+			}
+			escaped = false
+			if advance == len(data) && !atEOF {
+				return 1, 0, nil, nil
+			}
+			if advance == len(data) && pipes < need {
+				return 0, advance, nil, fmt.Errorf("%w: incomplete CEF header", ErrCorruptFrame)
+			}
+			if advance == len(data) {
+				// The escaped newline at EOF.
+				return 0, advance, data[:end], nil
+			}
+			// The next line continues the event.
+			pos = advance
+			i := bytes.IndexByte(data[pos:], '\n')
+			if i < 0 && !atEOF {
+				return 1, 0, nil, nil
+			}
+			if i < 0 {
+				i = len(data) - pos
+			}
+			line = dropCR(data[pos : pos+i])
+			scan = line
+			if k, _ := cefStart(line, leef); pipes < need && k >= 0 {
+				return 0, pos, nil, fmt.Errorf("%w: incomplete CEF header", ErrCorruptFrame)
+			}
+			advance = min(pos+i+1, len(data))
+		}
+	}
+}
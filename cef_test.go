@@ -0,0 +1,86 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanCEF(t *testing.T) {
+	event := `<134>Feb 01 12:00:00 host CEF:0|Vendor|Product|1.0|100|Login|5|src=10.0.0.1 msg=a\|b c\\`
+	multi := "CEF:0|Vendor|Product|1.0|101|Multi\nline name|3|msg=first\\\nsecond cs1=x"
+	escaped := `CEF:0|Ven\|dor|Product|1.0|102|Pipe|3|`
+	leef := "LEEF:1.0|Vendor|Product|1.0|Login|src=10.0.0.1\tdst=10.0.0.2"
+	protoscantest.TestSplitFunc(t, protoscan.ScanCEF(false), []protoscantest.Case{
+		{Name: "empty", Input: "", Tokens: nil},
+		{
+			Name:   "events",
+			Input:  event + "\r\n\n" + multi + "\n" + event,
+			Tokens: []string{event, multi, event},
+		},
+		{
+			Name:   "escaped pipe",
+			Input:  escaped + "\n" + event + "\n",
+			Tokens: []string{escaped, event},
+		},
+		{
+			Name:   "escaped newline at EOF",
+			Input:  "CEF:0|V|P|1|1|N|1|msg=a\\\n",
+			Tokens: []string{"CEF:0|V|P|1|1|N|1|msg=a\\"},
+		},
+		{
+			Name:   "incomplete header",
+			Input:  "CEF:0|V|P\n" + event + "\n",
+			Tokens: nil,
+			Err:    protoscan.ErrCorruptFrame,
+		},
+		{
+			Name:  "incomplete header at EOF",
+			Input: "CEF:0|V|P\nmore",
+			Err:   protoscan.ErrCorruptFrame,
+		},
+		{
+			Name:  "other line",
+			Input: "hello\n" + event,
+			Err:   protoscan.ErrCorruptFrame,
+		},
+		{
+			Name:  "leef not enabled",
+			Input: leef,
+			Err:   protoscan.ErrCorruptFrame,
+		},
+	})
+	protoscantest.TestSplitFunc(t, protoscan.ScanCEF(true), []protoscantest.Case{
+		{Name: "leef", Input: leef + "\n" + event + "\n", Tokens: []string{leef, event}},
+	})
+}
+
+func TestScanCEFRecovery(t *testing.T) {
+	event := "CEF:0|V|P|1|1|N|1|"
+	s := protoscan.New(
+		strings.NewReader("noise\n"+event+"\nCEF:0|V|P\n"+event+"\n"),
+		protoscan.WithSplit(protoscan.ScanCEF(false)),
+		protoscan.WithRecovery(func(error, []byte) {}),
+	)
+	var n int
+	for s.Scan() {
+		if string(s.Token()) != event {
+			t.Fatalf("unexpected token %q", s.Token())
+		}
+		n++
+	}
+	if s.Err() != nil || n != 2 {
+		t.Fatalf("expected 2 events; got %d, %v", n, s.Err())
+	}
+}
+
+func FuzzScanCEF(f *testing.F) {
+	f.Add([]byte("CEF:0|V|P|1|1|N\\|x|1|a=b\\\nc\nLEEF:1.0|V|P|1|E|\n"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanCEF(true)))
+}
@@ -0,0 +1,228 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"bufio"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Charset is the name of the character set of the data stream.
+type Charset string
+
+// Character sets detected by the WithCharsetDetection.
+const (
+	UTF8        Charset = "UTF-8"
+	UTF16LE     Charset = "UTF-16LE"
+	UTF16BE     Charset = "UTF-16BE"
+	Windows1252 Charset = "windows-1252"
+)
+
+// CharsetSniffSize is the size of the head of the data stream examined to
+// detect the character set.
+const CharsetSniffSize = 1024
+
+// WithCharsetDetection makes the Protoscan detect the character set of the
+// data stream by its byte order mark or, failing that, by the first
+// CharsetSniffSize bytes, and transform the data stream to UTF-8 before the
+// transformer set by the WithTransform. The byte order mark is removed. The
+// first read waits for CharsetSniffSize bytes or EOF.
+func WithCharsetDetection() Option {
+	return func(s *Protoscan) { s.detectCharset = true }
+}
+
+// Charset returns the detected character set of the data stream, or an
+// empty string if it is not detected yet or the detection is not set.
+func (s *Protoscan) Charset() Charset {
+	if s.charsetReader == nil {
+		return ""
+	}
+	return s.charsetReader.charset
+}
+
+// charsetReader reads the data stream transformed to UTF-8 from the
+// character set detected on the head of the stream.
+type charsetReader struct {
+	reader  io.Reader     // The reader of the raw data.
+	charset Charset       // The detected character set.
+	r       io.Reader     // The reader of the transformed data, nil until detected.
+	stamp   *stampReader  // The reader of the raw data recording its timestamps.
+	br      *bufio.Reader // The reader of the raw data peeked by the detection.
+}
+
+func (c *charsetReader) Read(p []byte) (int, error) {
+	if c.r == nil {
+		if err := c.detect(); err != nil {
+			return 0, err
+		}
+	}
+	return c.r.Read(p)
+}
+
+// detect peeks the head of the stream and chooses the character set.
+func (c *charsetReader) detect() error {
+	if c.br == nil {
+		// The bytes peeked before an error are kept for the next call.
+		c.stamp = &stampReader{reader: c.reader}
+		c.br = bufio.NewReaderSize(c.stamp, CharsetSniffSize)
+	}
+	br := c.br
+	head, err := br.Peek(CharsetSniffSize)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	var bom int
+	c.charset, bom = sniffCharset(head, err == io.EOF)
+	br.Discard(bom)
+	switch c.charset {
+	case UTF16LE:
+		c.r = newTransformReader(br, &utf16Decoder{bigEndian: false})
+	case UTF16BE:
+		c.r = newTransformReader(br, &utf16Decoder{bigEndian: true})
+	case Windows1252:
+		c.r = newTransformReader(br, windows1252Decoder{})
+	default:
+		c.r = br
+	}
+	return nil
+}
+
+// sniffCharset returns the character set of the head of the data stream and
+// the size of its byte order mark.
+func sniffCharset(head []byte, atEOF bool) (Charset, int) {
+	switch {
+	case len(head) >= 3 && head[0] == 0xef && head[1] == 0xbb && head[2] == 0xbf:
+		return UTF8, 3
+	case len(head) >= 2 && head[0] == 0xff && head[1] == 0xfe:
+		return UTF16LE, 2
+	case len(head) >= 2 && head[0] == 0xfe && head[1] == 0xff:
+		return UTF16BE, 2
+	}
+	// The text in UTF-16 of mostly ASCII characters has the zero byte in
+	// each pair.
+	var even, odd int
+	for i := 0; i+1 < len(head); i += 2 {
+		if head[i] == 0 {
+			even++
+		}
+		if head[i+1] == 0 {
+			odd++
+		}
+	}
+	pairs := len(head) / 2
+	switch {
+	case pairs > 0 && odd*4 >= pairs && even*16 < pairs:
+		return UTF16LE, 0
+	case pairs > 0 && even*4 >= pairs && odd*16 < pairs:
+		return UTF16BE, 0
+	}
+	if !atEOF {
+		// Drop the rune cut by the end of the head.
+		for i := len(head) - 1; i >= 0 && i >= len(head)-utf8.UTFMax; i-- {
+			if utf8.RuneStart(head[i]) {
+				if !utf8.FullRune(head[i:]) {
+					head = head[:i]
+				}
+				break
+			}
+		}
+	}
+	if utf8.Valid(head) {
+		return UTF8, 0
+	}
+	return Windows1252, 0
+}
+
+// utf16Decoder transforms UTF-16 to UTF-8.
+type utf16Decoder struct {
+	bigEndian bool
+}
+
+func (d *utf16Decoder) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for nSrc < len(src) {
+		if nSrc+2 > len(src) {
+			if !atEOF {
+				return nDst, nSrc, ErrShortSrc
+			}
+			// The odd byte at EOF.
+			if nDst+3 > len(dst) {
+				return nDst, nSrc, ErrShortDst
+			}
+			nDst += utf8.EncodeRune(dst[nDst:], utf8.RuneError)
+			nSrc++
+			continue
+		}
+		r, width := d.unit(src[nSrc:]), 2
+		if utf16.IsSurrogate(r) {
+			if nSrc+4 > len(src) && !atEOF {
+				return nDst, nSrc, ErrShortSrc
+			}
+			if nSrc+4 <= len(src) {
+				if r2 := utf16.DecodeRune(r, d.unit(src[nSrc+2:])); r2 != utf8.RuneError {
+					r, width = r2, 4
+				}
+			}
+			if width == 2 {
+				r = utf8.RuneError
+			}
+		}
+		if nDst+utf8.RuneLen(r) > len(dst) {
+			return nDst, nSrc, ErrShortDst
+		}
+		nDst += utf8.EncodeRune(dst[nDst:], r)
+		nSrc += width
+	}
+	return nDst, nSrc, nil
+}
+
+// unit returns the code unit of the first two bytes.
+func (d *utf16Decoder) unit(b []byte) rune {
+	if d.bigEndian {
+		return rune(b[0])<<8 | rune(b[1])
+	}
+	return rune(b[1])<<8 | rune(b[0])
+}
+
+func (*utf16Decoder) Reset() {}
+
+// windows1252 maps the bytes 0x80 to 0x9f of the Windows-1252 to the runes,
+// the undefined bytes are mapped to the C1 control characters.
+var windows1252 = [32]rune{
+	'€', '\u0081', '‚', 'ƒ', '„', '…', '†', '‡',
+	'ˆ', '‰', 'Š', '‹', 'Œ', '\u008d', 'Ž', '\u008f',
+	'\u0090', '‘', '’', '“', '”', '•', '–', '—',
+	'˜', '™', 'š', '›', 'œ', '\u009d', 'ž', 'Ÿ',
+}
+
+// windows1252Decoder transforms Windows-1252, a superset of the printable
+// characters of Latin-1, to UTF-8.
+type windows1252Decoder struct{}
+
+func (windows1252Decoder) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for ; nSrc < len(src); nSrc++ {
+		c := src[nSrc]
+		if c < utf8.RuneSelf {
+			if nDst == len(dst) {
+				return nDst, nSrc, ErrShortDst
+			}
+			dst[nDst] = c
+			nDst++
+			continue
+		}
+		r := rune(c)
+		if c < 0xa0 {
+			r = windows1252[c-0x80]
+		}
+		if nDst+utf8.RuneLen(r) > len(dst) {
+			return nDst, nSrc, ErrShortDst
+		}
+		nDst += utf8.EncodeRune(dst[nDst:], r)
+	}
+	return nDst, nSrc, nil
+}
+
+func (windows1252Decoder) Reset() {}
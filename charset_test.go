@@ -0,0 +1,99 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+// encodeUTF16 encodes the text in UTF-16 of the byte order.
+func encodeUTF16(text string, bigEndian bool) []byte {
+	var b []byte
+	for _, u := range utf16.Encode([]rune(text)) {
+		if bigEndian {
+			b = append(b, byte(u>>8), byte(u))
+		} else {
+			b = append(b, byte(u), byte(u>>8))
+		}
+	}
+	return b
+}
+
+func TestCharsetDetection(t *testing.T) {
+	const text = "naïve café\nprice: 5€ 😀\n"
+	for _, test := range []struct {
+		name    string
+		input   []byte
+		charset protoscan.Charset
+		want    string
+	}{
+		{name: "utf-8", input: []byte(text), charset: protoscan.UTF8, want: text},
+		{name: "utf-8 bom", input: append([]byte("\xef\xbb\xbf"), text...), charset: protoscan.UTF8, want: text},
+		{name: "utf-16le bom", input: append([]byte{0xff, 0xfe}, encodeUTF16(text, false)...), charset: protoscan.UTF16LE, want: text},
+		{name: "utf-16be bom", input: append([]byte{0xfe, 0xff}, encodeUTF16(text, true)...), charset: protoscan.UTF16BE, want: text},
+		{name: "utf-16le", input: encodeUTF16(text, false), charset: protoscan.UTF16LE, want: text},
+		{name: "utf-16be", input: encodeUTF16(text, true), charset: protoscan.UTF16BE, want: text},
+		{name: "windows-1252", input: []byte("na\xefve caf\xe9\nprice: 5\x80\n"), charset: protoscan.Windows1252, want: "naïve café\nprice: 5€\n"},
+	} {
+		for _, f := range protoscantest.Fragmentations() {
+			s := protoscan.New(
+				f.Reader(test.input),
+				protoscan.WithSplit(protoscan.ScanRawLines),
+				protoscan.WithCharsetDetection(),
+			)
+			var b bytes.Buffer
+			for s.Scan() {
+				b.Write(s.Token())
+			}
+			if s.Err() != nil {
+				t.Fatalf("%s: %s: %v", test.name, f.Name, s.Err())
+			}
+			if s.Charset() != test.charset || b.String() != test.want {
+				t.Errorf("%s: %s: expected %q in %s; got %q in %s", test.name, f.Name, test.want, test.charset, b.String(), s.Charset())
+			}
+		}
+	}
+}
+
+// Test that the rune cut by the end of the sniffed head is not taken for
+// the invalid UTF-8.
+func TestCharsetDetectionCutRune(t *testing.T) {
+	text := strings.Repeat("a", protoscan.CharsetSniffSize-1) + "é"
+	s := protoscan.New(strings.NewReader(text), protoscan.WithCharsetDetection())
+	for s.Scan() {
+	}
+	if s.Charset() != protoscan.UTF8 {
+		t.Fatalf("expected UTF-8; got %s", s.Charset())
+	}
+}
+
+// Test that the bytes peeked before a retried read error are kept.
+func TestCharsetDetectionRetry(t *testing.T) {
+	const text = "naïve café\n"
+	input := append([]byte{0xff, 0xfe}, encodeUTF16(text, false)...)
+	s := protoscan.New(
+		&hiccupReader{at: 2, r: &protoscantest.SlowReader{Max: 1, R: bytes.NewReader(input)}},
+		protoscan.WithSplit(protoscan.ScanRawLines),
+		protoscan.WithCharsetDetection(),
+		protoscan.WithRetry(protoscan.RetryPolicy{Attempts: 1, Backoff: protoscan.BackoffPolicy{Initial: time.Microsecond}}),
+	)
+	var b bytes.Buffer
+	for s.Scan() {
+		b.Write(s.Token())
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	if s.Charset() != protoscan.UTF16LE || b.String() != text {
+		t.Fatalf("expected %q in %s; got %q in %s", text, protoscan.UTF16LE, b.String(), s.Charset())
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// Errors of the checkpoints.
+var (
+	ErrCheckpointUnsupported = errors.New("protoscan: checkpoint of transformed or decompressed data stream")
+	ErrBadCheckpoint         = errors.New("protoscan: malformed checkpoint")
+)
+
+// checkpointVersion is the first byte of the checkpoint.
+const checkpointVersion = 1
+
+// Checkpoint returns the serialized position of the carriage, that is the
+// offset just past the last token and the line count, so that the scan may
+// be resumed by the Restore after a restart. The offsets of the transformed
+// or decompressed data streams are not the offsets of the underlying
+// reader, so such streams do not support checkpoints. Within the transaction
+// of the BeginPeek the position is the start of the transaction.
+func (s *Protoscan) Checkpoint() ([]byte, error) {
+	if s.transformer != nil || s.codecs != nil || s.detectCharset {
+		return nil, ErrCheckpointUnsupported
+	}
+	offset, lines := s.offset+int64(s.start), s.lines
+	if s.peeking {
+		offset, lines = s.mark.offset, s.mark.lines
+	}
+	state := []byte{checkpointVersion}
+	state = binary.AppendUvarint(state, uint64(offset))
+	state = binary.AppendUvarint(state, uint64(lines))
+	return state, nil
+}
+
+// Restore returns a new Protoscan resuming the scan at the position saved
+// by the Checkpoint. The reader is seeked to the offset of the position
+// relative to the start of the data stream.
+func Restore(r io.ReadSeeker, state []byte, opts ...Option) (*Protoscan, error) {
+	if len(state) == 0 || state[0] != checkpointVersion {
+		return nil, ErrBadCheckpoint
+	}
+	offset, n := binary.Uvarint(state[1:])
+	if n <= 0 {
+		return nil, ErrBadCheckpoint
+	}
+	lines, m := binary.Uvarint(state[1+n:])
+	if m <= 0 || 1+n+m != len(state) {
+		return nil, ErrBadCheckpoint
+	}
+	if _, err := r.Seek(int64(offset), io.SeekStart); err != nil {
+		return nil, err
+	}
+	s := New(r, opts...)
+	if s.transformer != nil || s.codecs != nil || s.detectCharset {
+		return nil, ErrCheckpointUnsupported
+	}
+	s.offset = int64(offset)
+	s.lines = int(lines)
+	return s, nil
+}
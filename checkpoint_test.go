@@ -0,0 +1,51 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestCheckpoint(t *testing.T) {
+	const text = "ab\ncd\nef\ngh\n"
+	s := protoscan.New(
+		&protoscantest.SlowReader{Max: 5, R: strings.NewReader(text)},
+		protoscan.WithSplit(protoscan.ScanLines),
+		protoscan.WithLineCount('\n'),
+	)
+	for i := 0; i < 2; i++ {
+		if !s.Scan() {
+			t.Fatal(s.Err())
+		}
+	}
+	state, err := s.Checkpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = protoscan.Restore(strings.NewReader(text), state, protoscan.WithSplit(protoscan.ScanLines), protoscan.WithLineCount('\n'))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Scan() {
+		t.Fatal(s.Err())
+	}
+	if string(s.Token()) != "ef" || s.Line() != 3 || s.TokenInfo().Start != 6 {
+		t.Fatalf("unexpected token %q at line %d, offset %d", s.Token(), s.Line(), s.TokenInfo().Start)
+	}
+
+	if _, err := protoscan.Restore(strings.NewReader(text), state[:2]); !errors.Is(err, protoscan.ErrBadCheckpoint) {
+		t.Fatalf("expected ErrBadCheckpoint; got %v", err)
+	}
+	s = protoscan.New(strings.NewReader(text), protoscan.WithTransform(xorTransformer(0)))
+	if _, err := s.Checkpoint(); !errors.Is(err, protoscan.ErrCheckpointUnsupported) {
+		t.Fatalf("expected ErrCheckpointUnsupported; got %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Limits of the chunked transfer coding.
+const (
+	maxChunkLine    = 4096     // Maximum length of the chunk-size line.
+	maxChunkTrailer = 64 << 10 // Maximum length of the trailer section.
+)
+
+// chunk is the chunk of the HTTP chunked transfer coding.
+type chunk struct {
+	size    int // Size of the chunk data.
+	data    int // Offset of the chunk data.
+	ext     int // Offset of the chunk extensions, after the first ';'.
+	extLen  int // Length of the chunk extensions.
+	trailer int // Offset of the trailer section of the last chunk.
+	trlLen  int // Length of the trailer section, without the final CRLF.
+	n       int // Length of the chunk.
+}
+
+// parseChunk parses the chunk at the start of the data. It returns the hint
+// of the bytes missing if the chunk is incomplete.
+func parseChunk(data []byte, atEOF bool) (int, chunk, error) {
+	var c chunk
+	line := bytes.IndexByte(data[:min(len(data), maxChunkLine)], '\n')
+	if line < 0 {
+		if len(data) >= maxChunkLine {
+			return 0, c, fmt.Errorf("%w: chunk-size line too long", ErrProtocolViolation)
+		}
+		return chunkMore(atEOF, 1, c)
+	}
+	if line == 0 || data[line-1] != '\r' {
+		return 0, c, fmt.Errorf("%w: chunk-size line not terminated by CRLF", ErrProtocolViolation)
+	}
+	i := 0
+	for ; i < line-1; i++ {
+		d := unhex(data[i])
+		if d < 0 {
+			break
+		}
+		if i == 15 {
+			return 0, c, fmt.Errorf("%w: chunk size too large", ErrProtocolViolation)
+		}
+		c.size = c.size<<4 | d
+	}
+	if i == 0 {
+		return 0, c, fmt.Errorf("%w: bad chunk size", ErrProtocolViolation)
+	}
+	c.ext = i
+	for ; c.ext < line-1 && (data[c.ext] == ' ' || data[c.ext] == '\t'); c.ext++ {
+	}
+	if c.ext < line-1 {
+		if data[c.ext] != ';' {
+			return 0, c, fmt.Errorf("%w: bad chunk size", ErrProtocolViolation)
+		}
+		c.ext++
+		c.extLen = line - 1 - c.ext
+	}
+	c.data = line + 1
+	if c.size > 0 {
+		c.n = c.data + c.size + 2
+		if len(data) < c.n {
+			return chunkMore(atEOF, c.n-len(data), c)
+		}
+		if data[c.n-2] != '\r' || data[c.n-1] != '\n' {
+			return 0, c, fmt.Errorf("%w: chunk data not terminated by CRLF", ErrProtocolViolation)
+		}
+		return 0, c, nil
+	}
+	// The last chunk is followed by the trailer section and the CRLF.
+	c.trailer = c.data
+	rest := data[c.trailer:min(len(data), c.trailer+maxChunkTrailer+2)]
+	if bytes.HasPrefix(rest, []byte("\r\n")) {
+		c.n = c.trailer + 2
+		return 0, c, nil
+	}
+	end := bytes.Index(rest, []byte("\r\n\r\n"))
+	if end < 0 {
+		if len(rest) == maxChunkTrailer+2 {
+			return 0, c, fmt.Errorf("%w: chunk trailer too long", ErrProtocolViolation)
+		}
+		return chunkMore(atEOF, 1, c)
+	}
+	c.trlLen = end + 2
+	c.n = c.trailer + end + 4
+	return 0, c, nil
+}
+
+// chunkMore returns the hint of the incomplete chunk, or the io.ErrUnexpectedEOF
+// at EOF.
+func chunkMore(atEOF bool, hint int, c chunk) (int, chunk, error) {
+	if atEOF {
+		return 0, c, io.ErrUnexpectedEOF
+	}
+	return hint, c, nil
+}
+
+// unhex returns the value of the hex digit, or -1.
+func unhex(b byte) int {
+	switch {
+	case '0' <= b && b <= '9':
+		return int(b - '0')
+	case 'a' <= b && b <= 'f':
+		return int(b - 'a' + 10)
+	case 'A' <= b && b <= 'F':
+		return int(b - 'A' + 10)
+	}
+	return -1
+}
+
+// ScanHTTPChunkFrames is the split function for the Protoscan with the
+// WithSplitContext option which returns each chunk of the HTTP chunked
+// transfer coding as a token, unmodified, so that the intermediaries may
+// preserve or validate the chunk extensions and the trailer section. The
+// Indexes of each chunk are the offset and the length in the token of the
+// chunk data, of the chunk extensions after the first ';', and of the
+// trailer section of the last chunk including the CRLF of its last field.
+// The last chunk is delivered together with the FinalToken, stopping the
+// scan at the end of the body.
+func ScanHTTPChunkFrames(ctx *SplitContext, data []byte, atEOF bool) (int, int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, 0, nil, io.ErrUnexpectedEOF
+	}
+	hint, c, err := parseChunk(data, atEOF)
+	if hint > 0 || err != nil {
+		return hint, 0, nil, err
+	}
+	ctx.Indexes = append(ctx.Indexes, c.data, c.size, c.ext, c.extLen, c.trailer, c.trlLen)
+	if c.size == 0 {
+		return 0, c.n, data[:c.n], FinalToken
+	}
+	return 0, c.n, data[:c.n], nil
+}
+
+// ScanHTTPChunks is a split function for a Protoscan that returns the data
+// of each chunk of the HTTP chunked transfer coding as a token, decoding
+// the body. The chunk extensions and the trailer section are consumed. The
+// last chunk is delivered as the empty token together with the FinalToken,
+// stopping the scan at the end of the body.
+func ScanHTTPChunks(data []byte, atEOF bool) (int, int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, 0, nil, io.ErrUnexpectedEOF
+	}
+	hint, c, err := parseChunk(data, atEOF)
+	if hint > 0 || err != nil {
+		return hint, 0, nil, err
+	}
+	token := data[c.data : c.data+c.size]
+	if c.size == 0 {
+		return 0, c.n, token, FinalToken
+	}
+	return 0, c.n, token, nil
+}
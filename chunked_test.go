@@ -0,0 +1,93 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanHTTPChunkFrames(t *testing.T) {
+	body := "4;name=\"v\"\r\nWiki\r\n" +
+		"5\r\npedia\r\n" +
+		"0;last\r\nExpires: never\r\nDigest: x\r\n\r\n"
+	want := []string{
+		`"4;name=\"v\"\r\nWiki\r\n" data="Wiki" ext="name=\"v\"" trailer=""`,
+		`"5\r\npedia\r\n" data="pedia" ext="" trailer=""`,
+		`"0;last\r\nExpires: never\r\nDigest: x\r\n\r\n" data="" ext="last" trailer="Expires: never\r\nDigest: x\r\n"`,
+	}
+	for _, f := range protoscantest.Fragmentations() {
+		s := protoscan.New(
+			f.Reader([]byte(body+"next")),
+			protoscan.WithSplitContext(protoscan.ScanHTTPChunkFrames),
+		)
+		var got []string
+		for s.Scan() {
+			tok, ix := s.Token(), s.Indexes()
+			got = append(got, fmt.Sprintf("%q data=%q ext=%q trailer=%q", tok,
+				tok[ix[0]:ix[0]+ix[1]], tok[ix[2]:ix[2]+ix[3]], tok[ix[4]:ix[4]+ix[5]]))
+		}
+		if s.Err() != nil {
+			t.Fatalf("%s: %v", f.Name, s.Err())
+		}
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Fatalf("%s: expected chunks\n%s\ngot\n%s", f.Name, strings.Join(want, "\n"), strings.Join(got, "\n"))
+		}
+	}
+}
+
+func TestScanHTTPChunkFramesErrors(t *testing.T) {
+	for _, test := range []struct {
+		body string
+		err  error
+	}{
+		{"", io.ErrUnexpectedEOF},
+		{"4\r\nWi", io.ErrUnexpectedEOF},
+		{"0\r\nExpires: never\r\n", io.ErrUnexpectedEOF},
+		{"x\r\n", protoscan.ErrProtocolViolation},
+		{"4\nWiki\r\n", protoscan.ErrProtocolViolation},
+		{"4\r\nWikiXX", protoscan.ErrProtocolViolation},
+		{"4 x\r\nWiki\r\n", protoscan.ErrProtocolViolation},
+		{"1000000000000000\r\n", protoscan.ErrProtocolViolation},
+		{strings.Repeat("0", 5000), protoscan.ErrProtocolViolation},
+	} {
+		s := protoscan.New(
+			strings.NewReader(test.body),
+			protoscan.WithSplitContext(protoscan.ScanHTTPChunkFrames),
+		)
+		for s.Scan() {
+		}
+		if !errors.Is(s.Err(), test.err) {
+			t.Errorf("%.20q: expected error %v; got %v", test.body, test.err, s.Err())
+		}
+	}
+}
+
+func TestScanHTTPChunks(t *testing.T) {
+	protoscantest.TestSplitFunc(t, protoscan.ScanHTTPChunks, []protoscantest.Case{
+		{Name: "empty", Input: "", Err: io.ErrUnexpectedEOF},
+		{
+			Name:   "chunks",
+			Input:  "4;name=\"v\"\r\nWiki\r\n5\r\npedia\r\n0\r\nExpires: never\r\n\r\nnext",
+			Tokens: []string{"Wiki", "pedia", ""},
+		},
+		{Name: "last chunk", Input: "0\r\n\r\n", Tokens: []string{""}},
+		{Name: "short chunk", Input: "4\r\nWi", Err: io.ErrUnexpectedEOF},
+		{Name: "short trailer", Input: "0\r\nExpires: never\r\n", Err: io.ErrUnexpectedEOF},
+		{Name: "bad size", Input: "x\r\n", Err: protoscan.ErrProtocolViolation},
+		{Name: "bad data", Input: "4\r\nWikiXX", Err: protoscan.ErrProtocolViolation},
+	})
+}
+
+func FuzzScanHTTPChunks(f *testing.F) {
+	f.Add([]byte("4\r\nWiki\r\n0;x\r\nA: b\r\n\r\n"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanHTTPChunks))
+}
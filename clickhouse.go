@@ -0,0 +1,853 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ClickHouseAddendum is the packet type in the Indexes of the addendum the
+// client sends after its Hello packet, which has no packet type.
+const ClickHouseAddendum = -1
+
+// Range of the protocol revisions supported by the ScanClickHouseNative.
+const (
+	ClickHouseMinRevision = 54429
+	ClickHouseMaxRevision = 54460
+)
+
+// Revisions of the ClickHouse native protocol changing the packets.
+const (
+	chRevServerTimezone   = 54058
+	chRevQuotaKeyInfo     = 54060
+	chRevDisplayName      = 54372
+	chRevVersionPatch     = 54401
+	chRevWriteInfo        = 54420
+	chRevInterserver      = 54441
+	chRevOpenTelemetry    = 54442
+	chRevForwardedFor     = 54443
+	chRevReferer          = 54447
+	chRevDistributedDepth = 54448
+	chRevQueryStartTime   = 54449
+	chRevParallelReplicas = 54453
+	chRevCustomSerial     = 54454
+	chRevAddendum         = 54458
+	chRevParameters       = 54459
+	chRevElapsed          = 54460
+)
+
+// Packet types of the ClickHouse native protocol.
+const (
+	chClientHello  = 0
+	chClientQuery  = 1
+	chClientData   = 2
+	chClientCancel = 3
+	chClientPing   = 4
+	chClientScalar = 7
+
+	chServerHello         = 0
+	chServerData          = 1
+	chServerException     = 2
+	chServerProgress      = 3
+	chServerPong          = 4
+	chServerEndOfStream   = 5
+	chServerProfileInfo   = 6
+	chServerTotals        = 7
+	chServerExtremes      = 8
+	chServerLog           = 10
+	chServerTableColumns  = 11
+	chServerPartUUIDs     = 12
+	chServerReadTask      = 13
+	chServerProfileEvents = 14
+	chServerTimezone      = 17
+)
+
+// Compression methods of the compressed blocks.
+const (
+	chMethodNone = 0x02
+	chMethodLZ4  = 0x82
+)
+
+// chMaxBlock is the maximum uncompressed size of the compressed block.
+const chMaxBlock = 1 << 30
+
+// ClickHouseConfig configures the ScanClickHouseNative.
+type ClickHouseConfig struct {
+	// Server tells the packets are sent by the server, otherwise by the
+	// client.
+	Server bool
+	// Revision is the negotiated protocol revision, the lower of the
+	// revisions of the client and the server, taken from the Hello packet
+	// if 0.
+	Revision uint64
+	// Compressed tells the server compresses the data blocks, as the
+	// client requested by its Query. The compression of the blocks of the
+	// client is taken from its Query packets.
+	Compressed bool
+}
+
+// chState is the state of the ScanClickHouseNative kept in the
+// SplitContext.
+type chState struct {
+	rev        uint64
+	compressed bool
+	addendum   bool // Whether the addendum follows the Hello packet.
+}
+
+// chStateKey is the key of the chState in the SplitContext.
+type chStateKey struct{}
+
+// ScanClickHouseNative returns the split function for the Protoscan with
+// the WithSplitContext option which returns each packet of the ClickHouse
+// native TCP protocol sent by one side of the connection as a token,
+// without decoding the column data: the fields of the packets are walked
+// by the protocol revision, the data blocks by the types of their columns,
+// decompressing the LZ4 compressed blocks. The Indexes of the token are its
+// packet type. The revisions from the ClickHouseMinRevision to the
+// ClickHouseMaxRevision are supported; the packet types, the column types
+// and the compression methods not supported stop the scan with the
+// ErrProtocolViolation.
+func ScanClickHouseNative(cfg ClickHouseConfig) SplitCtxFunc {
+	return func(ctx *SplitContext, data []byte, atEOF bool) (int, int, []byte, error) {
+		st, _ := ctx.Value(chStateKey{}).(*chState)
+		if st == nil {
+			st = &chState{rev: cfg.Revision, compressed: cfg.Compressed}
+			ctx.SetValue(chStateKey{}, st)
+		}
+		if len(data) == 0 {
+			if atEOF {
+				return 0, 0, nil, nil
+			}
+			return 1, 0, nil, nil
+		}
+		p := chParser{cursor: cursor{data: data, proto: "ClickHouse"}, rev: st.rev}
+		typ, err := st.packet(ctx, &p, cfg.Server)
+		if err == errShort {
+			if atEOF {
+				return 0, 0, nil, io.ErrUnexpectedEOF
+			}
+			return p.need, 0, nil, nil
+		}
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		ctx.Indexes = append(ctx.Indexes, typ)
+		return 0, p.pos, data[:p.pos], nil
+	}
+}
+
+// packet walks the packet, updating the state by the Hello and the Query.
+func (st *chState) packet(ctx *SplitContext, p *chParser, server bool) (int, error) {
+	if st.addendum {
+		// The quota key.
+		if err := p.skipString(); err != nil {
+			return 0, err
+		}
+		st.addendum = false
+		return ClickHouseAddendum, nil
+	}
+	typ, err := p.uvarint()
+	if err != nil {
+		return 0, err
+	}
+	if typ != chClientHello && st.rev == 0 {
+		return 0, fmt.Errorf("%w: ClickHouse packet %d before Hello", ErrProtocolViolation, typ)
+	}
+	if server {
+		err = st.serverPacket(ctx, p, typ)
+	} else {
+		err = st.clientPacket(ctx, p, typ)
+	}
+	return int(typ), err
+}
+
+// hello walks the name, the version and the revision of the Hello packet,
+// setting the revision if unknown.
+func (st *chState) hello(p *chParser) error {
+	if err := p.skipString(); err != nil {
+		return err
+	}
+	if err := p.skipUvarints(2); err != nil {
+		return err
+	}
+	rev, err := p.uvarint()
+	if err != nil {
+		return err
+	}
+	if st.rev == 0 {
+		st.rev = rev
+	}
+	if st.rev < ClickHouseMinRevision || st.rev > ClickHouseMaxRevision {
+		return fmt.Errorf("%w: unsupported ClickHouse revision %d", ErrProtocolViolation, st.rev)
+	}
+	p.rev = st.rev
+	return nil
+}
+
+// clientPacket walks the packet of the client.
+func (st *chState) clientPacket(ctx *SplitContext, p *chParser, typ uint64) error {
+	switch typ {
+	case chClientHello:
+		if err := st.hello(p); err != nil {
+			return err
+		}
+		// The database, the user and the password.
+		if err := p.skipStrings(3); err != nil {
+			return err
+		}
+		st.addendum = st.rev >= chRevAddendum
+		return nil
+	case chClientQuery:
+		compressed, err := p.query()
+		if err == nil {
+			st.compressed = compressed
+		}
+		return err
+	case chClientData, chClientScalar:
+		if err := p.skipString(); err != nil {
+			return err
+		}
+		return p.skipBlock(ctx, st.compressed)
+	case chClientCancel, chClientPing:
+		return nil
+	}
+	return fmt.Errorf("%w: unsupported ClickHouse client packet %d", ErrProtocolViolation, typ)
+}
+
+// serverPacket walks the packet of the server.
+func (st *chState) serverPacket(ctx *SplitContext, p *chParser, typ uint64) error {
+	switch typ {
+	case chServerHello:
+		if err := st.hello(p); err != nil {
+			return err
+		}
+		if p.rev >= chRevServerTimezone {
+			if err := p.skipString(); err != nil {
+				return err
+			}
+		}
+		if p.rev >= chRevDisplayName {
+			if err := p.skipString(); err != nil {
+				return err
+			}
+		}
+		if p.rev >= chRevVersionPatch {
+			return p.skipUvarints(1)
+		}
+		return nil
+	case chServerData, chServerTotals, chServerExtremes:
+		if err := p.skipString(); err != nil {
+			return err
+		}
+		return p.skipBlock(ctx, st.compressed)
+	case chServerLog, chServerProfileEvents:
+		if err := p.skipString(); err != nil {
+			return err
+		}
+		return p.skipBlock(ctx, false)
+	case chServerException:
+		for {
+			// The code, the name, the message and the stack trace.
+			if err := p.skip(4); err != nil {
+				return err
+			}
+			if err := p.skipStrings(3); err != nil {
+				return err
+			}
+			nested, err := p.readByte()
+			if err != nil || nested == 0 {
+				return err
+			}
+		}
+	case chServerProgress:
+		n := 3
+		if p.rev >= chRevWriteInfo {
+			n += 2
+		}
+		if p.rev >= chRevElapsed {
+			n++
+		}
+		return p.skipUvarints(n)
+	case chServerProfileInfo:
+		// The rows, the blocks, the bytes, the applied limit, the rows
+		// before limit and whether they were calculated.
+		if err := p.skipUvarints(3); err != nil {
+			return err
+		}
+		if err := p.skip(1); err != nil {
+			return err
+		}
+		if err := p.skipUvarints(1); err != nil {
+			return err
+		}
+		return p.skip(1)
+	case chServerTableColumns:
+		return p.skipStrings(2)
+	case chServerPartUUIDs:
+		n, err := p.uvarint()
+		if err != nil {
+			return err
+		}
+		if n > math.MaxInt/16 {
+			return fmt.Errorf("%w: ClickHouse %d part UUIDs", ErrProtocolViolation, n)
+		}
+		return p.skip(16 * n)
+	case chServerPong, chServerEndOfStream, chServerReadTask:
+		return nil
+	case chServerTimezone:
+		return p.skipString()
+	}
+	return fmt.Errorf("%w: unsupported ClickHouse server packet %d", ErrProtocolViolation, typ)
+}
+
+// chParser walks the packet of the ClickHouse native protocol.
+type chParser struct {
+	cursor
+	rev uint64 // The protocol revision.
+}
+
+// uint64 returns the next little-endian 8-byte integer.
+func (p *chParser) uint64() (uint64, error) {
+	if err := p.skip(8); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(p.data[p.pos-8:]), nil
+}
+
+// uvarint returns the next uvarint.
+func (p *chParser) uvarint() (uint64, error) {
+	v, n := binary.Uvarint(p.data[p.pos:])
+	if n < 0 {
+		return 0, fmt.Errorf("%w: ClickHouse varint overflow", ErrProtocolViolation)
+	}
+	if n == 0 {
+		p.need = 1
+		return 0, errShort
+	}
+	p.pos += n
+	return v, nil
+}
+
+// skipUvarints skips n uvarints.
+func (p *chParser) skipUvarints(n int) error {
+	for ; n > 0; n-- {
+		if _, err := p.uvarint(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// string returns the next string of the uvarint length.
+func (p *chParser) string() (string, error) {
+	n, err := p.uvarint()
+	if err != nil {
+		return "", err
+	}
+	if err := p.skip(n); err != nil {
+		return "", err
+	}
+	return string(p.data[p.pos-int(n) : p.pos]), nil
+}
+
+// skipString skips the next string of the uvarint length.
+func (p *chParser) skipString() error {
+	n, err := p.uvarint()
+	if err != nil {
+		return err
+	}
+	return p.skip(n)
+}
+
+// skipStrings skips n strings.
+func (p *chParser) skipStrings(n uint64) error {
+	for ; n > 0; n-- {
+		if err := p.skipString(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// query walks the Query packet, returning whether the client requested the
+// compression.
+func (p *chParser) query() (bool, error) {
+	// The query ID.
+	if err := p.skipString(); err != nil {
+		return false, err
+	}
+	if err := p.clientInfo(); err != nil {
+		return false, err
+	}
+	if err := p.settings(); err != nil {
+		return false, err
+	}
+	if p.rev >= chRevInterserver {
+		if err := p.skipString(); err != nil {
+			return false, err
+		}
+	}
+	// The stage, the compression and the query.
+	if err := p.skipUvarints(1); err != nil {
+		return false, err
+	}
+	compression, err := p.uvarint()
+	if err != nil {
+		return false, err
+	}
+	if err := p.skipString(); err != nil {
+		return false, err
+	}
+	if p.rev >= chRevParameters {
+		if err := p.settings(); err != nil {
+			return false, err
+		}
+	}
+	return compression != 0, nil
+}
+
+// clientInfo walks the client info of the Query packet.
+func (p *chParser) clientInfo() error {
+	kind, err := p.readByte()
+	if err != nil || kind == 0 {
+		return err
+	}
+	// The initial user, query ID and address.
+	if err := p.skipStrings(3); err != nil {
+		return err
+	}
+	if p.rev >= chRevQueryStartTime {
+		if err := p.skip(8); err != nil {
+			return err
+		}
+	}
+	iface, err := p.readByte()
+	if err != nil {
+		return err
+	}
+	switch iface {
+	case 1:
+		// The OS user, the client hostname and name, and version.
+		if err := p.skipStrings(3); err != nil {
+			return err
+		}
+		if err := p.skipUvarints(3); err != nil {
+			return err
+		}
+	case 2:
+		// The HTTP method and user agent, forwarded for and referer.
+		if err := p.skip(1); err != nil {
+			return err
+		}
+		n := uint64(1)
+		if p.rev >= chRevForwardedFor {
+			n++
+		}
+		if p.rev >= chRevReferer {
+			n++
+		}
+		if err := p.skipStrings(n); err != nil {
+			return err
+		}
+	}
+	if p.rev >= chRevQuotaKeyInfo {
+		if err := p.skipString(); err != nil {
+			return err
+		}
+	}
+	if p.rev >= chRevDistributedDepth {
+		if err := p.skipUvarints(1); err != nil {
+			return err
+		}
+	}
+	if p.rev >= chRevVersionPatch && iface == 1 {
+		if err := p.skipUvarints(1); err != nil {
+			return err
+		}
+	}
+	if p.rev >= chRevOpenTelemetry {
+		trace, err := p.readByte()
+		if err != nil {
+			return err
+		}
+		if trace != 0 {
+			// The trace and span IDs, the trace state and flags.
+			if err := p.skip(16 + 8); err != nil {
+				return err
+			}
+			if err := p.skipString(); err != nil {
+				return err
+			}
+			if err := p.skip(1); err != nil {
+				return err
+			}
+		}
+	}
+	if p.rev >= chRevParallelReplicas {
+		return p.skipUvarints(3)
+	}
+	return nil
+}
+
+// settings walks the settings serialized as the strings up to the empty
+// name.
+func (p *chParser) settings() error {
+	for {
+		n, err := p.uvarint()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+		if err := p.skip(n); err != nil {
+			return err
+		}
+		// The flags and the value.
+		if err := p.skipUvarints(1); err != nil {
+			return err
+		}
+		if err := p.skipString(); err != nil {
+			return err
+		}
+	}
+}
+
+// skipBlock skips the data block, decompressing the compressed block into
+// the Scratch of the SplitContext.
+func (p *chParser) skipBlock(ctx *SplitContext, compressed bool) error {
+	if !compressed {
+		return p.block()
+	}
+	ctx.Scratch = ctx.Scratch[:0]
+	for {
+		var err error
+		ctx.Scratch, err = p.frame(ctx.Scratch)
+		if err != nil {
+			return err
+		}
+		b := chParser{cursor: cursor{data: ctx.Scratch, proto: "ClickHouse"}, rev: p.rev}
+		err = b.block()
+		if err == errShort {
+			// The block continues in the next frame.
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if b.pos != len(b.data) {
+			return fmt.Errorf("%w: ClickHouse block shorter than its frames", ErrProtocolViolation)
+		}
+		return nil
+	}
+}
+
+// frame appends the data of the compressed frame to the dst.
+func (p *chParser) frame(dst []byte) ([]byte, error) {
+	// The checksum, the method, the compressed and the data sizes.
+	if err := p.skip(16 + 9); err != nil {
+		return dst, err
+	}
+	header := p.data[p.pos-9 : p.pos]
+	method := header[0]
+	size := binary.LittleEndian.Uint32(header[1:])
+	n := binary.LittleEndian.Uint32(header[5:])
+	if size < 9 || n > chMaxBlock || len(dst)+int(n) > chMaxBlock {
+		return dst, fmt.Errorf("%w: bad ClickHouse compressed frame", ErrProtocolViolation)
+	}
+	if err := p.skip(uint64(size) - 9); err != nil {
+		return dst, err
+	}
+	src := p.data[p.pos-int(size)+9 : p.pos]
+	switch method {
+	case chMethodNone:
+		if len(src) != int(n) {
+			return dst, fmt.Errorf("%w: bad ClickHouse uncompressed frame", ErrProtocolViolation)
+		}
+		return append(dst, src...), nil
+	case chMethodLZ4:
+		return lz4Decompress(dst, src, int(n))
+	}
+	return dst, fmt.Errorf("%w: unsupported ClickHouse compression method 0x%02x", ErrProtocolViolation, method)
+}
+
+// block walks the data block: the block info, the columns and the rows,
+// and the name, the type and the data of each of the columns.
+func (p *chParser) block() error {
+	for {
+		field, err := p.uvarint()
+		if err != nil {
+			return err
+		}
+		switch field {
+		case 0:
+		case 1:
+			// Is overflows.
+			err = p.skip(1)
+		case 2:
+			// Bucket number.
+			err = p.skip(4)
+		default:
+			return fmt.Errorf("%w: bad ClickHouse block info field %d", ErrProtocolViolation, field)
+		}
+		if err != nil {
+			return err
+		}
+		if field == 0 {
+			break
+		}
+	}
+	columns, err := p.uvarint()
+	if err != nil {
+		return err
+	}
+	rows, err := p.uvarint()
+	if err != nil {
+		return err
+	}
+	for ; columns > 0; columns-- {
+		if err := p.skipString(); err != nil {
+			return err
+		}
+		typ, err := p.string()
+		if err != nil {
+			return err
+		}
+		if p.rev >= chRevCustomSerial {
+			custom, err := p.readByte()
+			if err != nil {
+				return err
+			}
+			if custom != 0 {
+				return fmt.Errorf("%w: unsupported ClickHouse custom serialization", ErrProtocolViolation)
+			}
+		}
+		if rows == 0 {
+			continue
+		}
+		if err := p.prefix(typ); err != nil {
+			return err
+		}
+		if err := p.column(typ, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chTypeArgs splits the type into its name and the arguments in the
+// parentheses, the argument names of the Tuple elements stripped.
+func chTypeArgs(typ string) (string, []string) {
+	typ = strings.TrimSpace(typ)
+	i := strings.IndexByte(typ, '(')
+	if i < 0 || !strings.HasSuffix(typ, ")") {
+		return typ, nil
+	}
+	name, inner := typ[:i], typ[i+1:len(typ)-1]
+	var args []string
+	depth, start := 0, 0
+	var quote byte
+	for j := 0; j <= len(inner); j++ {
+		if j < len(inner) {
+			c := inner[j]
+			switch {
+			case quote != 0:
+				if c == '\\' {
+					j++
+				} else if c == quote {
+					quote = 0
+				}
+				continue
+			case c == '\'' || c == '`' || c == '"':
+				quote = c
+				continue
+			case c == '(':
+				depth++
+				continue
+			case c == ')':
+				depth--
+				continue
+			case c != ',' || depth > 0:
+				continue
+			}
+		}
+		arg := strings.TrimSpace(inner[start:j])
+		if name == "Tuple" || name == "Nested" {
+			// Strip the element name.
+			if k := strings.IndexByte(arg, ' '); k > 0 && !strings.ContainsAny(arg[:k], "(") {
+				arg = strings.TrimSpace(arg[k+1:])
+			}
+		}
+		args = append(args, arg)
+		start = j + 1
+	}
+	return name, args
+}
+
+// chFixedSizes are the sizes of the values of the fixed-size types.
+var chFixedSizes = map[string]int{
+	"UInt8": 1, "Int8": 1, "Bool": 1, "Enum8": 1, "Nothing": 1,
+	"UInt16": 2, "Int16": 2, "Enum16": 2, "Date": 2,
+	"UInt32": 4, "Int32": 4, "Float32": 4, "DateTime": 4, "Date32": 4, "IPv4": 4, "Decimal32": 4,
+	"UInt64": 8, "Int64": 8, "Float64": 8, "DateTime64": 8, "Decimal64": 8,
+	"IntervalNanosecond": 8, "IntervalMicrosecond": 8, "IntervalMillisecond": 8,
+	"IntervalSecond": 8, "IntervalMinute": 8, "IntervalHour": 8, "IntervalDay": 8,
+	"IntervalWeek": 8, "IntervalMonth": 8, "IntervalQuarter": 8, "IntervalYear": 8,
+	"UInt128": 16, "Int128": 16, "UUID": 16, "IPv6": 16, "Decimal128": 16,
+	"UInt256": 32, "Int256": 32, "Decimal256": 32,
+}
+
+// chAliases are the types serialized as other types.
+var chAliases = map[string]string{
+	"Point":        "Tuple(Float64, Float64)",
+	"Ring":         "Array(Point)",
+	"LineString":   "Array(Point)",
+	"Polygon":      "Array(Ring)",
+	"MultiPolygon": "Array(Polygon)",
+}
+
+// prefix walks the prefix of the column of the type, written before its
+// data: the key version of the LowCardinality.
+func (p *chParser) prefix(typ string) error {
+	name, args := chTypeArgs(typ)
+	if alias, ok := chAliases[name]; ok {
+		return p.prefix(alias)
+	}
+	switch name {
+	case "LowCardinality":
+		return p.skip(8)
+	case "Array", "Nullable", "SimpleAggregateFunction", "Map", "Tuple", "Nested":
+		if name == "SimpleAggregateFunction" && len(args) == 2 {
+			args = args[1:]
+		}
+		for _, arg := range args {
+			if err := p.prefix(arg); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// column walks the n values of the column of the type.
+func (p *chParser) column(typ string, n uint64) error {
+	name, args := chTypeArgs(typ)
+	if size, ok := chFixedSizes[name]; ok {
+		if n > math.MaxInt/uint64(size) {
+			return fmt.Errorf("%w: ClickHouse column of %d rows", ErrProtocolViolation, n)
+		}
+		return p.skip(n * uint64(size))
+	}
+	if alias, ok := chAliases[name]; ok {
+		return p.column(alias, n)
+	}
+	switch {
+	case name == "String":
+		return p.skipStrings(n)
+	case name == "FixedString" && len(args) == 1:
+		size, err := strconv.ParseUint(args[0], 10, 32)
+		if err != nil || size > 0 && n > math.MaxInt/size {
+			return fmt.Errorf("%w: bad ClickHouse type %q", ErrProtocolViolation, typ)
+		}
+		return p.skip(n * size)
+	case name == "Decimal" && len(args) >= 1:
+		precision, err := strconv.Atoi(args[0])
+		if err != nil || precision < 1 || precision > 76 {
+			return fmt.Errorf("%w: bad ClickHouse type %q", ErrProtocolViolation, typ)
+		}
+		size := uint64(32)
+		switch {
+		case precision <= 9:
+			size = 4
+		case precision <= 18:
+			size = 8
+		case precision <= 38:
+			size = 16
+		}
+		return p.skip(n * size)
+	case name == "Nullable" && len(args) == 1:
+		if err := p.skip(n); err != nil {
+			return err
+		}
+		return p.column(args[0], n)
+	case name == "SimpleAggregateFunction" && len(args) == 2:
+		return p.column(args[1], n)
+	case (name == "Array" || name == "Map" || name == "Nested") && len(args) > 0:
+		if n > math.MaxInt/8 {
+			return fmt.Errorf("%w: ClickHouse column of %d rows", ErrProtocolViolation, n)
+		}
+		if err := p.skip(8 * n); err != nil {
+			return err
+		}
+		// The last offset is the count of the nested values.
+		total := binary.LittleEndian.Uint64(p.data[p.pos-8:])
+		if name == "Array" && len(args) == 1 {
+			return p.column(args[0], total)
+		}
+		for _, arg := range args {
+			if err := p.column(arg, total); err != nil {
+				return err
+			}
+		}
+		return nil
+	case name == "Tuple" && len(args) > 0:
+		for _, arg := range args {
+			if err := p.column(arg, n); err != nil {
+				return err
+			}
+		}
+		return nil
+	case name == "LowCardinality" && len(args) == 1:
+		return p.lowCardinality(args[0], n)
+	}
+	return fmt.Errorf("%w: unsupported ClickHouse type %q", ErrProtocolViolation, typ)
+}
+
+// lowCardinality walks the n values of the LowCardinality column of the
+// type: the granules of the serialization type, the additional keys of the
+// dictionary and the indexes.
+func (p *chParser) lowCardinality(typ string, n uint64) error {
+	if name, args := chTypeArgs(typ); name == "Nullable" && len(args) == 1 {
+		// The dictionary keeps the null as the default value.
+		typ = args[0]
+	}
+	for n > 0 {
+		flags, err := p.uint64()
+		if err != nil {
+			return err
+		}
+		const hasAdditionalKeys = 1 << 9
+		if flags&hasAdditionalKeys != 0 {
+			keys, err := p.uint64()
+			if err != nil {
+				return err
+			}
+			if err := p.column(typ, keys); err != nil {
+				return err
+			}
+		}
+		rows, err := p.uint64()
+		if err != nil {
+			return err
+		}
+		if rows == 0 || rows > n {
+			return fmt.Errorf("%w: bad ClickHouse LowCardinality granule of %d rows", ErrProtocolViolation, rows)
+		}
+		size := uint64(1) << (flags & 0xff)
+		if flags&0xff > 3 {
+			return fmt.Errorf("%w: bad ClickHouse LowCardinality index type", ErrProtocolViolation)
+		}
+		if err := p.skip(rows * size); err != nil {
+			return err
+		}
+		n -= rows
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+// chPacket builds the packet of the ClickHouse native protocol from the
+// uvarints, the strings and the raw bytes.
+func chPacket(fields ...interface{}) string {
+	var b []byte
+	for _, f := range fields {
+		switch f := f.(type) {
+		case int:
+			b = binary.AppendUvarint(b, uint64(f))
+		case string:
+			b = binary.AppendUvarint(b, uint64(len(f)))
+			b = append(b, f...)
+		case []byte:
+			b = append(b, f...)
+		}
+	}
+	return string(b)
+}
+
+// chUint64s returns the little-endian 8-byte integers.
+func chUint64s(v ...uint64) []byte {
+	var b []byte
+	for _, v := range v {
+		b = binary.LittleEndian.AppendUint64(b, v)
+	}
+	return b
+}
+
+// chFrame returns the compressed frame of the data as the LZ4 block of
+// literals if lz4, otherwise as the uncompressed frame.
+func chFrame(data string, lz4 bool) []byte {
+	method, payload := byte(0x02), []byte(data)
+	if lz4 {
+		method, payload = 0x82, lz4Literals(data)
+	}
+	b := make([]byte, 16, 16+9+len(payload))
+	b = append(b, method)
+	b = binary.LittleEndian.AppendUint32(b, uint32(9+len(payload)))
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(data)))
+	return append(b, payload...)
+}
+
+// lz4Literals returns the LZ4 block of the literals of the data.
+func lz4Literals(data string) []byte {
+	n := len(data)
+	b := []byte{byte(min(n, 15) << 4)}
+	if n >= 15 {
+		for n -= 15; n >= 255; n -= 255 {
+			b = append(b, 255)
+		}
+		b = append(b, byte(n))
+	}
+	return append(b, data...)
+}
+
+// chBlockInfo is the default block info of the data block.
+var chBlockInfo = []byte{1, 0, 2, 0xff, 0xff, 0xff, 0xff, 0}
+
+func TestScanClickHouseNative(t *testing.T) {
+	block := chPacket(chBlockInfo, 1, 2, "s", "String", []byte{0}, "abc", strings.Repeat("x", 300))
+	client := chPacket(0, "cli", 23, 8, 54460, "default", "default", "") +
+		chPacket("") +
+		chPacket(1, "qid",
+			[]byte{1}, "default", "qid", "127.0.0.1:9000", chUint64s(0), []byte{1},
+			"os", "host", "cli", 23, 8, 54460, "", 0, 0, []byte{0}, 0, 0, 0,
+			"max_threads", 0, "4", "",
+			"", 2, 1, "INSERT INTO t FORMAT Native", "") +
+		chPacket(2, "", chFrame(chPacket(chBlockInfo, 0, 0), false)) +
+		chPacket(2, "", chFrame(block[:10], false), chFrame(block[10:], true)) +
+		chPacket(4)
+	server := chPacket(0, "ClickHouse", 23, 8, 54460, "UTC", "srv", 1) +
+		chPacket(1, "", chBlockInfo, 5, 2,
+			"a", "Array(Nullable(String))", []byte{0}, chUint64s(1, 3), []byte{0, 1, 0}, "x", "", "y",
+			"l", "LowCardinality(Nullable(String))", []byte{0}, chUint64s(1, 0x200, 2), "a", "b", chUint64s(2), []byte{0, 1},
+			"t", "Tuple(a UInt8, b FixedString(2))", []byte{0}, []byte{1, 2}, []byte("abcd"),
+			"m", "Map(String, UInt64)", []byte{0}, chUint64s(1, 1), "k", chUint64s(7),
+			"d", "Decimal(10, 2)", []byte{0}, chUint64s(100, 200)) +
+		chPacket(3, 1, 2, 3, 4, 5, 6) +
+		chPacket(6, 1, 1, 8, []byte{0}, 0, []byte{0}) +
+		chPacket(11, "", "columns format version: 1") +
+		chPacket(2, []byte{1, 0, 0, 0}, "DB::Exception", "outer", "", []byte{1}, []byte{2, 0, 0, 0}, "DB::Exception", "inner", "", []byte{0}) +
+		chPacket(5)
+	for _, test := range []struct {
+		name   string
+		cfg    protoscan.ClickHouseConfig
+		stream string
+		types  []int
+	}{
+		{"client", protoscan.ClickHouseConfig{}, client, []int{0, protoscan.ClickHouseAddendum, 1, 2, 2, 4}},
+		{"server", protoscan.ClickHouseConfig{Server: true}, server, []int{0, 1, 3, 6, 11, 2, 5}},
+	} {
+		for _, f := range protoscantest.Fragmentations() {
+			s := protoscan.New(f.Reader([]byte(test.stream)), protoscan.WithSplitContext(protoscan.ScanClickHouseNative(test.cfg)))
+			var types []int
+			var all string
+			for s.Scan() {
+				types = append(types, s.Indexes()[0])
+				all += string(s.Token())
+			}
+			if s.Err() != nil {
+				t.Fatalf("%s %s: %v", test.name, f.Name, s.Err())
+			}
+			if fmt.Sprint(types) != fmt.Sprint(test.types) {
+				t.Fatalf("%s %s: expected packets %v; got %v", test.name, f.Name, test.types, types)
+			}
+			if all != test.stream {
+				t.Fatalf("%s %s: tokens do not cover the stream", test.name, f.Name)
+			}
+		}
+	}
+}
+
+func TestScanClickHouseNativeErrors(t *testing.T) {
+	hello := chPacket(0, "ClickHouse", 23, 8, 54460, "UTC", "srv", 1)
+	for _, test := range []struct {
+		name   string
+		stream string
+		err    error
+	}{
+		{"before hello", chPacket(4), protoscan.ErrProtocolViolation},
+		{"old revision", chPacket(0, "ClickHouse", 1, 1, 54000), protoscan.ErrProtocolViolation},
+		{"unknown packet", hello + chPacket(99), protoscan.ErrProtocolViolation},
+		{"unknown type", hello + chPacket(1, "", chBlockInfo, 1, 1, "j", "JSON", []byte{0}), protoscan.ErrProtocolViolation},
+		{"custom serialization", hello + chPacket(1, "", chBlockInfo, 1, 1, "s", "String", []byte{1}), protoscan.ErrProtocolViolation},
+		{"short", hello + chPacket(1, "", chBlockInfo, 1, 2, "s", "String", []byte{0}, "abc"), io.ErrUnexpectedEOF},
+	} {
+		s := protoscan.New(
+			strings.NewReader(test.stream),
+			protoscan.WithSplitContext(protoscan.ScanClickHouseNative(protoscan.ClickHouseConfig{Server: true})),
+		)
+		for s.Scan() {
+		}
+		if !errors.Is(s.Err(), test.err) {
+			t.Errorf("%s: expected error %v; got %v", test.name, test.err, s.Err())
+		}
+	}
+}
+
+func TestScanClickHouseNativeLZ4(t *testing.T) {
+	// The literals "abc", the match of 9 bytes at the offset 3, and the
+	// literals "\x01x".
+	lz4 := []byte{0x35, 'a', 'b', 'c', 3, 0, 0x20, 1, 'x'}
+	frame := make([]byte, 16, 64)
+	frame = append(frame, 0x82)
+	frame = binary.LittleEndian.AppendUint32(frame, uint32(9+len(lz4)))
+	frame = binary.LittleEndian.AppendUint32(frame, uint32(len("abcabcabcabc\x01x")))
+	frame = append(frame, lz4...)
+	// The String column of the values "abcabcabcabc" and "x" continued by
+	// the LZ4 frame.
+	block := chPacket(chBlockInfo, 1, 2, "s", "String", []byte{0}) + "\x0c"
+	hello := chPacket(0, "ClickHouse", 23, 8, 54460, "UTC", "srv", 1)
+	data := chPacket(1, "", chFrame(block, false), frame)
+	s := protoscan.New(
+		strings.NewReader(hello+data),
+		protoscan.WithSplitContext(protoscan.ScanClickHouseNative(protoscan.ClickHouseConfig{Server: true, Compressed: true})),
+	)
+	var tokens []string
+	for s.Scan() {
+		tokens = append(tokens, string(s.Token()))
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	if len(tokens) != 2 || tokens[1] != data {
+		t.Fatalf("unexpected tokens %q", tokens)
+	}
+}
+
+func FuzzScanClickHouseNative(f *testing.F) {
+	protoscantest.AddCorpus(f, protoscantest.ClickHouseCorpus())
+	f.Fuzz(protoscantest.FuzzContext(protoscan.ScanClickHouseNative(protoscan.ClickHouseConfig{})))
+}
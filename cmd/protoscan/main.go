@@ -0,0 +1,249 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Protoscan splits a file, the standard input or a TCP stream into tokens
+// and writes the tokens to the standard output.
+//
+// Usage:
+//
+//	protoscan [flags]
+//
+// For example, to dump the lines of a capture file as JSON lines:
+//
+//	protoscan -split lines -in capture.txt -out jsonl
+//
+// The flags are:
+//
+//	-split name
+//		Split function, see -help for the list.
+//	-in file
+//		Input file, the standard input if "-" (default).
+//	-connect address
+//		Read from the TCP connection to the address instead of the file.
+//	-listen address
+//		Read from the first TCP connection accepted on the address instead of the file.
+//	-out format
+//		Output format: hex (default), base64, quote, length, jsonl, or the
+//		dissection of the fields of the tokens dissect or dissectjson.
+//	-max size
+//		Maximum size of a token.
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/protoscan/protoscan"
+)
+
+// splits holds the split functions selectable by the -split flag.
+var splits = map[string]protoscan.SplitFunc{
+	"ber":            protoscan.ScanBERTLV,
+	"bgp":            protoscan.ScanBGP,
+	"bytes":          protoscan.ScanBytes,
+	"cbor":           protoscan.ScanCBOR,
+	"cef":            protoscan.ScanCEF(true),
+	"chunks":         protoscan.ScanHTTPChunks,
+	"coap":           protoscan.ScanCoAPTCP,
+	"esbulk":         protoscan.ScanESBulk,
+	"csv":            protoscan.ScanCSVRecords,
+	"dlt":            protoscan.ScanDLT,
+	"dotstuffed":     protoscan.ScanDotStuffed,
+	"ebcdic":         protoscan.ScanLinesEBCDIC,
+	"fix":            protoscan.ScanFIX,
+	"graphemes":      protoscan.ScanGraphemes,
+	"http":           protoscan.ScanHTTPMessage,
+	"icap":           protoscan.ScanICAP,
+	"iso8583-2ascii": protoscan.ScanISO8583(protoscan.ISO8583Config{HeaderSize: 2, Encoding: protoscan.ISO8583ASCII}),
+	"iso8583-2bcd":   protoscan.ScanISO8583(protoscan.ISO8583Config{HeaderSize: 2, Encoding: protoscan.ISO8583BCD}),
+	"iso8583-2bin":   protoscan.ScanISO8583(protoscan.ISO8583Config{HeaderSize: 2, Encoding: protoscan.ISO8583Binary}),
+	"iso8583-4ascii": protoscan.ScanISO8583(protoscan.ISO8583Config{HeaderSize: 4, Encoding: protoscan.ISO8583ASCII}),
+	"iso8583-4bcd":   protoscan.ScanISO8583(protoscan.ISO8583Config{HeaderSize: 4, Encoding: protoscan.ISO8583BCD}),
+	"iso8583-4bin":   protoscan.ScanISO8583(protoscan.ISO8583Config{HeaderSize: 4, Encoding: protoscan.ISO8583Binary}),
+	"journal":        protoscan.ScanJournalExport,
+	"gearman":        protoscan.ScanGearman,
+	"runes":          protoscan.ScanRunes,
+	"sip":            protoscan.ScanSIP,
+	"sse":            protoscan.ScanSSE(false),
+	"soupbintcp":     protoscan.ScanSoupBinTCP,
+	"lines":          protoscan.ScanLines,
+	"lsp":            protoscan.ScanLSP,
+	"mqtt":           protoscan.ScanMQTT,
+	"prom":           protoscan.ScanPromFamilies,
+	"quic":           protoscan.ScanVarintQUIC(0),
+	"rawlines":       protoscan.ScanRawLines,
+	"varint":         protoscan.ScanVarintDelimited(0),
+	"rdb":            protoscan.ScanRDBEntries,
+	"rdw":            protoscan.ScanRDW(false),
+	"relp":           protoscan.ScanRELP,
+	"stomp":          protoscan.ScanSTOMP,
+	"syslog":         protoscan.ScanSyslog,
+	"words":          protoscan.ScanWords,
+	"wordgaps":       protoscan.ScanWordsKeepGaps,
+	"zabbix":         protoscan.ScanZabbix,
+}
+
+// ctxSplits holds the split functions receiving the context of the scan
+// selectable by the -split flag.
+var ctxSplits = map[string]protoscan.SplitCtxFunc{
+	"9p":               protoscan.Scan9P,
+	"chunkframes":      protoscan.ScanHTTPChunkFrames,
+	"clickhouse":       protoscan.ScanClickHouseNative(protoscan.ClickHouseConfig{}),
+	"clickhouseserver": protoscan.ScanClickHouseNative(protoscan.ClickHouseConfig{Server: true}),
+	"cql":              protoscan.ScanCQLFrame,
+	"fixindexed":       protoscan.ScanFIXIndexed,
+	"grpc":             protoscan.ScanGRPC,
+	"ipfix":            protoscan.ScanIPFIX,
+	"mongo":            protoscan.ScanMongoWire,
+	"rtsp":             protoscan.ScanRTSPInterleaved,
+}
+
+// dissectors holds the field-aware split functions selectable by the -split
+// flag, with the annotators of the fields recorded in their Indexes, used by
+// the dissect and dissectjson formats.
+var dissectors = map[string]struct {
+	split    protoscan.SplitCtxFunc
+	annotate protoscan.AnnotateFunc
+}{
+	"iso8583": {
+		protoscan.ScanISO8583Fields(protoscan.ScanISO8583(protoscan.ISO8583Config{Encoding: protoscan.ISO8583Binary}), protoscan.ISO8583Spec1987()),
+		protoscan.AnnotateISO8583,
+	},
+}
+
+// formatFunc writes the token with its annotations.
+type formatFunc func(w *bufio.Writer, token []byte, info protoscan.TokenInfo, annotations []protoscan.Annotation) error
+
+// formats holds the token writers selectable by the -out flag.
+var formats = map[string]formatFunc{
+	"hex": func(w *bufio.Writer, token []byte, _ protoscan.TokenInfo, _ []protoscan.Annotation) error {
+		_, err := fmt.Fprintf(w, "%x\n", token)
+		return err
+	},
+	"base64": func(w *bufio.Writer, token []byte, _ protoscan.TokenInfo, _ []protoscan.Annotation) error {
+		_, err := fmt.Fprintln(w, base64.StdEncoding.EncodeToString(token))
+		return err
+	},
+	"quote": func(w *bufio.Writer, token []byte, _ protoscan.TokenInfo, _ []protoscan.Annotation) error {
+		_, err := fmt.Fprintln(w, strconv.Quote(string(token)))
+		return err
+	},
+	"length": func(w *bufio.Writer, token []byte, _ protoscan.TokenInfo, _ []protoscan.Annotation) error {
+		var n [4]byte
+		binary.BigEndian.PutUint32(n[:], uint32(len(token)))
+		w.Write(n[:])
+		_, err := w.Write(token)
+		return err
+	},
+	"jsonl": func(w *bufio.Writer, token []byte, info protoscan.TokenInfo, _ []protoscan.Annotation) error {
+		return json.NewEncoder(w).Encode(struct {
+			Offset int64  `json:"offset"`
+			Length int64  `json:"length"`
+			Token  string `json:"token"`
+			Hex    string `json:"hex"`
+		}{info.Start, info.End - info.Start, string(token), hex.EncodeToString(token)})
+	},
+	"dissect": func(w *bufio.Writer, token []byte, info protoscan.TokenInfo, annotations []protoscan.Annotation) error {
+		return protoscan.RenderText(w, token, info, annotations)
+	},
+	"dissectjson": func(w *bufio.Writer, token []byte, info protoscan.TokenInfo, annotations []protoscan.Annotation) error {
+		return protoscan.RenderJSON(w, token, info, annotations)
+	},
+}
+
+// names returns the sorted keys of the map.
+func names[V any](m map[string]V) string {
+	var names []string
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("protoscan: ")
+	split := flag.String("split", "lines", "split function: "+names(splits)+", "+names(ctxSplits)+", or with the fields: "+names(dissectors))
+	in := flag.String("in", "-", "input file, the standard input if \"-\"")
+	connect := flag.String("connect", "", "read from the TCP connection to the address")
+	listen := flag.String("listen", "", "read from the first TCP connection accepted on the address")
+	out := flag.String("out", "hex", "output format: "+names(formats))
+	max := flag.Int("max", 0, "maximum size of a token")
+	flag.Parse()
+	if flag.NArg() > 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	var opts []protoscan.Option
+	if fn, ok := splits[*split]; ok {
+		opts = append(opts, protoscan.WithSplit(fn))
+	} else if fn, ok := ctxSplits[*split]; ok {
+		opts = append(opts, protoscan.WithSplitContext(fn))
+	} else if d, ok := dissectors[*split]; ok {
+		opts = append(opts, protoscan.WithSplitContext(d.split), protoscan.WithAnnotator(d.annotate))
+	} else {
+		log.Fatalf("unknown split function %q, use one of: %s, %s, %s", *split, names(splits), names(ctxSplits), names(dissectors))
+	}
+	format, ok := formats[*out]
+	if !ok {
+		log.Fatalf("unknown output format %q, use one of: %s", *out, names(formats))
+	}
+	if *max > 0 {
+		opts = append(opts, protoscan.WithMaxBuffer(*max))
+	}
+	r, err := open(*in, *connect, *listen)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer r.Close()
+	if err := run(os.Stdout, r, format, opts...); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// open opens the input.
+func open(in, connect, listen string) (io.ReadCloser, error) {
+	switch {
+	case connect != "":
+		return net.Dial("tcp", connect)
+	case listen != "":
+		ln, err := net.Listen("tcp", listen)
+		if err != nil {
+			return nil, err
+		}
+		defer ln.Close()
+		return ln.Accept()
+	case in == "-":
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(in)
+}
+
+// run writes the tokens of the reader split by the options in the format.
+func run(w io.Writer, r io.Reader, format formatFunc, opts ...protoscan.Option) error {
+	bw := bufio.NewWriter(w)
+	s := protoscan.New(r, opts...)
+	for s.Scan() {
+		if err := format(bw, s.Token(), s.TokenInfo(), s.Annotations()); err != nil {
+			return err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return s.Err()
+}
@@ -0,0 +1,81 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+)
+
+func TestRun(t *testing.T) {
+	for _, test := range []struct {
+		format string
+		want   string
+	}{
+		{"hex", "6162\n6300\n"},
+		{"base64", "YWI=\nYwA=\n"},
+		{"quote", "\"ab\"\n\"c\\x00\"\n"},
+		{"length", "\x00\x00\x00\x02ab\x00\x00\x00\x02c\x00"},
+		{"jsonl", `{"offset":0,"length":3,"token":"ab","hex":"6162"}` + "\n" +
+			`{"offset":3,"length":3,"token":"c\u0000","hex":"6300"}` + "\n"},
+		{"dissect", "Frame 0-3 (3 bytes), token 2 bytes\nFrame 3-6 (3 bytes), token 2 bytes\n"},
+	} {
+		var out bytes.Buffer
+		err := run(&out, strings.NewReader("ab\nc\x00\n"), formats[test.format], protoscan.WithSplit(splits["lines"]))
+		if err != nil {
+			t.Fatalf("%s: %v", test.format, err)
+		}
+		if out.String() != test.want {
+			t.Errorf("%s: expected %q got %q", test.format, test.want, out.String())
+		}
+	}
+}
+
+func TestRunDissect(t *testing.T) {
+	msg := "\x00\x18" + "0800" + "\x20\x20\x00\x00\x00\x00\x00\x00" + "990000" + "000001"
+	want := "Frame 0-26 (26 bytes), token 24 bytes\n" +
+		"    [0:4] Message type indicator: \"0800\"\n" +
+		"    [4:12] Bitmap: \"0x2020000000000000\"\n" +
+		"    [12:18] DE3 Processing code: \"990000\"\n" +
+		"    [18:24] DE11 System trace audit number: \"000001\"\n"
+	d := dissectors["iso8583"]
+	var out bytes.Buffer
+	err := run(&out, strings.NewReader(msg), formats["dissect"],
+		protoscan.WithSplitContext(d.split), protoscan.WithAnnotator(d.annotate))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != want {
+		t.Errorf("expected %q got %q", want, out.String())
+	}
+}
+
+func TestRunSplits(t *testing.T) {
+	for _, test := range []struct {
+		split string
+		input string
+		want  string
+	}{
+		{"iso8583-2ascii", "04abcd", "61626364\n"},
+		{"iso8583-4bcd", "\x00\x00\x00\x02ab", "6162\n"},
+		{"iso8583-2bin", "\x00\x01a", "61\n"},
+		{"grpc", "\x00\x00\x00\x00\x02ab", "6162\n"},
+	} {
+		opt := protoscan.WithSplit(splits[test.split])
+		if fn, ok := ctxSplits[test.split]; ok {
+			opt = protoscan.WithSplitContext(fn)
+		}
+		var out bytes.Buffer
+		if err := run(&out, strings.NewReader(test.input), formats["hex"], opt); err != nil {
+			t.Fatalf("%s: %v", test.split, err)
+		}
+		if out.String() != test.want {
+			t.Errorf("%s: expected %q got %q", test.split, test.want, out.String())
+		}
+	}
+}
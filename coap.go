@@ -0,0 +1,60 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"fmt"
+	"io"
+)
+
+// ScanCoAPTCP is a split function for a Protoscan that returns each CoAP
+// message of the RFC 8323 CoAP over TCP as a token. The Len nibble of the
+// first byte, extended by 1, 2 or 4 bytes if 13, 14 or 15, is the length of
+// the options and the payload following the Code and the Token of the TKL
+// nibble length. The message of the reserved TKL is advanced over and
+// reported as the ErrCorruptFrame.
+func ScanCoAPTCP(data []byte, atEOF bool) (int, int, []byte, error) {
+	if len(data) == 0 {
+		if atEOF {
+			return 0, 0, nil, nil
+		}
+		return 1, 0, nil, nil
+	}
+	var ext, base int
+	switch data[0] >> 4 {
+	case 13:
+		ext, base = 1, 13
+	case 14:
+		ext, base = 2, 269
+	case 15:
+		ext, base = 4, 65805
+	}
+	if len(data) < 1+ext {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return 1 + ext - len(data), 0, nil, nil
+	}
+	length := int(data[0] >> 4)
+	if ext > 0 {
+		length = 0
+		for _, b := range data[1 : 1+ext] {
+			length = length<<8 | int(b)
+		}
+		length += base
+	}
+	tkl := int(data[0] & 0x0f)
+	n := 1 + ext + 1 + tkl + length
+	if len(data) < n {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return n - len(data), 0, nil, nil
+	}
+	if tkl > 8 {
+		return 0, n, nil, fmt.Errorf("%w: CoAP reserved token length %d", ErrCorruptFrame, tkl)
+	}
+	return 0, n, data[:n], nil
+}
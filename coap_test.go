@@ -0,0 +1,41 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanCoAPTCP(t *testing.T) {
+	// The empty CSM, the GET with a 2-byte token and the Uri-Path option.
+	csm := "\x00\xe1"
+	get := "\x52\x01\xab\xcd\xb4test"
+	ext1 := "\xd0\x02\x45" + strings.Repeat("p", 15)
+	ext2 := "\xe0\x00\x01\x45" + strings.Repeat("p", 270)
+	ext4 := "\xf0\x00\x00\x00\x01\x45" + strings.Repeat("p", 65806)
+	protoscantest.TestSplitFunc(t, protoscan.ScanCoAPTCP, []protoscantest.Case{
+		{Name: "empty"},
+		{Name: "messages", Input: csm + get + ext1 + ext2, Tokens: []string{csm, get, ext1, ext2}},
+		{Name: "truncated", Input: csm + get[:4], Tokens: []string{csm}, Err: io.ErrUnexpectedEOF},
+		{Name: "truncated length", Input: "\xe0\x00", Err: io.ErrUnexpectedEOF},
+		{Name: "reserved tkl", Input: "\x09\x01123456789" + csm, Err: protoscan.ErrCorruptFrame},
+	})
+	protoscantest.TestSplitFunc(t, protoscan.ScanCoAPTCP, []protoscantest.Case{
+		{Name: "recovery", Input: "\x09\x01123456789" + csm, Tokens: []string{csm}},
+	}, protoscan.WithRecovery(nil))
+	protoscantest.TestSplitFunc(t, protoscan.ScanCoAPTCP, []protoscantest.Case{
+		{Name: "4-byte length", Input: ext4, Tokens: []string{ext4}},
+	}, protoscan.WithMaxBuffer(1<<17))
+}
+
+func FuzzScanCoAPTCP(f *testing.F) {
+	f.Add([]byte("\x00\xe1\x52\x01\xab\xcd\xb4test"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanCoAPTCP))
+}
@@ -0,0 +1,139 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"time"
+)
+
+// DefaultIdleTimeout is the default maximum duration of a single read
+// from the connection scanned by the ScanConn.
+const DefaultIdleTimeout = 2 * time.Minute
+
+// ScanConn returns a new Protoscan reading the tokens separated by the split
+// function from the network connection. The read deadline of the connection
+// is set before each read according to the WithIdleTimeout and
+// WithTokenTimeout options, the idle timeout defaults to DefaultIdleTimeout.
+// The connection is closed when the scan stops with an error, read errors
+// other than io.EOF are reported as *ConnError.
+func ScanConn(conn net.Conn, split SplitFunc, opts ...Option) *Protoscan {
+	r := &connReader{conn: conn}
+	opts = append([]Option{WithSplit(split), WithIdleTimeout(DefaultIdleTimeout)}, opts...)
+	s := New(r, opts...)
+	r.scan = s
+	s.closer = conn
+	return s
+}
+
+// WithIdleTimeout sets maximum duration of a single read from the connection
+// scanned by the ScanConn. Zero means no limit.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(s *Protoscan) { s.idleTimeout = d }
+}
+
+// WithTokenTimeout sets maximum duration of a call to Scan reading from the
+// connection scanned by the ScanConn, or from any reader if the WithAbort
+// option is set. Zero means no limit.
+func WithTokenTimeout(d time.Duration) Option {
+	return func(s *Protoscan) { s.tokenTimeout = d }
+}
+
+// ConnErrorKind classifies the failed reads from the network connection.
+type ConnErrorKind int
+
+// Kinds of the failed reads.
+const (
+	ConnFailed  ConnErrorKind = iota // Read failed for other reason.
+	ConnTimeout                      // Read deadline exceeded.
+	ConnReset                        // Connection reset or aborted by peer.
+	ConnClosed                       // Connection closed locally.
+)
+
+var connErrorKinds = [...]string{
+	ConnFailed:  "failed",
+	ConnTimeout: "timeout",
+	ConnReset:   "reset",
+	ConnClosed:  "closed",
+}
+
+func (k ConnErrorKind) String() string {
+	if k < 0 || int(k) >= len(connErrorKinds) {
+		return "unknown"
+	}
+	return connErrorKinds[k]
+}
+
+// ConnError records a failed read from the network connection.
+type ConnError struct {
+	Kind ConnErrorKind
+	Err  error
+}
+
+func (e *ConnError) Error() string {
+	return "protoscan: read " + e.Kind.String() + ": " + e.Err.Error()
+}
+
+func (e *ConnError) Unwrap() error { return e.Err }
+
+// Timeout reports whether the read deadline was exceeded.
+func (e *ConnError) Timeout() bool { return e.Kind == ConnTimeout }
+
+// classifyConnError returns the kind of the read error.
+func classifyConnError(err error) ConnErrorKind {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, os.ErrDeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return ConnTimeout
+	case errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNABORTED),
+		errors.Is(err, syscall.EPIPE):
+		return ConnReset
+	case errors.Is(err, net.ErrClosed),
+		errors.Is(err, io.ErrClosedPipe):
+		return ConnClosed
+	}
+	return ConnFailed
+}
+
+// connReader sets the read deadline before each read from the connection
+// and classifies the read errors.
+type connReader struct {
+	conn net.Conn
+	scan *Protoscan
+}
+
+func (r *connReader) Read(p []byte) (int, error) {
+	if err := r.setDeadline(); err != nil {
+		return 0, err
+	}
+	n, err := r.conn.Read(p)
+	return n, r.classify(err)
+}
+
+// setDeadline sets the read deadline of the connection.
+func (r *connReader) setDeadline() error {
+	var deadline time.Time
+	if r.scan.idleTimeout > 0 {
+		deadline = time.Now().Add(r.scan.idleTimeout)
+	}
+	if t := r.scan.deadline(); !t.IsZero() && (deadline.IsZero() || t.Before(deadline)) {
+		deadline = t
+	}
+	return r.classify(r.conn.SetReadDeadline(deadline))
+}
+
+// classify wraps the error other than io.EOF into the ConnError.
+func (r *connReader) classify(err error) error {
+	if err != nil && err != io.EOF {
+		err = &ConnError{Kind: classifyConnError(err), Err: err}
+	}
+	return err
+}
@@ -0,0 +1,89 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/protoscan/protoscan"
+)
+
+func TestScanConn(t *testing.T) {
+	client, server := net.Pipe()
+	s := protoscan.ScanConn(server, protoscan.ScanLines)
+	go func() {
+		client.Write([]byte("abc\ndef\n"))
+		client.Close()
+	}()
+	var lines []string
+	for s.Scan() {
+		lines = append(lines, string(s.Token()))
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	if len(lines) != 2 || lines[0] != "abc" || lines[1] != "def" {
+		t.Fatalf("unexpected lines: %q", lines)
+	}
+}
+
+// Test that the idle timeout is classified and closes the connection.
+func TestScanConnIdleTimeout(t *testing.T) {
+	client, server := net.Pipe()
+	s := protoscan.ScanConn(server, protoscan.ScanLines,
+		protoscan.WithIdleTimeout(time.Millisecond),
+	)
+	if s.Scan() {
+		t.Fatalf("unexpected token: %q", s.Token())
+	}
+	var connErr *protoscan.ConnError
+	if !errors.As(s.Err(), &connErr) || connErr.Kind != protoscan.ConnTimeout {
+		t.Fatalf("expected timeout; got %v", s.Err())
+	}
+	if _, err := client.Write([]byte("late\n")); err == nil {
+		t.Fatal("connection is not closed")
+	}
+}
+
+// Test that the token timeout limits the whole token while the data trickles.
+func TestScanConnTokenTimeout(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	s := protoscan.ScanConn(server, protoscan.ScanLines,
+		protoscan.WithIdleTimeout(time.Second),
+		protoscan.WithTokenTimeout(20*time.Millisecond),
+	)
+	go func() {
+		for i := 0; i < 100; i++ {
+			if _, err := client.Write([]byte("a")); err != nil {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	if s.Scan() {
+		t.Fatalf("unexpected token: %q", s.Token())
+	}
+	var connErr *protoscan.ConnError
+	if !errors.As(s.Err(), &connErr) || !connErr.Timeout() {
+		t.Fatalf("expected timeout; got %v", s.Err())
+	}
+}
+
+func TestScanConnClosed(t *testing.T) {
+	_, server := net.Pipe()
+	server.Close()
+	s := protoscan.ScanConn(server, protoscan.ScanLines)
+	if s.Scan() {
+		t.Fatalf("unexpected token: %q", s.Token())
+	}
+	var connErr *protoscan.ConnError
+	if !errors.As(s.Err(), &connErr) || connErr.Kind != protoscan.ConnClosed {
+		t.Fatalf("expected closed connection; got %v", s.Err())
+	}
+}
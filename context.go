@@ -0,0 +1,61 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"context"
+	"io"
+)
+
+// ScanContext returns a new Protoscan reading the tokens separated by the
+// split function from the reader until the context is canceled. The reader
+// is wrapped by the ContextReader, it is closed on the cancellation if it
+// is an io.Closer, the caller still closes it after the scan. The cancellation
+// also ends the wait for the new data set by the WithWaitEOF. The scan stops
+// with the error of the context.
+func ScanContext(ctx context.Context, r io.Reader, split SplitFunc, opts ...Option) *Protoscan {
+	closer, _ := r.(io.Closer)
+	opts = append([]Option{WithSplit(split)}, opts...)
+	opts = append(opts, func(s *Protoscan) { s.ctx = ctx })
+	return New(ContextReader(ctx, r, closer), opts...)
+}
+
+// ContextReader returns a reader which reads from the reader until the
+// context is canceled, then it returns the error of the context. The closer,
+// if not nil, is closed on the cancellation to unblock the read in flight,
+// unless the reader has already returned an error, such as io.EOF, other
+// than a temporary error which may be retried by the WithRetry. The
+// closer remains owned by the caller, which closes it as usual once the
+// reader is done; it must tolerate the second Close.
+func ContextReader(ctx context.Context, r io.Reader, closer io.Closer) io.Reader {
+	cr := &contextReader{ctx: ctx, reader: r}
+	if closer != nil {
+		cr.stop = context.AfterFunc(ctx, func() { closer.Close() })
+	}
+	return cr
+}
+
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+	stop   func() bool // Stops the closing on the cancellation, nil without the closer.
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := r.reader.Read(p)
+	if err != nil {
+		if r.ctx.Err() != nil {
+			err = r.ctx.Err()
+		}
+		if r.stop != nil && !isTemporary(err) {
+			// The reader is done, unless the read is retried.
+			r.stop()
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,95 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/protoscan/protoscan"
+)
+
+func TestScanContext(t *testing.T) {
+	r, w := io.Pipe()
+	go w.Write([]byte("abc\nde"))
+	ctx, cancel := context.WithCancel(context.Background())
+	s := protoscan.ScanContext(ctx, r, protoscan.ScanLines)
+	if !s.Scan() || string(s.Token()) != "abc" {
+		t.Fatalf("expected the first line; got %q, %v", s.Token(), s.Err())
+	}
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if s.Scan() {
+		t.Fatalf("unexpected token %q", s.Token())
+	}
+	if s.Err() != context.Canceled {
+		t.Fatalf("expected context.Canceled; got %v", s.Err())
+	}
+}
+
+func TestContextReader(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := protoscan.ContextReader(ctx, eofReader{}, nil)
+	if _, err := r.Read(make([]byte, 1)); err != context.Canceled {
+		t.Fatalf("expected context.Canceled; got %v", err)
+	}
+}
+
+// countingCloser counts the calls to Close.
+type countingCloser struct{ closed int }
+
+func (c *countingCloser) Close() error {
+	c.closed++
+	return nil
+}
+
+// Test that the closer is not closed on the cancellation after the reader
+// returned an error.
+func TestContextReaderStop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := new(countingCloser)
+	r := protoscan.ContextReader(ctx, eofReader{}, c)
+	if _, err := r.Read(make([]byte, 1)); err != io.EOF {
+		t.Fatalf("expected io.EOF; got %v", err)
+	}
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	if c.closed != 0 {
+		t.Fatalf("closer closed %d times after EOF", c.closed)
+	}
+}
+
+type eofReader struct{}
+
+func (eofReader) Read([]byte) (int, error) { return 0, io.EOF }
+
+// Test that the closer is still closed on the cancellation after the reader
+// returned a temporary error.
+func TestContextReaderTemporary(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := chanCloser(make(chan struct{}))
+	r := protoscan.ContextReader(ctx, &timeoutReader{every: 2, r: eofReader{}}, c)
+	if _, err := r.Read(make([]byte, 1)); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("expected the exceeded deadline; got %v", err)
+	}
+	cancel()
+	select {
+	case <-c:
+	case <-time.After(time.Second):
+		t.Fatal("closer not closed after the temporary error")
+	}
+}
+
+// chanCloser is closed by Close.
+type chanCloser chan struct{}
+
+func (c chanCloser) Close() error {
+	close(c)
+	return nil
+}
@@ -0,0 +1,305 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// Opcodes of the CQL native protocol switching to the framing layer.
+const (
+	cqlStartup      = 0x01
+	cqlReady        = 0x02
+	cqlAuthResponse = 0x0f
+	cqlAuthSuccess  = 0x10
+)
+
+const (
+	cqlMaxFrame   = 256 << 20 // Maximum length of the frame body.
+	cqlV5         = 5         // Version introducing the framing layer.
+	cqlHeader     = 9         // Length of the frame header since the version 3.
+	cqlSegmentCRC = 4         // Length of the CRC32 of the segment payload.
+)
+
+// cqlCRC32Init are the bytes preceding the payload in its CRC32.
+var cqlCRC32Init = []byte{0xfa, 0x2d, 0x55, 0xca}
+
+// cqlState is the state of the ScanCQLFrame kept in the SplitContext.
+type cqlState struct {
+	framed     bool     // Whether the framing layer is in effect.
+	pending    bool     // Whether the framing layer may follow.
+	compressed bool     // Whether the segments are compressed.
+	left       int      // Bytes of the uncompressed segment payload left.
+	frames     [][]byte // Frames of the compressed segment left.
+	rest       int      // Bytes of the compressed segment left.
+	large      bool     // Whether the Scratch holds a part of a frame.
+	seg        []byte   // Payload of the compressed segment.
+}
+
+// cqlStateKey is the key of the cqlState in the SplitContext.
+type cqlStateKey struct{}
+
+// cqlCRC24 returns the CRC24 of the n bytes of the little-endian header.
+func cqlCRC24(header uint64, n int) uint32 {
+	crc := uint32(0x875060)
+	for ; n > 0; n-- {
+		crc ^= uint32(header&0xff) << 16
+		header >>= 8
+		for i := 0; i < 8; i++ {
+			crc <<= 1
+			if crc&0x1000000 != 0 {
+				crc ^= 0x1974f0b
+			}
+		}
+	}
+	return crc & 0xffffff
+}
+
+// cqlUint returns the little-endian integer of the bytes.
+func cqlUint(b []byte) uint64 {
+	var v uint64
+	for i := len(b) - 1; i >= 0; i-- {
+		v = v<<8 | uint64(b[i])
+	}
+	return v
+}
+
+// cqlSegmentHeader returns the length of the segment header at the start of
+// the data and its bits, checking its CRC24 as the header of the
+// uncompressed segment, then of the compressed one, unless compressed. The
+// length is 0 if the data are too short to tell.
+func cqlSegmentHeader(data []byte, compressed bool) (int, uint64, bool) {
+	for _, n := range []int{3, 5} {
+		if n == 3 && compressed {
+			continue
+		}
+		if len(data) < n+3 {
+			return 0, 0, true
+		}
+		header := cqlUint(data[:n])
+		if cqlCRC24(header, n) == uint32(cqlUint(data[n:n+3])) {
+			return n + 3, header, true
+		}
+	}
+	return 0, 0, false
+}
+
+// cqlFrameLen returns the length of the frame at the start of the data, or
+// 0 if the data are too short to tell.
+func cqlFrameLen(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, nil
+	}
+	version := data[0] & 0x7f
+	if version == 0 || version > 6 {
+		return 0, fmt.Errorf("%w: bad CQL version 0x%02x", ErrProtocolViolation, data[0])
+	}
+	header := cqlHeader
+	if version < 3 {
+		// The stream of 1 byte.
+		header = 8
+	}
+	if len(data) < header {
+		return 0, nil
+	}
+	length := binary.BigEndian.Uint32(data[header-4:])
+	if length > cqlMaxFrame {
+		return 0, fmt.Errorf("%w: CQL frame length %d", ErrProtocolViolation, length)
+	}
+	return header + int(length), nil
+}
+
+// ScanCQLFrame is a split function for the Protoscan with the
+// WithSplitContext option which returns each frame of the CQL native
+// protocol of one side of the connection as a token: the 9-byte header of
+// the version, the flags, the stream, the opcode and the length, then the
+// body; the header of the versions 1 and 2 has the stream of 1 byte. For
+// the version 5, the frames following the READY or the AUTH_SUCCESS, or
+// the STARTUP or the AUTH_RESPONSE when the segment header follows them,
+// are carried by the segments of the framing layer, their CRCs checked:
+// the frames of the self-contained segments are returned one by one, the
+// frame split into the segments is returned from the Scratch, as are the
+// frames of the LZ4 compressed segments, which are advanced over 1 byte but
+// the last. The segments with a bad payload CRC are the ErrCorruptFrame,
+// the bad headers stop the scan with the ErrProtocolViolation.
+func ScanCQLFrame(ctx *SplitContext, data []byte, atEOF bool) (int, int, []byte, error) {
+	st, _ := ctx.Value(cqlStateKey{}).(*cqlState)
+	if st == nil {
+		st = new(cqlState)
+		ctx.SetValue(cqlStateKey{}, st)
+	}
+	if len(st.frames) > 0 {
+		frame := st.frames[0]
+		st.frames = st.frames[1:]
+		advance := 1
+		if len(st.frames) == 0 {
+			advance = st.rest
+		}
+		st.rest -= advance
+		return 0, advance, frame, nil
+	}
+	if st.left > 0 {
+		n, err := cqlFrameLen(data[:st.left])
+		if err == nil && n == 0 || n > st.left {
+			err = fmt.Errorf("%w: CQL frame across the segment", ErrProtocolViolation)
+		}
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		st.left -= n
+		if st.left == 0 {
+			return 0, n + cqlSegmentCRC, data[:n], nil
+		}
+		return 0, n, data[:n], nil
+	}
+	if len(data) == 0 {
+		if atEOF && !st.large {
+			return 0, 0, nil, nil
+		}
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return 1, 0, nil, nil
+	}
+	if st.pending {
+		n, _, ok := cqlSegmentHeader(data, false)
+		if ok && n == 0 && !atEOF {
+			return 8 - len(data), 0, nil, nil
+		}
+		st.framed, st.pending = ok && n > 0, false
+	}
+	if st.framed {
+		return st.segment(ctx, data, atEOF)
+	}
+	n, err := cqlFrameLen(data)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if n == 0 || n > len(data) {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		if n == 0 {
+			n = cqlHeader
+			if data[0]&0x7f < 3 {
+				n--
+			}
+		}
+		return n - len(data), 0, nil, nil
+	}
+	if data[0]&0x7f == cqlV5 {
+		response, opcode := data[0]&0x80 != 0, data[4]
+		switch {
+		case response && (opcode == cqlReady || opcode == cqlAuthSuccess):
+			st.framed = true
+		case !response && (opcode == cqlStartup || opcode == cqlAuthResponse):
+			st.pending = true
+		}
+	}
+	return 0, n, data[:n], nil
+}
+
+// segment splits the frames of the segment at the start of the data.
+func (st *cqlState) segment(ctx *SplitContext, data []byte, atEOF bool) (int, int, []byte, error) {
+	n, header, ok := cqlSegmentHeader(data, st.compressed)
+	if !ok {
+		return 0, 0, nil, fmt.Errorf("%w: bad CQL segment header CRC", ErrProtocolViolation)
+	}
+	if n == 0 {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return 8 - len(data), 0, nil, nil
+	}
+	st.compressed = n == 8
+	length := int(header & 0x1ffff)
+	uncompressed := 0
+	self := header>>17&1 != 0
+	if st.compressed {
+		uncompressed = int(header >> 17 & 0x1ffff)
+		self = header>>34&1 != 0
+	}
+	total := n + length + cqlSegmentCRC
+	if total > len(data) {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return total - len(data), 0, nil, nil
+	}
+	payload := data[n : n+length]
+	crc := crc32.Update(crc32.ChecksumIEEE(cqlCRC32Init), crc32.IEEETable, payload)
+	if crc != binary.LittleEndian.Uint32(data[n+length:]) {
+		return 0, total, nil, fmt.Errorf("%w: bad CQL segment payload CRC", ErrCorruptFrame)
+	}
+	if st.compressed {
+		// The frames are returned after advancing over the segment.
+		var err error
+		if uncompressed > 0 {
+			st.seg, err = lz4Decompress(st.seg[:0], payload, uncompressed)
+		} else {
+			st.seg = append(st.seg[:0], payload...)
+		}
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		payload = st.seg
+	}
+	if !self {
+		return st.part(ctx, payload, total)
+	}
+	if st.large {
+		return 0, 0, nil, fmt.Errorf("%w: CQL self-contained segment within a frame", ErrProtocolViolation)
+	}
+	var frames [][]byte
+	for rest := payload; len(rest) > 0; {
+		m, err := cqlFrameLen(rest)
+		if err == nil && (m == 0 || m > len(rest)) {
+			err = fmt.Errorf("%w: CQL frame across the self-contained segment", ErrProtocolViolation)
+		}
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		frames = append(frames, rest[:m])
+		rest = rest[m:]
+	}
+	switch {
+	case len(frames) == 0:
+		return 0, total, nil, nil
+	case len(frames) == 1:
+		return 0, total, frames[0], nil
+	case !st.compressed:
+		st.left = length - len(frames[0])
+		return 0, n + len(frames[0]), frames[0], nil
+	case len(frames)-1 > length+cqlSegmentCRC:
+		return 0, 0, nil, fmt.Errorf("%w: CQL compressed segment of %d frames", ErrProtocolViolation, len(frames))
+	}
+	st.frames, st.rest = frames[1:], length+cqlSegmentCRC
+	return 0, n, frames[0], nil
+}
+
+// part appends the payload of the segment which is not self-contained to
+// the frame in the Scratch, returning the frame when complete.
+func (st *cqlState) part(ctx *SplitContext, payload []byte, total int) (int, int, []byte, error) {
+	if !st.large {
+		ctx.Scratch = ctx.Scratch[:0]
+	}
+	ctx.Scratch = append(ctx.Scratch, payload...)
+	st.large = true
+	n, err := cqlFrameLen(ctx.Scratch)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if n == 0 || n > len(ctx.Scratch) {
+		return 0, total, nil, nil
+	}
+	if n < len(ctx.Scratch) {
+		return 0, 0, nil, fmt.Errorf("%w: CQL frame shorter than its segments", ErrProtocolViolation)
+	}
+	st.large = false
+	return 0, total, ctx.Scratch, nil
+}
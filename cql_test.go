@@ -0,0 +1,156 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+// cqlFrame returns the frame of the version, the opcode and the body.
+func cqlFrame(version, opcode byte, body string) string {
+	b := []byte{version, 0, 0, 1, opcode}
+	if version&0x7f < 3 {
+		b = []byte{version, 0, 1, opcode}
+	}
+	b = binary.BigEndian.AppendUint32(b, uint32(len(body)))
+	return string(b) + body
+}
+
+// cqlSegment returns the segment of the payload, compressed as the LZ4
+// literals if lz4, or stored in the compressed segment if stored.
+func cqlSegment(payload string, self, lz4, stored bool) string {
+	var header uint64
+	var n int
+	data := []byte(payload)
+	switch {
+	case lz4 || stored:
+		if lz4 {
+			data = lz4Literals(payload)
+			header = uint64(len(payload)) << 17
+		}
+		header |= uint64(len(data))
+		if self {
+			header |= 1 << 34
+		}
+		n = 5
+	default:
+		header = uint64(len(data))
+		if self {
+			header |= 1 << 17
+		}
+		n = 3
+	}
+	var b []byte
+	for i := 0; i < n; i++ {
+		b = append(b, byte(header>>(8*i)))
+	}
+	crc := protoscan.CQLCRC24(header, n)
+	b = append(b, byte(crc), byte(crc>>8), byte(crc>>16))
+	b = append(b, data...)
+	sum := crc32.Update(crc32.ChecksumIEEE([]byte{0xfa, 0x2d, 0x55, 0xca}), crc32.IEEETable, data)
+	return string(binary.LittleEndian.AppendUint32(b, sum))
+}
+
+// scanCQL returns the frames of the stream.
+func scanCQL(r io.Reader) ([]string, error) {
+	s := protoscan.New(r, protoscan.WithSplitContext(protoscan.ScanCQLFrame))
+	var frames []string
+	for s.Scan() {
+		frames = append(frames, string(s.Token()))
+	}
+	return frames, s.Err()
+}
+
+func TestScanCQLFrame(t *testing.T) {
+	query := cqlFrame(0x05, 0x07, "\x00\x00\x00\x08SELECT 1")
+	rows := cqlFrame(0x85, 0x08, strings.Repeat("r", 40))
+	large := cqlFrame(0x85, 0x08, strings.Repeat("l", 100))
+	for _, test := range []struct {
+		name   string
+		stream string
+		frames []string
+	}{
+		{
+			"legacy",
+			cqlFrame(0x04, 0x07, "query") + cqlFrame(0x84, 0x08, "") + cqlFrame(0x02, 0x05, ""),
+			[]string{cqlFrame(0x04, 0x07, "query"), cqlFrame(0x84, 0x08, ""), cqlFrame(0x02, 0x05, "")},
+		},
+		{
+			"client",
+			cqlFrame(0x05, 0x01, "startup") + cqlSegment(query+query, true, false, false),
+			[]string{cqlFrame(0x05, 0x01, "startup"), query, query},
+		},
+		{
+			"client auth",
+			cqlFrame(0x05, 0x01, "startup") + cqlFrame(0x05, 0x0f, "token") + cqlSegment(query, true, false, false),
+			[]string{cqlFrame(0x05, 0x01, "startup"), cqlFrame(0x05, 0x0f, "token"), query},
+		},
+		{
+			"server",
+			cqlFrame(0x85, 0x02, "") +
+				cqlSegment(rows+rows+rows, true, false, false) +
+				cqlSegment(large[:50], false, false, false) + cqlSegment(large[50:], false, false, false) +
+				cqlSegment("", true, false, false) +
+				cqlSegment(rows, true, false, false),
+			[]string{cqlFrame(0x85, 0x02, ""), rows, rows, rows, large, rows},
+		},
+		{
+			"compressed",
+			cqlFrame(0x85, 0x10, "") +
+				cqlSegment(rows+rows+rows, true, true, false) +
+				cqlSegment(rows, true, false, true) +
+				cqlSegment(large[:50], false, true, false) + cqlSegment(large[50:], false, false, true),
+			[]string{cqlFrame(0x85, 0x10, ""), rows, rows, rows, rows, large},
+		},
+	} {
+		for _, f := range protoscantest.Fragmentations() {
+			frames, err := scanCQL(f.Reader([]byte(test.stream)))
+			if err != nil {
+				t.Fatalf("%s %s: %v", test.name, f.Name, err)
+			}
+			if strings.Join(frames, "|") != strings.Join(test.frames, "|") {
+				t.Fatalf("%s %s: expected frames %q; got %q", test.name, f.Name, test.frames, frames)
+			}
+		}
+	}
+}
+
+func TestScanCQLFrameErrors(t *testing.T) {
+	ready := cqlFrame(0x85, 0x02, "")
+	rows := cqlFrame(0x85, 0x08, "rows")
+	corrupt := []byte(cqlSegment(rows, true, false, false))
+	corrupt[10] ^= 1
+	for _, test := range []struct {
+		name   string
+		stream string
+		err    error
+	}{
+		{"version", "\x07\x00\x00\x01\x07\x00\x00\x00\x00", protoscan.ErrProtocolViolation},
+		{"length", "\x04\x00\x00\x01\x07\x7f\x00\x00\x00", protoscan.ErrProtocolViolation},
+		{"short", cqlFrame(0x04, 0x07, "query")[:12], io.ErrUnexpectedEOF},
+		{"header CRC", ready + "\x09\x00\x02\x00\x00\x00" + rows + "\x00\x00\x00\x00", protoscan.ErrProtocolViolation},
+		{"payload CRC", ready + string(corrupt), protoscan.ErrCorruptFrame},
+		{"short segment", ready + cqlSegment(rows, true, false, false)[:12], io.ErrUnexpectedEOF},
+		{"split frame", ready + cqlSegment(rows[:5], true, false, false), protoscan.ErrProtocolViolation},
+		{"unfinished frame", ready + cqlSegment(rows[:5], false, false, false), io.ErrUnexpectedEOF},
+	} {
+		if _, err := scanCQL(strings.NewReader(test.stream)); !errors.Is(err, test.err) {
+			t.Errorf("%s: expected error %v; got %v", test.name, test.err, err)
+		}
+	}
+}
+
+func FuzzScanCQLFrame(f *testing.F) {
+	protoscantest.AddCorpus(f, protoscantest.CQLCorpus())
+	f.Fuzz(protoscantest.FuzzContext(protoscan.ScanCQLFrame))
+}
@@ -0,0 +1,54 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import "io"
+
+// ScanCSVRecords is a split function for a Protoscan that returns each
+// record of the comma-separated values, RFC 4180, as a token, without its
+// trailing newline and carriage return, so that the records may be parsed
+// by the encoding/csv in parallel. The quoted fields may hold the commas,
+// the newlines and the quotes escaped by doubling them; a quote inside an
+// unquoted field is a literal. The empty lines are skipped, as by the
+// encoding/csv. The last record may be unterminated, but the quoted field
+// unterminated at EOF is the io.ErrUnexpectedEOF.
+func ScanCSVRecords(data []byte, atEOF bool) (int, int, []byte, error) {
+	start := 0
+	for start < len(data) && (data[start] == '\n' || data[start] == '\r' && start+1 < len(data) && data[start+1] == '\n') {
+		start++
+	}
+	quoted, field := false, true
+	for i := start; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case quoted:
+			if c != '"' {
+				continue
+			}
+			if i+1 == len(data) && !atEOF {
+				return 1, 0, nil, nil
+			}
+			if i+1 < len(data) && data[i+1] == '"' {
+				i++
+				continue
+			}
+			quoted = false
+		case field && c == '"':
+			quoted = true
+		case c == '\n':
+			return 0, i + 1, dropCR(data[start:i]), nil
+		}
+		field = c == ','
+	}
+	switch {
+	case !atEOF:
+		return 1, 0, nil, nil
+	case quoted:
+		return 0, 0, nil, io.ErrUnexpectedEOF
+	case start == len(data):
+		return 0, 0, nil, nil
+	}
+	return 0, len(data), dropCR(data[start:]), nil
+}
@@ -0,0 +1,33 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanCSVRecords(t *testing.T) {
+	protoscantest.TestSplitFunc(t, protoscan.ScanCSVRecords, []protoscantest.Case{
+		{Name: "empty", Input: "", Tokens: nil},
+		{
+			Name:   "records",
+			Input:  "a,b,c\r\n1,\"x\ny\",3\n\n\r\n\"say \"\"hi\"\"\",\"\",\n4,5\"6,7",
+			Tokens: []string{"a,b,c", "1,\"x\ny\",3", "\"say \"\"hi\"\"\",\"\",", "4,5\"6,7"},
+		},
+		{Name: "quoted newline", Input: "\"a\r\n\",b\r\n", Tokens: []string{"\"a\r\n\",b"}},
+		{Name: "final quote", Input: "a,\"b\"", Tokens: []string{"a,\"b\""}},
+		{Name: "empty lines", Input: "\n\r\n\n", Tokens: nil},
+		{Name: "unterminated quote", Input: "a,\"b\nc", Err: io.ErrUnexpectedEOF},
+	})
+}
+
+func FuzzScanCSVRecords(f *testing.F) {
+	f.Add([]byte("a,\"b\n\"\"c\"\"\"\n\n1,2\r\n"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanCSVRecords))
+}
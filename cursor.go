@@ -0,0 +1,45 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// errShort is returned by the cursor when the data end before the
+// structure walked by the parser.
+var errShort = errors.New("short data")
+
+// cursor walks the data for the parsers of the split functions which must
+// tell how many bytes the structure is missing.
+type cursor struct {
+	data  []byte
+	pos   int
+	need  int    // Count of the bytes missing if errShort.
+	proto string // Name of the protocol in the errors.
+}
+
+// skip skips n bytes.
+func (c *cursor) skip(n uint64) error {
+	if n > uint64(math.MaxInt-c.pos) {
+		return fmt.Errorf("%w: %s length %d", ErrProtocolViolation, c.proto, n)
+	}
+	if c.pos+int(n) > len(c.data) {
+		c.need = c.pos + int(n) - len(c.data)
+		return errShort
+	}
+	c.pos += int(n)
+	return nil
+}
+
+// readByte returns the next byte.
+func (c *cursor) readByte() (byte, error) {
+	if err := c.skip(1); err != nil {
+		return 0, err
+	}
+	return c.data[c.pos-1], nil
+}
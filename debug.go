@@ -0,0 +1,41 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import "fmt"
+
+// DebugState is a snapshot of the internals of the Protoscan, which may be
+// logged when a scan wedges, for instance, on the ErrNoProgress.
+type DebugState struct {
+	BufferLen   int   // Length of the buffer.
+	BufferCap   int   // Capacity of the buffer.
+	Start       int   // Position of the carriage in the buffer.
+	End         int   // Number of the buffered bytes.
+	Empties     int   // Count of successive empty reads or scans.
+	MaxBuffer   int   // Maximum size of the buffer.
+	LastHint    int   // Hint returned by the last call to the split function.
+	LastAdvance int   // Advance returned by the last call to the split function.
+	Err         error // Sticky error, including io.EOF.
+}
+
+// DebugState returns the snapshot of the internals of the Protoscan.
+func (s *Protoscan) DebugState() DebugState {
+	return DebugState{
+		BufferLen:   len(s.buffer),
+		BufferCap:   cap(s.buffer),
+		Start:       s.start,
+		End:         s.end,
+		Empties:     s.empties,
+		MaxBuffer:   s.maxBuffer,
+		LastHint:    s.lastHint,
+		LastAdvance: s.lastAdvance,
+		Err:         s.err,
+	}
+}
+
+func (d DebugState) String() string {
+	return fmt.Sprintf("buffer=%d/%d start=%d end=%d empties=%d max=%d hint=%d advance=%d err=%v",
+		d.BufferLen, d.BufferCap, d.Start, d.End, d.Empties, d.MaxBuffer, d.LastHint, d.LastAdvance, d.Err)
+}
@@ -0,0 +1,29 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+)
+
+func TestDebugState(t *testing.T) {
+	s := protoscan.New(strings.NewReader("abc\ndef"), protoscan.WithSplit(protoscan.ScanLines))
+	if !s.Scan() {
+		t.Fatal(s.Err())
+	}
+	d := s.DebugState()
+	if d.Start != 4 || d.End != 4 || d.LastAdvance != 4 || d.LastHint != 0 || d.Err != nil {
+		t.Fatalf("unexpected state: %v", d)
+	}
+	for s.Scan() {
+	}
+	const want = "buffer=4/"
+	if got := s.DebugState().String(); !strings.HasPrefix(got, want) || !strings.HasSuffix(got, "hint=0 advance=0 err=EOF") {
+		t.Fatalf("unexpected state: %s", got)
+	}
+}
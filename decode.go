@@ -0,0 +1,106 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+)
+
+// errOddHex is returned by the hex decoder on the odd digit at EOF.
+var errOddHex = errors.New("protoscan: odd count of hex digits")
+
+// HexDecoder returns the Transformer which decodes the hex-encoded data
+// stream, ignoring the white space such as line breaks between the digits.
+// The hints and the maximum size of the buffer apply to the decoded bytes.
+func HexDecoder() Transformer {
+	return hexDecoder{}
+}
+
+type hexDecoder struct{}
+
+func (hexDecoder) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for {
+		nSrc = skipSpace(src, nSrc)
+		if nSrc == len(src) {
+			return nDst, nSrc, nil
+		}
+		i := skipSpace(src, nSrc+1)
+		if i == len(src) {
+			if atEOF {
+				return nDst, nSrc, errOddHex
+			}
+			return nDst, nSrc, ErrShortSrc
+		}
+		if nDst == len(dst) {
+			return nDst, nSrc, ErrShortDst
+		}
+		if _, err := hex.Decode(dst[nDst:nDst+1], []byte{src[nSrc], src[i]}); err != nil {
+			return nDst, nSrc, err
+		}
+		nDst++
+		nSrc = i + 1
+	}
+}
+
+func (hexDecoder) Reset() {}
+
+// Base64Decoder returns the Transformer which decodes the data stream
+// encoded by the encoding, ignoring the white space such as line breaks.
+// The padded quanta may be followed by more data, as of the concatenated
+// encodings. The hints and the maximum size of the buffer apply to the
+// decoded bytes.
+func Base64Decoder(enc *base64.Encoding) Transformer {
+	return base64Decoder{enc: enc}
+}
+
+type base64Decoder struct {
+	enc *base64.Encoding
+}
+
+func (d base64Decoder) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	var quantum [4]byte
+	var decoded [3]byte
+	for {
+		// Collect the quantum of up to four characters.
+		n, end := 0, skipSpace(src, nSrc)
+		for n < 4 && end < len(src) {
+			quantum[n] = src[end]
+			n++
+			end = skipSpace(src, end+1)
+		}
+		if n == 0 {
+			return nDst, end, nil
+		}
+		if n < 4 && !atEOF {
+			return nDst, nSrc, ErrShortSrc
+		}
+		m, err := d.enc.Decode(decoded[:], quantum[:n])
+		if err != nil {
+			return nDst, nSrc, err
+		}
+		if nDst+m > len(dst) {
+			return nDst, nSrc, ErrShortDst
+		}
+		nDst += copy(dst[nDst:], decoded[:m])
+		nSrc = end
+	}
+}
+
+func (base64Decoder) Reset() {}
+
+// skipSpace returns the index of the first byte from i which is not a space.
+func skipSpace(src []byte, i int) int {
+	for i < len(src) {
+		switch src[i] {
+		case ' ', '\t', '\r', '\n':
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
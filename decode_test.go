@@ -0,0 +1,70 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestDecoders(t *testing.T) {
+	const text = "lorem ipsum\ndolor sit amet\n"
+	wrap := func(s string, n int) string {
+		var b strings.Builder
+		for len(s) > n {
+			b.WriteString(s[:n] + "\r\n")
+			s = s[n:]
+		}
+		b.WriteString(s)
+		return b.String()
+	}
+	for _, test := range []struct {
+		name    string
+		input   string
+		decoder protoscan.Transformer
+		err     bool
+	}{
+		{name: "hex", input: wrap("6c6f72656d20697073756d0a646f6c6f722073697420616d65740a", 7), decoder: protoscan.HexDecoder()},
+		{name: "hex spaced", input: "6c 6f 72 65 6d 20 69 70 73 75 6d 0a 64 6f 6c 6f 72 20 73 69 74 20 61 6d 65 74 0A", decoder: protoscan.HexDecoder()},
+		{name: "hex odd", input: "6c6", decoder: protoscan.HexDecoder(), err: true},
+		{name: "hex invalid", input: "6x", decoder: protoscan.HexDecoder(), err: true},
+		{name: "base64", input: wrap(base64.StdEncoding.EncodeToString([]byte(text)), 10), decoder: protoscan.Base64Decoder(base64.StdEncoding)},
+		{name: "base64 raw", input: wrap(base64.RawStdEncoding.EncodeToString([]byte(text)), 5), decoder: protoscan.Base64Decoder(base64.RawStdEncoding)},
+		{
+			name:    "base64 concatenated",
+			input:   base64.StdEncoding.EncodeToString([]byte(text[:13])) + "\n" + base64.StdEncoding.EncodeToString([]byte(text[13:])),
+			decoder: protoscan.Base64Decoder(base64.StdEncoding),
+		},
+		{name: "base64 invalid", input: "bG9y!", decoder: protoscan.Base64Decoder(base64.StdEncoding), err: true},
+	} {
+		for _, f := range protoscantest.Fragmentations() {
+			s := protoscan.New(
+				f.Reader([]byte(test.input)),
+				protoscan.WithSplit(protoscan.ScanRawLines),
+				protoscan.WithTransform(test.decoder),
+			)
+			var b strings.Builder
+			for s.Scan() {
+				b.Write(s.Token())
+			}
+			if test.err {
+				if s.Err() == nil {
+					t.Errorf("%s: %s: expected error", test.name, f.Name)
+				}
+				continue
+			}
+			if s.Err() != nil {
+				t.Fatalf("%s: %s: %v", test.name, f.Name, s.Err())
+			}
+			if b.String() != text {
+				t.Errorf("%s: %s: expected %q; got %q", test.name, f.Name, text, b.String())
+			}
+		}
+	}
+}
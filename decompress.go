@@ -0,0 +1,160 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+)
+
+// Codec detects and decompresses a compressed data stream.
+// Gzip, Zlib, Snappy and LZ4 are provided, codecs of other formats such as
+// zstd may be implemented by wrapping the readers of the third-party
+// packages.
+type Codec interface {
+	// Detect reports whether the header of the data stream is the one
+	// of the codec. It is called with the header growing as the data is
+	// read, until a codec matches or CodecHeaderSize bytes or io.EOF are
+	// read, so the header shorter than the magic number of the codec does
+	// not match.
+	Detect(header []byte) bool
+	// NewReader returns a reader of the decompressed data stream.
+	NewReader(r io.Reader) (io.Reader, error)
+}
+
+// CodecHeaderSize is the maximum size of the header passed to Codec.Detect.
+const CodecHeaderSize = 16
+
+// Codecs of the compressed data streams.
+var (
+	Gzip   Codec = gzipCodec{}
+	Zlib   Codec = zlibCodec{}
+	Snappy Codec = snappyCodec{} // The framing format of the snappy.
+	LZ4    Codec = lz4Codec{}    // The frame format of the LZ4.
+)
+
+// WithDecompression sets the codecs of the compressed data stream.
+// The codec is detected by the header of the stream, a stream which does not
+// match any of the codecs is scanned as is. The data is decompressed before
+// the split function runs, so the hints and the maximum size of the buffer
+// are applied to the decompressed bytes.
+func WithDecompression(codecs ...Codec) Option {
+	return func(s *Protoscan) { s.codecs = codecs }
+}
+
+// decompressReader reads the data stream decompressed by the codec detected
+// on the header of the stream.
+type decompressReader struct {
+	reader io.Reader    // The reader of the raw data.
+	codecs []Codec      // The codecs to detect.
+	header []byte       // The header read so far, until the codec is detected.
+	r      io.Reader    // The reader of the decompressed data, nil until detected.
+	stamp  *stampReader // The reader of the raw data recording its timestamps.
+}
+
+func (d *decompressReader) Read(p []byte) (int, error) {
+	if d.r == nil {
+		if d.stamp == nil {
+			d.stamp = &stampReader{reader: d.reader}
+			d.header = make([]byte, 0, CodecHeaderSize)
+		}
+		for len(d.header) < CodecHeaderSize && !d.match() {
+			n, err := d.stamp.Read(d.header[len(d.header):CodecHeaderSize])
+			d.header = d.header[:len(d.header)+n]
+			if err == io.EOF {
+				break
+			}
+			if err != nil || n == 0 {
+				// The header read so far is kept for the next call.
+				return 0, err
+			}
+		}
+		if err := d.detect(); err != nil {
+			return 0, err
+		}
+	}
+	return d.r.Read(p)
+}
+
+// match reports whether the header read so far matches one of the codecs.
+func (d *decompressReader) match() bool {
+	for _, c := range d.codecs {
+		if c.Detect(d.header) {
+			return true
+		}
+	}
+	return false
+}
+
+// detect chooses the codec by the header.
+func (d *decompressReader) detect() error {
+	raw := &prefixReader{prefix: d.header, r: d.stamp}
+	d.r, d.header = raw, nil
+	for _, c := range d.codecs {
+		if c.Detect(raw.prefix) {
+			r, err := c.NewReader(raw)
+			if err != nil {
+				d.r = &prefixReader{err: err}
+				return err
+			}
+			d.r = r
+			break
+		}
+	}
+	return nil
+}
+
+// prefixReader reads the prefix, then the reader, or the error of the codec
+// failed to start if set.
+type prefixReader struct {
+	prefix []byte
+	err    error
+	r      io.Reader
+}
+
+func (p *prefixReader) Read(b []byte) (int, error) {
+	if len(p.prefix) > 0 {
+		n := copy(b, p.prefix)
+		p.prefix = p.prefix[n:]
+		return n, nil
+	}
+	if p.err != nil {
+		return 0, p.err
+	}
+	return p.r.Read(b)
+}
+
+// unexpectedEOF converts the io.EOF in the middle of the compressed data to
+// the io.ErrUnexpectedEOF.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+type gzipCodec struct{}
+
+func (gzipCodec) Detect(header []byte) bool {
+	return len(header) >= 2 && header[0] == 0x1f && header[1] == 0x8b
+}
+
+func (gzipCodec) NewReader(r io.Reader) (io.Reader, error) {
+	return gzip.NewReader(r)
+}
+
+type zlibCodec struct{}
+
+func (zlibCodec) Detect(header []byte) bool {
+	// Deflate method of the window up to 32 KiB and the header checksum
+	// FCHECK as of RFC 1950.
+	return len(header) >= 2 && header[0]&0x0f == 8 && header[0]>>4 <= 7 &&
+		(uint(header[0])<<8|uint(header[1]))%31 == 0
+}
+
+func (zlibCodec) NewReader(r io.Reader) (io.Reader, error) {
+	return zlib.NewReader(r)
+}
@@ -0,0 +1,199 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func compress(t *testing.T, w io.WriteCloser, text string) {
+	if _, err := io.WriteString(w, text); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// snappyFramed returns the snappy framing format of the count repeats of
+// the unit, compressed as the literal of the unit and the copies of it.
+func snappyFramed(unit string, count int) []byte {
+	text := strings.Repeat(unit, count)
+	block := binary.AppendUvarint(nil, uint64(len(text)))
+	block = append(block, byte(len(unit)-1)<<2)
+	block = append(block, unit...)
+	for rest := len(text) - len(unit); rest > 0; rest -= 64 {
+		n := min(rest, 64)
+		block = append(block, byte(n-1)<<2|2, byte(len(unit)), byte(len(unit)>>8))
+	}
+	c := crc32.Checksum([]byte(text), crc32.MakeTable(crc32.Castagnoli))
+	chunk := binary.LittleEndian.AppendUint32(nil, (c>>15|c<<17)+0xa282ead8)
+	chunk = append(chunk, block...)
+	data := []byte("\xff\x06\x00\x00sNaPpY")
+	data = append(data, 0, byte(len(chunk)), byte(len(chunk)>>8), byte(len(chunk)>>16))
+	return append(data, chunk...)
+}
+
+// lz4Framed returns the LZ4 frame of the count repeats of the unit, as the
+// uncompressed block of the unit and the dependent block copying it.
+func lz4Framed(unit string, count int, contentSize bool) []byte {
+	// The dependent blocks of up to 64 KiB, the header checksum is not
+	// verified.
+	data := []byte("\x04\x22\x4d\x18\x40\x40")
+	if contentSize {
+		data[4] |= 0x08
+		data = binary.LittleEndian.AppendUint64(data, uint64(len(unit)*count))
+	}
+	data = append(data, 0)
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(unit))|0x80000000)
+	data = append(data, unit...)
+	block := []byte{0x0f, byte(len(unit)), byte(len(unit) >> 8)}
+	match := len(unit)*(count-1) - 4 - 15
+	for ; match >= 255; match -= 255 {
+		block = append(block, 255)
+	}
+	block = append(block, byte(match))
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(block)))
+	data = append(data, block...)
+	return binary.LittleEndian.AppendUint32(data, 0)
+}
+
+func TestDecompression(t *testing.T) {
+	text := strings.Repeat("lorem ipsum dolor sit amet\n", 100)
+	gz, zl := new(bytes.Buffer), new(bytes.Buffer)
+	compress(t, gzip.NewWriter(gz), text)
+	compress(t, zlib.NewWriter(zl), text)
+	for name, data := range map[string][]byte{
+		"gzip":             gz.Bytes(),
+		"zlib":             zl.Bytes(),
+		"snappy":           snappyFramed("lorem ipsum dolor sit amet\n", 100),
+		"lz4":              lz4Framed("lorem ipsum dolor sit amet\n", 100, false),
+		"lz4 content size": lz4Framed("lorem ipsum dolor sit amet\n", 100, true),
+		"raw":              []byte(text),
+	} {
+		s := protoscan.New(
+			&protoscantest.SlowReader{Max: 5, R: bytes.NewReader(data)},
+			protoscan.WithSplit(protoscan.ScanLines),
+			protoscan.WithDecompression(protoscan.Gzip, protoscan.Zlib, protoscan.Snappy, protoscan.LZ4),
+			protoscan.WithMaxBuffer(smallMaxTokenSize),
+		)
+		var n int
+		for ; s.Scan(); n++ {
+			if string(s.Token()) != "lorem ipsum dolor sit amet" {
+				t.Fatalf("%s: %d: unexpected line %q", name, n, s.Token())
+			}
+		}
+		if s.Err() != nil {
+			t.Fatalf("%s: %v", name, s.Err())
+		}
+		if n != 100 {
+			t.Fatalf("%s: expected 100 lines; got %d", name, n)
+		}
+	}
+}
+
+func TestDecompressionCorrupt(t *testing.T) {
+	s := protoscan.New(
+		strings.NewReader("\x1f\x8bcorrupt"),
+		protoscan.WithDecompression(protoscan.Gzip),
+	)
+	for s.Scan() {
+	}
+	if s.Err() == nil {
+		t.Fatal("expected error of the corrupt stream")
+	}
+}
+
+func TestDecompressionCorruptChecksum(t *testing.T) {
+	data := snappyFramed("lorem ipsum dolor sit amet\n", 100)
+	data[len(data)-1] ^= 1
+	s := protoscan.New(bytes.NewReader(data), protoscan.WithDecompression(protoscan.Snappy))
+	for s.Scan() {
+	}
+	if !errors.Is(s.Err(), protoscan.ErrProtocolViolation) {
+		t.Fatalf("expected protocol violation; got %v", s.Err())
+	}
+}
+
+// hiccupReader fails the read number at, counted from 1, with the exceeded
+// deadline.
+type hiccupReader struct {
+	at, n int
+	r     io.Reader
+}
+
+func (r *hiccupReader) Read(p []byte) (int, error) {
+	r.n++
+	if r.n == r.at {
+		return 0, os.ErrDeadlineExceeded
+	}
+	return r.r.Read(p)
+}
+
+// Test that the codec is detected on the header split across the reads,
+// and that the read errors before the detection keep the header read.
+func TestDecompressionHeader(t *testing.T) {
+	text := strings.Repeat("lorem ipsum dolor sit amet\n", 100)
+	gz := new(bytes.Buffer)
+	compress(t, gzip.NewWriter(gz), text)
+	retry := protoscan.RetryPolicy{Attempts: 1, Backoff: protoscan.BackoffPolicy{Initial: time.Microsecond}}
+	errRead := errors.New("read")
+	for _, test := range []struct {
+		name string
+		r    io.Reader
+		want string
+		err  error
+	}{
+		{"short", strings.NewReader("ab\n"), "ab", nil},
+		{"gzip", &protoscantest.SlowReader{Max: 1, R: bytes.NewReader(gz.Bytes())}, text, nil},
+		{"snappy", &protoscantest.SlowReader{Max: 1, R: bytes.NewReader(snappyFramed("lorem ipsum dolor sit amet\n", 100))}, text, nil},
+		{"lz4", &protoscantest.SlowReader{Max: 1, R: bytes.NewReader(lz4Framed("lorem ipsum dolor sit amet\n", 100, false))}, text, nil},
+		{"retry", &hiccupReader{at: 2, r: &protoscantest.SlowReader{Max: 1, R: bytes.NewReader(gz.Bytes())}}, text, nil},
+		{"error", &protoscantest.ErrorReader{N: 7, Err: errRead, R: strings.NewReader("ab\ncd\nef\n")}, "", errRead},
+	} {
+		s := protoscan.New(test.r, protoscan.WithSplit(protoscan.ScanLines), protoscan.WithRetry(retry),
+			protoscan.WithDecompression(protoscan.Gzip, protoscan.Zlib, protoscan.Snappy, protoscan.LZ4))
+		var got strings.Builder
+		for s.Scan() {
+			got.Write(s.Token())
+			got.WriteByte('\n')
+		}
+		if strings.TrimSuffix(got.String(), "\n") != strings.TrimSuffix(test.want, "\n") || s.Err() != test.err {
+			t.Errorf("%s: unexpected tokens %q and error %v", test.name, got.String(), s.Err())
+		}
+	}
+}
+
+func TestDetectZlib(t *testing.T) {
+	for _, test := range []struct {
+		header string
+		want   bool
+	}{
+		{"\x78\x9c", true},
+		{"\x78\x01", true},
+		{"\x08\x1d", true},
+		{"\x78\x9d", false}, // Bad FCHECK.
+		{"\x79\x9c", false}, // Not the deflate method.
+		{"\x88\x98", false}, // Window over 32 KiB.
+		{"\x78", false},
+	} {
+		if got := protoscan.Zlib.Detect([]byte(test.header)); got != test.want {
+			t.Errorf("%q: expected %v; got %v", test.header, test.want, got)
+		}
+	}
+}
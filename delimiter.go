@@ -0,0 +1,43 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"bytes"
+	"errors"
+)
+
+// errEmptyDelimiter is returned by the split function of the
+// ScanDelimiter of the empty delimiter.
+var errEmptyDelimiter = errors.New("protoscan: empty delimiter")
+
+// ScanDelimiter returns a split function for a Protoscan that returns each
+// run of bytes terminated by the delimiter as a token, such as the NUL or
+// the blank line "\r\n\r\n", with the delimiter if keep, otherwise
+// without it. As for the ScanLines, the last non-empty run of bytes is
+// returned even if it has no delimiter. The delimiter is copied. The empty
+// delimiter stops the scan with an error.
+func ScanDelimiter(delim []byte, keep bool) SplitFunc {
+	delim = bytes.Clone(delim)
+	return func(data []byte, atEOF bool) (int, int, []byte, error) {
+		if len(delim) == 0 {
+			return 0, 0, nil, errEmptyDelimiter
+		}
+		if atEOF && len(data) == 0 {
+			return 0, 0, nil, nil
+		}
+		if i := bytes.Index(data, delim); i >= 0 {
+			n := i + len(delim)
+			if keep {
+				return 0, n, data[:n], nil
+			}
+			return 0, n, data[:i], nil
+		}
+		if atEOF {
+			return 0, len(data), data, nil
+		}
+		return 1, 0, nil, nil
+	}
+}
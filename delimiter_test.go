@@ -0,0 +1,34 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanDelimiter(t *testing.T) {
+	protoscantest.TestSplitFunc(t, protoscan.ScanDelimiter([]byte("|||"), false), []protoscantest.Case{
+		{Name: "empty", Input: "", Tokens: nil},
+		{Name: "tokens", Input: "a|||b||c||||||d", Tokens: []string{"a", "b||c", "", "d"}},
+		{Name: "terminated", Input: "a||||||", Tokens: []string{"a", ""}},
+	})
+	protoscantest.TestSplitFunc(t, protoscan.ScanDelimiter([]byte("\r\n\r\n"), true), []protoscantest.Case{
+		{Name: "keep", Input: "A: b\r\n\r\nC: d\r\n\r\n\r\n", Tokens: []string{"A: b\r\n\r\n", "C: d\r\n\r\n", "\r\n"}},
+	})
+	protoscantest.TestSplitFunc(t, protoscan.ScanDelimiter([]byte{0}, false), []protoscantest.Case{
+		{Name: "NUL", Input: "a\x00\x00b", Tokens: []string{"a", "", "b"}},
+	})
+	protoscantest.TestSplitFunc(t, protoscan.ScanDelimiter(nil, false), []protoscantest.Case{
+		{Name: "empty delimiter", Input: "a", Err: protoscan.ErrEmptyDelimiter},
+	})
+}
+
+func FuzzScanDelimiter(f *testing.F) {
+	f.Add([]byte("a\r\n\r\nb\r\n\r"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanDelimiter([]byte("\r\n\r\n"), true)))
+}
@@ -0,0 +1,98 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Patterns of the DLT storage and serial headers.
+var (
+	dltStorage = []byte("DLT\x01")
+	dltSerial  = []byte("DLS\x01")
+)
+
+// Bits of the header type of the DLT standard header.
+const (
+	dltUEH  = 0x01 // Use extended header.
+	dltWEID = 0x04 // With ECU ID.
+	dltWSID = 0x08 // With session ID.
+	dltWTMS = 0x10 // With timestamp.
+)
+
+const (
+	dltStorageHeader  = 16 // Pattern, seconds, microseconds and ECU ID.
+	dltStandardHeader = 4  // Header type, counter and length.
+	dltExtendedHeader = 10 // Message info, arguments, application and context IDs.
+)
+
+// ScanDLT is a split function for a Protoscan that returns each message of
+// the AUTOSAR Diagnostic Log and Trace protocol as a token: the standard
+// header of the header type, the message counter and the big-endian length
+// of the message, optionally preceded by the 16-byte storage header of the
+// "DLT\x01" pattern of the DLT files, or by the 4-byte serial header of
+// the "DLS\x01" pattern. The length shorter than the standard header with
+// the ECU ID, the session ID, the timestamp and the extended header its
+// header type tells, and the protocol version other than 1, are reported
+// as the ErrNeedResync, advancing 1 byte.
+func ScanDLT(data []byte, atEOF bool) (int, int, []byte, error) {
+	if len(data) == 0 && atEOF {
+		return 0, 0, nil, nil
+	}
+	prefix := 0
+	for _, p := range []struct {
+		pattern []byte
+		n       int
+	}{{dltStorage, dltStorageHeader}, {dltSerial, len(dltSerial)}} {
+		m := data[:min(len(data), len(p.pattern))]
+		if len(m) == 0 || !bytes.HasPrefix(p.pattern, m) {
+			continue
+		}
+		if len(m) < len(p.pattern) {
+			// The pattern tells the header.
+			if atEOF {
+				return 0, 0, nil, io.ErrUnexpectedEOF
+			}
+			return len(p.pattern) - len(m), 0, nil, nil
+		}
+		prefix = p.n
+		break
+	}
+	need := prefix + dltStandardHeader
+	if len(data) < need {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return need - len(data), 0, nil, nil
+	}
+	htyp := data[prefix]
+	if htyp>>5 != 1 {
+		return 0, 1, nil, fmt.Errorf("%w: DLT version %d", ErrNeedResync, htyp>>5)
+	}
+	minLength := dltStandardHeader
+	for _, f := range []struct {
+		bit byte
+		n   int
+	}{{dltWEID, 4}, {dltWSID, 4}, {dltWTMS, 4}, {dltUEH, dltExtendedHeader}} {
+		if htyp&f.bit != 0 {
+			minLength += f.n
+		}
+	}
+	length := int(binary.BigEndian.Uint16(data[prefix+2:]))
+	if length < minLength {
+		return 0, 1, nil, fmt.Errorf("%w: DLT message length %d", ErrNeedResync, length)
+	}
+	n := prefix + length
+	if n > len(data) {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return n - len(data), 0, nil, nil
+	}
+	return 0, n, data[:n], nil
+}
@@ -0,0 +1,41 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+// dltMsg returns the DLT message of the header type, the optional header
+// fields and the payload.
+func dltMsg(htyp byte, fields, payload string) string {
+	return string([]byte{htyp, 7}) + be16(4+len(fields)+len(payload)) + fields + payload
+}
+
+func TestScanDLT(t *testing.T) {
+	// The ECU ID, the timestamp and the extended header.
+	verbose := dltMsg(0x35, "ECU1"+"\x00\x00\x00\x10"+"\x41\x01APP1CTX1", "\x00\x02\x00\x00\x03\x00hi\x00")
+	plain := dltMsg(0x20, "", "\x01\x02\x03")
+	storage := "DLT\x01" + "\x01\x00\x00\x00\x02\x00\x00\x00" + "ECU1"
+	protoscantest.TestSplitFunc(t, protoscan.ScanDLT, []protoscantest.Case{
+		{Name: "empty", Input: "", Tokens: nil},
+		{Name: "raw", Input: verbose + plain, Tokens: []string{verbose, plain}},
+		{Name: "storage", Input: storage + verbose + storage + plain, Tokens: []string{storage + verbose, storage + plain}},
+		{Name: "serial", Input: "DLS\x01" + plain + "DLS\x01" + verbose, Tokens: []string{"DLS\x01" + plain, "DLS\x01" + verbose}},
+		{Name: "short pattern", Input: "DL", Err: io.ErrUnexpectedEOF},
+		{Name: "short storage", Input: storage + verbose[:6], Err: io.ErrUnexpectedEOF},
+		{Name: "version", Input: "\x40\x00\x00\x04", Err: protoscan.ErrNeedResync},
+		{Name: "length", Input: dltMsg(0x21, "", "")[:4], Err: protoscan.ErrNeedResync},
+	})
+}
+
+func FuzzScanDLT(f *testing.F) {
+	f.Add([]byte("DLT\x01\x01\x00\x00\x00\x02\x00\x00\x00ECU1\x20\x00\x00\x05x" + "DLS\x01\x20\x00\x00\x04"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanDLT))
+}
@@ -0,0 +1,34 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"bytes"
+	"io"
+)
+
+// ScanDotStuffed is a split function for a Protoscan that returns each
+// line of the dot-stuffed body of the SMTP DATA command or of the NNTP
+// multi-line responses as a token, stripped of its CRLF or LF, and of its
+// leading dot. The line of the lone dot terminating the body is delivered
+// as the empty token together with the FinalToken, leaving the data after
+// it unread. The body unterminated at EOF is the io.ErrUnexpectedEOF.
+func ScanDotStuffed(data []byte, atEOF bool) (int, int, []byte, error) {
+	i := bytes.IndexByte(data, '\n')
+	if i < 0 {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return 1, 0, nil, nil
+	}
+	line := dropCR(data[:i])
+	if len(line) > 0 && line[0] == '.' {
+		if len(line) == 1 {
+			return 0, i + 1, line[1:], FinalToken
+		}
+		line = line[1:]
+	}
+	return 0, i + 1, line, nil
+}
@@ -0,0 +1,32 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanDotStuffed(t *testing.T) {
+	protoscantest.TestSplitFunc(t, protoscan.ScanDotStuffed, []protoscantest.Case{
+		{Name: "empty", Input: "", Err: io.ErrUnexpectedEOF},
+		{
+			Name:   "body",
+			Input:  "Subject: hi\r\n\r\n..\r\n...x\r\n.y\nz\r\n.\r\nQUIT\r\n",
+			Tokens: []string{"Subject: hi", "", ".", "..x", "y", "z", ""},
+		},
+		{Name: "empty body", Input: ".\r\n", Tokens: []string{""}},
+		{Name: "unterminated", Input: "a\r\nb\r\n", Tokens: []string{"a", "b"}, Err: io.ErrUnexpectedEOF},
+		{Name: "unterminated line", Input: "a\r\n.", Tokens: []string{"a"}, Err: io.ErrUnexpectedEOF},
+	})
+}
+
+func FuzzScanDotStuffed(f *testing.F) {
+	f.Add([]byte("a\r\n..b\r\n.\r\nc"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanDotStuffed))
+}
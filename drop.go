@@ -0,0 +1,38 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import "bytes"
+
+// WithDrop sets the function reporting whether the token is dropped.
+// Dropped tokens, typically protocol keepalives, are never returned by Scan,
+// except the tokens delivered together with the FinalToken.
+func WithDrop(drop func(token []byte) bool) Option {
+	return func(s *Protoscan) { s.drop = drop }
+}
+
+// Dropped returns the count of tokens dropped by the function set by WithDrop.
+func (s *Protoscan) Dropped() int {
+	return s.dropped
+}
+
+// IsFIXKeepalive reports whether the token is a FIX Heartbeat or TestRequest
+// message, MsgType(35) is 0 or 1.
+func IsFIXKeepalive(token []byte) bool {
+	return bytes.Contains(token, []byte("\x0135=0\x01")) ||
+		bytes.Contains(token, []byte("\x0135=1\x01"))
+}
+
+// IsNATSKeepalive reports whether the token is a NATS PING or PONG line
+// split by the ScanLines.
+func IsNATSKeepalive(token []byte) bool {
+	return bytes.EqualFold(token, []byte("PING")) || bytes.EqualFold(token, []byte("PONG"))
+}
+
+// IsSoupBinTCPKeepalive reports whether the token is a SoupBinTCP server
+// or client heartbeat packet including its length.
+func IsSoupBinTCPKeepalive(token []byte) bool {
+	return len(token) == 3 && token[0] == 0 && token[1] == 1 && (token[2] == 'H' || token[2] == 'R')
+}
@@ -0,0 +1,59 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+)
+
+func TestDrop(t *testing.T) {
+	s := protoscan.New(
+		strings.NewReader("PING\nPUB a 1\nx\nping\nPONG\nPUB b 1\ny\nPING\n"),
+		protoscan.WithSplit(protoscan.ScanLines),
+		protoscan.WithDrop(protoscan.IsNATSKeepalive),
+	)
+	var lines []string
+	for s.Scan() {
+		lines = append(lines, string(s.Token()))
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	if got := strings.Join(lines, "|"); got != "PUB a 1|x|PUB b 1|y" {
+		t.Fatalf("unexpected lines: %q", got)
+	}
+	if s.Dropped() != 4 {
+		t.Fatalf("expected 4 dropped tokens; got %d", s.Dropped())
+	}
+}
+
+func TestIsFIXKeepalive(t *testing.T) {
+	for msg, want := range map[string]bool{
+		"8=FIX.4.4\x019=5\x0135=0\x0110=000\x01":           true,
+		"8=FIX.4.4\x019=11\x0135=1\x01112=1\x0110=000\x01": true,
+		"8=FIX.4.4\x019=5\x0135=D\x0110=000\x01":           false,
+		"8=FIX.4.4\x019=5\x01135=0\x0110=000\x01":          false,
+	} {
+		if got := protoscan.IsFIXKeepalive([]byte(msg)); got != want {
+			t.Errorf("%q: expected %t got %t", msg, want, got)
+		}
+	}
+}
+
+func TestIsSoupBinTCPKeepalive(t *testing.T) {
+	for msg, want := range map[string]bool{
+		"\x00\x01H":  true,
+		"\x00\x01R":  true,
+		"\x00\x02H1": false,
+		"\x00\x01S":  false,
+	} {
+		if got := protoscan.IsSoupBinTCPKeepalive([]byte(msg)); got != want {
+			t.Errorf("%q: expected %t got %t", msg, want, got)
+		}
+	}
+}
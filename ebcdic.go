@@ -0,0 +1,43 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+// Control characters of the EBCDIC line endings.
+const (
+	ebcdicCR = 0x0d // Carriage return.
+	ebcdicNL = 0x15 // New line.
+	ebcdicLF = 0x25 // Line feed.
+)
+
+// ScanLinesEBCDIC is a split function for a Protoscan that returns each
+// line of the EBCDIC text, such as the streams of the mainframes, stripped
+// of its end-of-line marker, without transcoding it: the new line NL
+// (0x15), or the line feed LF (0x25) preceded by an optional carriage
+// return CR (0x0d). As for the ScanLines, the returned line may be empty
+// and the last non-empty line of input is returned even if it has no
+// end-of-line marker.
+func ScanLinesEBCDIC(data []byte, atEOF bool) (int, int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, 0, nil, nil
+	}
+	for i, c := range data {
+		switch c {
+		case ebcdicNL:
+			return 0, i + 1, data[:i], nil
+		case ebcdicLF:
+			if i > 0 && data[i-1] == ebcdicCR {
+				return 0, i + 1, data[:i-1], nil
+			}
+			return 0, i + 1, data[:i], nil
+		}
+	}
+	if atEOF {
+		if data[len(data)-1] == ebcdicCR {
+			return 0, len(data), data[:len(data)-1], nil
+		}
+		return 0, len(data), data, nil
+	}
+	return 1, 0, nil, nil
+}
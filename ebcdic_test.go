@@ -0,0 +1,32 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanLinesEBCDIC(t *testing.T) {
+	// "HELLO" and "WORLD" in the code page 037.
+	hello, world := "\xc8\xc5\xd3\xd3\xd6", "\xe6\xd6\xd9\xd3\xc4"
+	protoscantest.TestSplitFunc(t, protoscan.ScanLinesEBCDIC, []protoscantest.Case{
+		{Name: "empty", Input: "", Tokens: nil},
+		{
+			Name:   "lines",
+			Input:  hello + "\x15" + world + "\x0d\x25" + "\x15" + hello + "\x25" + world,
+			Tokens: []string{hello, world, "", hello, world},
+		},
+		{Name: "ASCII newline", Input: hello + "\n" + world + "\x15", Tokens: []string{hello + "\n" + world}},
+		{Name: "final CR", Input: hello + "\x0d", Tokens: []string{hello}},
+	})
+}
+
+func FuzzScanLinesEBCDIC(f *testing.F) {
+	f.Add([]byte("\xc8\x15\x0d\x25\xc5\x25\x0d"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanLinesEBCDIC))
+}
@@ -0,0 +1,74 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// esBulkAction returns the action of the action line of the bulk request
+// body, the first key of its JSON object.
+func esBulkAction(line []byte) (string, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return "", fmt.Errorf("%w: Elasticsearch bulk action %q is not an object", ErrCorruptFrame, line)
+	}
+	tok, err := dec.Token()
+	action, ok := tok.(string)
+	if err != nil || !ok {
+		return "", fmt.Errorf("%w: Elasticsearch bulk action %q without a name", ErrCorruptFrame, line)
+	}
+	return action, nil
+}
+
+// ScanESBulk is a split function for a Protoscan that returns the action
+// lines of the Elasticsearch _bulk request body with their document lines
+// as tokens: the index, the create and the update actions are followed by
+// the document line, joined by the newline in the token, and the delete
+// action has none. The final newline is dropped, as are the empty lines
+// between the actions. The action line which is not the JSON object of a
+// known action is reported as the ErrCorruptFrame, advancing over the line.
+func ScanESBulk(data []byte, atEOF bool) (int, int, []byte, error) {
+	i := bytes.IndexByte(data, '\n')
+	if i < 0 {
+		if !atEOF {
+			return 1, 0, nil, nil
+		}
+		if len(data) == 0 {
+			return 0, 0, nil, nil
+		}
+		i = len(data)
+	}
+	advance := min(i+1, len(data))
+	line := dropCR(data[:i])
+	if len(bytes.TrimSpace(line)) == 0 {
+		return 0, advance, nil, nil
+	}
+	action, err := esBulkAction(line)
+	if err != nil {
+		return 0, advance, nil, err
+	}
+	switch action {
+	case "delete":
+		return 0, advance, line, nil
+	case "index", "create", "update":
+	default:
+		return 0, advance, nil, fmt.Errorf("%w: unknown Elasticsearch bulk action %q", ErrCorruptFrame, action)
+	}
+	j := bytes.IndexByte(data[advance:], '\n')
+	if j < 0 {
+		if !atEOF {
+			return 1, 0, nil, nil
+		}
+		if advance == len(data) {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return 0, len(data), dropCR(data), nil
+	}
+	return 0, advance + j + 1, dropCR(data[:advance+j]), nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanESBulk(t *testing.T) {
+	protoscantest.TestSplitFunc(t, protoscan.ScanESBulk, []protoscantest.Case{
+		{Name: "empty", Input: "", Tokens: nil},
+		{
+			Name: "pairs",
+			Input: `{"index":{"_index":"t","_id":"1"}}` + "\n" + `{"f":"a\nb"}` + "\n" +
+				`{"delete":{"_index":"t","_id":"2"}}` + "\n" +
+				`{ "create" : {"_index":"t"}}` + "\r\n" + `{"f":1}` + "\r\n" +
+				"\n" +
+				`{"update":{"_id":"3"}}` + "\n" + `{"doc":{"f":2}}`,
+			Tokens: []string{
+				`{"index":{"_index":"t","_id":"1"}}` + "\n" + `{"f":"a\nb"}`,
+				`{"delete":{"_index":"t","_id":"2"}}`,
+				`{ "create" : {"_index":"t"}}` + "\r\n" + `{"f":1}`,
+				`{"update":{"_id":"3"}}` + "\n" + `{"doc":{"f":2}}`,
+			},
+		},
+		{
+			Name:   "delete last",
+			Input:  `{"delete":{"_id":"1"}}`,
+			Tokens: []string{`{"delete":{"_id":"1"}}`},
+		},
+		{
+			Name:   "missing document",
+			Input:  `{"delete":{"_id":"1"}}` + "\n" + `{"index":{"_id":"2"}}` + "\n",
+			Tokens: []string{`{"delete":{"_id":"1"}}`},
+			Err:    io.ErrUnexpectedEOF,
+		},
+		{
+			Name:  "unknown action",
+			Input: `{"upsert":{}}` + "\n" + `{"f":1}` + "\n",
+			Err:   protoscan.ErrCorruptFrame,
+		},
+		{
+			Name:  "not an object",
+			Input: `["index"]` + "\n",
+			Err:   protoscan.ErrCorruptFrame,
+		},
+	})
+}
+
+func FuzzScanESBulk(f *testing.F) {
+	f.Add([]byte(`{"index":{}}` + "\n" + `{"f":1}` + "\n" + `{"delete":{}}` + "\n"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanESBulk))
+}
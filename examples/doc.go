@@ -0,0 +1,9 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package examples holds runnable examples of the protocols scanned by
+// the Protoscan: an ISO 8583 echo server, a FIX session skeleton, an MLLP
+// HL7 receiver and a Language Server Protocol frame reader. The examples
+// are tested by the go test, so they double as integration tests.
+package examples
@@ -0,0 +1,57 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package examples_test
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/protoscan/protoscan"
+)
+
+// fix returns the FIX message of the fields separated by the '|'.
+func fix(fields string) string {
+	body := strings.ReplaceAll(fields, "|", "\x01") + "\x01"
+	msg := fmt.Sprintf("8=FIX.4.4\x019=%d\x01%s", len(body), body)
+	var sum int
+	for i := 0; i < len(msg); i++ {
+		sum += int(msg[i])
+	}
+	return fmt.Sprintf("%s10=%03d\x01", msg, sum%256)
+}
+
+// Example_fixSession reads the FIX messages of a session, dropping the
+// heartbeats, reporting the gaps of the MsgSeqNum and stopping on the first
+// malformed message.
+func Example_fixSession() {
+	stream := fix("35=A|34=1|49=SELL|56=BUY|98=0|108=30") +
+		fix("35=0|34=2|49=SELL|56=BUY") +
+		fix("35=8|34=5|49=SELL|56=BUY|11=ORD1|39=2") +
+		"8=FIX.4.4\x019=x\x01"
+	session := protoscan.NewFIXSession(func(e protoscan.FIXEvent) {
+		if e.Kind == protoscan.FIXSequenceGap {
+			fmt.Printf("gap: expected %d, got %d\n", e.Expected, e.SeqNum)
+		}
+	})
+	s := protoscan.New(
+		strings.NewReader(stream),
+		protoscan.WithSplit(protoscan.ScanFIX),
+		protoscan.WithDrop(func(msg []byte) bool {
+			session.Track(msg)
+			return protoscan.IsFIXKeepalive(msg)
+		}),
+	)
+	for s.Scan() {
+		fmt.Println(strings.ReplaceAll(string(s.Token()), "\x01", "|"))
+	}
+	fmt.Println("dropped:", s.Dropped())
+	fmt.Println("error:", s.Err())
+	// Output:
+	// 8=FIX.4.4|9=37|35=A|34=1|49=SELL|56=BUY|98=0|108=30|10=076|
+	// gap: expected 3, got 5
+	// 8=FIX.4.4|9=38|35=8|34=5|49=SELL|56=BUY|11=ORD1|39=2|10=193|
+	// dropped: 1
+	// error: protoscan: need resync: bad FIX BodyLength
+}
@@ -0,0 +1,62 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package examples_test
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/protoscan/protoscan"
+)
+
+// splitISO8583 splits the ISO 8583 messages prefixed by the 2-byte binary
+// length.
+var splitISO8583 = protoscan.ScanISO8583(protoscan.ISO8583Config{Encoding: protoscan.ISO8583Binary})
+
+// frameISO8583 prefixes the message by the 2-byte binary length.
+func frameISO8583(dst, msg []byte) ([]byte, error) {
+	if len(msg) > 0xffff {
+		return nil, protoscan.ErrTooLong
+	}
+	dst = append(dst, byte(len(msg)>>8), byte(len(msg)))
+	return append(dst, msg...), nil
+}
+
+// Example_iso8583Echo serves the network management echo requests (0800)
+// by the echo responses (0810).
+func Example_iso8583Echo() {
+	client, server := net.Pipe()
+	go func() {
+		s := protoscan.NewSession(server, splitISO8583, frameISO8583)
+		defer s.Close()
+		for s.Scan() {
+			req := s.Token()
+			if string(req[:4]) != "0800" {
+				continue
+			}
+			resp := append([]byte("0810"), req[4:]...)
+			if err := s.Send(resp); err != nil {
+				return
+			}
+		}
+	}()
+
+	s := protoscan.NewSession(client, splitISO8583, frameISO8583)
+	defer s.Close()
+	for _, stan := range []string{"000001", "000002"} {
+		if err := s.Send([]byte("0800" + stan + "301")); err != nil {
+			fmt.Println("send:", err)
+			return
+		}
+		if !s.Scan() {
+			fmt.Println("scan:", s.Err())
+			return
+		}
+		fmt.Printf("%s\n", s.Token())
+	}
+	// Output:
+	// 0810000001301
+	// 0810000002301
+}
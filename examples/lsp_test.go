@@ -0,0 +1,45 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package examples_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/protoscan/protoscan"
+)
+
+func lsp(content string) string {
+	return fmt.Sprintf("Content-Length: %d\r\nContent-Type: application/vscode-jsonrpc; charset=utf-8\r\n\r\n%s", len(content), content)
+}
+
+// Example_lspReader reads the JSON-RPC messages of a language server.
+func Example_lspReader() {
+	stream := lsp(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`) +
+		lsp(`{"jsonrpc":"2.0","method":"initialized","params":{}}`) +
+		"Content-Length: x\r\n\r\n"
+	s := protoscan.New(strings.NewReader(stream), protoscan.WithSplit(protoscan.ScanLSP))
+	for s.Scan() {
+		var msg struct {
+			ID     *int   `json:"id"`
+			Method string `json:"method"`
+		}
+		if err := json.Unmarshal(s.Token(), &msg); err != nil {
+			fmt.Println("json:", err)
+			continue
+		}
+		if msg.ID != nil {
+			fmt.Printf("request %d: %s\n", *msg.ID, msg.Method)
+		} else {
+			fmt.Printf("notification: %s\n", msg.Method)
+		}
+	}
+	fmt.Println("error:", s.Err())
+	// Output:
+	// request 1: initialize
+	// notification: initialized
+	// error: protoscan: protocol violation: bad Content-Length "x"
+}
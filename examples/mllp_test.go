@@ -0,0 +1,65 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package examples_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/protoscan/protoscan"
+)
+
+// MLLP block characters.
+const (
+	startBlock = '\x0b'
+	endBlock   = "\x1c\r"
+)
+
+// splitMLLP splits the HL7 messages wrapped in the MLLP blocks. Garbage
+// before the start of the block is skipped, so the scanner resynchronizes
+// with the stream after a broken block.
+func splitMLLP(data []byte, atEOF bool) (int, int, []byte, error) {
+	i := bytes.IndexByte(data, startBlock)
+	if i < 0 {
+		// Skip the garbage, hint more data unless at EOF.
+		if atEOF {
+			return 0, len(data), nil, nil
+		}
+		return 1, len(data), nil, nil
+	}
+	if i > 0 {
+		return 0, i, nil, nil
+	}
+	j := bytes.Index(data, []byte(endBlock))
+	if j < 0 {
+		if atEOF {
+			return 0, len(data), nil, nil
+		}
+		return 1, 0, nil, nil
+	}
+	return 0, j + len(endBlock), data[1:j], nil
+}
+
+// Example_mllpReceiver receives the HL7 messages, printing the message type
+// of each one.
+func Example_mllpReceiver() {
+	stream := "\x0bMSH|^~\\&|LAB|HOSP|||202201011200||ORU^R01|1|P|2.5\rPID|1||12345\x1c\r" +
+		"noise" +
+		"\x0bMSH|^~\\&|ADT|HOSP|||202201011201||ADT^A01|2|P|2.5\rPID|1||67890\x1c\r" +
+		"\x0bMSH|^~\\&|TRUNCATED"
+	s := protoscan.New(strings.NewReader(stream), protoscan.WithSplit(splitMLLP))
+	for s.Scan() {
+		segments := strings.Split(string(s.Token()), "\r")
+		fields := strings.Split(segments[0], "|")
+		fmt.Printf("%s control %s, %d segments\n", fields[8], fields[9], len(segments))
+	}
+	if err := s.Err(); err != nil {
+		fmt.Println("error:", err)
+	}
+	// Output:
+	// ORU^R01 control 1, 2 segments
+	// ADT^A01 control 2, 2 segments
+}
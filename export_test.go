@@ -7,9 +7,15 @@ package protoscan
 // Exported for testing only.
 
 var (
-	MaxBuffer = maxBuffer
-	IsSpace   = isSpace
+	MaxBuffer         = maxBuffer
+	IsSpace           = isSpace
+	CQLCRC24          = cqlCRC24
+	ErrBadBoundary    = errBadBoundary
+	ErrEmptyDelimiter = errEmptyDelimiter
 )
 
 // ErrOrEOF is like Err, but returns EOF. Used to test a corner case.
 func (s *Protoscan) ErrOrEOF() error { return s.err }
+
+// ReadMarks returns the count of the marks of the reads.
+func (s *Protoscan) ReadMarks() int { return len(s.reads) }
@@ -0,0 +1,164 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// SlowPolicy defines what the Fanout does with the token when the queue of
+// the subscriber is full.
+type SlowPolicy int
+
+// Policies of the slow subscribers.
+const (
+	SlowBlock      SlowPolicy = iota // Wait for the subscriber, slowing down all of them.
+	SlowDrop                         // Drop the token for the subscriber.
+	SlowDisconnect                   // Unsubscribe the subscriber.
+)
+
+// Fanout scans the tokens once and delivers the copy of each token to each
+// of the subscribers through their bounded queues.
+type Fanout struct {
+	scan    *Protoscan
+	mu      sync.Mutex
+	subs    []*Subscriber
+	stopped bool // Whether the Run has returned.
+}
+
+// NewFanout returns a new Fanout of the tokens of the Protoscan.
+func NewFanout(s *Protoscan) *Fanout {
+	return &Fanout{scan: s}
+}
+
+// Subscriber receives the tokens of the Fanout.
+type Subscriber struct {
+	c       chan []byte
+	policy  SlowPolicy
+	dropped atomic.Int64
+	gone    atomic.Bool
+
+	mu     sync.Mutex    // Guards the sends to the channel and its closing.
+	closed bool          // Whether the channel is closed.
+	done   chan struct{} // Closed by the Unsubscribe to cancel the send in flight.
+	once   sync.Once
+}
+
+// Subscribe adds the subscriber with the queue of the size and the policy
+// applied when the queue is full. The subscriber receives the tokens scanned
+// after the subscription. The channel of the subscriber added after the Run
+// has returned is closed.
+func (f *Fanout) Subscribe(queue int, policy SlowPolicy) *Subscriber {
+	sub := &Subscriber{c: make(chan []byte, queue), policy: policy, done: make(chan struct{})}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.stopped {
+		sub.closed = true
+		close(sub.c)
+		return sub
+	}
+	f.subs = append(f.subs, sub)
+	return sub
+}
+
+// Unsubscribe removes the subscriber and closes its channel, the blocked
+// delivery to the subscriber is canceled. The queued tokens are still
+// received from the channel.
+func (f *Fanout) Unsubscribe(sub *Subscriber) {
+	sub.once.Do(func() { close(sub.done) })
+	f.remove(sub)
+	sub.mu.Lock()
+	sub.close()
+	sub.mu.Unlock()
+}
+
+// Tokens returns the channel of the tokens, it is closed when the scan
+// stops or the subscriber is unsubscribed or disconnected.
+func (sub *Subscriber) Tokens() <-chan []byte {
+	return sub.c
+}
+
+// Dropped returns the count of the tokens dropped for the subscriber.
+func (sub *Subscriber) Dropped() int64 {
+	return sub.dropped.Load()
+}
+
+// Disconnected reports whether the subscriber was disconnected as slow.
+func (sub *Subscriber) Disconnected() bool {
+	return sub.gone.Load()
+}
+
+// close closes the channel once, the caller must hold the mutex.
+func (sub *Subscriber) close() {
+	if !sub.closed {
+		sub.closed = true
+		close(sub.c)
+	}
+}
+
+// Run scans the tokens and delivers them to the subscribers until the scan
+// stops, then it closes the channels of the subscribers and returns the
+// error of the scan.
+func (f *Fanout) Run() error {
+	for f.scan.Scan() {
+		f.mu.Lock()
+		subs := f.subs
+		f.mu.Unlock()
+		for _, sub := range subs {
+			f.deliver(sub, append([]byte(nil), f.scan.Token()...))
+		}
+	}
+	f.mu.Lock()
+	subs := f.subs
+	f.subs = nil
+	f.stopped = true
+	f.mu.Unlock()
+	for _, sub := range subs {
+		sub.mu.Lock()
+		sub.close()
+		sub.mu.Unlock()
+	}
+	return f.scan.Err()
+}
+
+// deliver delivers the token to the subscriber according to its policy.
+func (f *Fanout) deliver(sub *Subscriber, token []byte) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	if sub.closed {
+		return
+	}
+	if sub.policy == SlowBlock {
+		select {
+		case sub.c <- token:
+		case <-sub.done:
+		}
+		return
+	}
+	select {
+	case sub.c <- token:
+	default:
+		if sub.policy == SlowDrop {
+			sub.dropped.Add(1)
+			return
+		}
+		sub.gone.Store(true)
+		sub.close()
+		f.remove(sub)
+	}
+}
+
+// remove removes the subscriber from the subscribers.
+func (f *Fanout) remove(sub *Subscriber) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, s := range f.subs {
+		if s == sub {
+			f.subs = append(f.subs[:i:i], f.subs[i+1:]...)
+			break
+		}
+	}
+}
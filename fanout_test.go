@@ -0,0 +1,81 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+)
+
+func TestFanout(t *testing.T) {
+	text := strings.Repeat("abc\n", 100)
+	f := protoscan.NewFanout(protoscan.New(strings.NewReader(text), protoscan.WithSplit(protoscan.ScanLines)))
+	block := f.Subscribe(1, protoscan.SlowBlock)
+	drop := f.Subscribe(10, protoscan.SlowDrop)
+	disconnect := f.Subscribe(10, protoscan.SlowDisconnect)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var n int
+	go func() {
+		defer wg.Done()
+		for token := range block.Tokens() {
+			if string(token) != "abc" {
+				t.Errorf("unexpected token %q", token)
+			}
+			n++
+		}
+	}()
+	if err := f.Run(); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	if n != 100 {
+		t.Fatalf("expected 100 tokens of the blocking subscriber; got %d", n)
+	}
+
+	var dropped int
+	for range drop.Tokens() {
+		dropped++
+	}
+	if dropped != 10 || drop.Dropped() != 90 {
+		t.Fatalf("expected 10 tokens and 90 dropped; got %d and %d", dropped, drop.Dropped())
+	}
+	var disconnected int
+	for range disconnect.Tokens() {
+		disconnected++
+	}
+	if disconnected != 10 || !disconnect.Disconnected() {
+		t.Fatalf("expected 10 tokens before disconnection; got %d", disconnected)
+	}
+}
+
+func TestFanoutUnsubscribe(t *testing.T) {
+	r, w := io.Pipe()
+	f := protoscan.NewFanout(protoscan.New(r, protoscan.WithSplit(protoscan.ScanLines)))
+	stuck := f.Subscribe(1, protoscan.SlowBlock)
+	errc := make(chan error)
+	go func() { errc <- f.Run() }()
+	// The second token blocks the delivery to the subscriber not reading.
+	io.WriteString(w, "a\nb\n")
+	f.Unsubscribe(stuck)
+	w.Close()
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	for range stuck.Tokens() {
+	}
+	if stuck.Disconnected() {
+		t.Fatal("unsubscribed subscriber reported as disconnected")
+	}
+	late := f.Subscribe(1, protoscan.SlowBlock)
+	if _, ok := <-late.Tokens(); ok {
+		t.Fatal("expected the closed channel of the subscription after the Run")
+	}
+}
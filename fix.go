@@ -0,0 +1,151 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Limits of the FIX header fields.
+const (
+	fixMaxBeginString = 32 // Maximum length of the BeginString(8) field.
+	fixMaxBodyLength  = 16 // Maximum length of the BodyLength(9) field.
+)
+
+// ScanFIX is a split function for a Protoscan that returns each FIX message
+// as a token: the BeginString(8), the BodyLength(9), the body of that length
+// and the CheckSum(10). The data not starting with the BeginString is
+// reported as the ErrNeedResync, advancing to the next BeginString, and the
+// message of the wrong CheckSum as the ErrCorruptFrame.
+func ScanFIX(data []byte, atEOF bool) (int, int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, 0, nil, nil
+	}
+	if len(data) < 2 {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return 2 - len(data), 0, nil, nil
+	}
+	if data[0] != '8' || data[1] != '=' {
+		// Keep the bytes which may be the start of the next "\x018=".
+		advance := max(len(data)-2, 1)
+		if i := bytes.Index(data, []byte("\x018=")); i >= 0 {
+			advance = i + 1
+		}
+		return 0, advance, nil, fmt.Errorf("%w: FIX message does not start with BeginString", ErrNeedResync)
+	}
+	// Search the fields within their limits only, so that the result does
+	// not depend on the data buffered.
+	head := data[:min(len(data), fixMaxBeginString+3)]
+	i := bytes.Index(head, []byte("\x019="))
+	if i < 0 {
+		if len(head) == fixMaxBeginString+3 {
+			return 0, 1, nil, fmt.Errorf("%w: FIX BeginString not followed by BodyLength", ErrNeedResync)
+		}
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return 1, 0, nil, nil
+	}
+	head = data[i+3 : min(len(data), i+3+fixMaxBodyLength+1)]
+	j := bytes.IndexByte(head, '\x01')
+	if j < 0 {
+		if len(head) == fixMaxBodyLength+1 {
+			return 0, 1, nil, fmt.Errorf("%w: FIX BodyLength too long", ErrNeedResync)
+		}
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return 1, 0, nil, nil
+	}
+	bodyLen, err := strconv.Atoi(string(data[i+3 : i+3+j]))
+	if err != nil || bodyLen < 0 {
+		return 0, 1, nil, fmt.Errorf("%w: bad FIX BodyLength", ErrNeedResync)
+	}
+	body := i + 3 + j + 1
+	n := body + bodyLen + len("10=000\x01")
+	if len(data) < n {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return n - len(data), 0, nil, nil
+	}
+	trailer := data[n-7 : n]
+	if !bytes.HasPrefix(trailer, []byte("10=")) || trailer[6] != '\x01' {
+		return 0, 1, nil, fmt.Errorf("%w: FIX CheckSum not at BodyLength", ErrNeedResync)
+	}
+	var sum byte
+	for _, b := range data[:n-7] {
+		sum += b
+	}
+	want, err := strconv.Atoi(string(trailer[3:6]))
+	if err != nil || want > 255 || byte(want) != sum {
+		return 0, n, nil, fmt.Errorf("%w: bad FIX CheckSum %q", ErrCorruptFrame, trailer[3:6])
+	}
+	return 0, n, data[:n], nil
+}
+
+// ScanFIXIndexed is the split function for the Protoscan with the
+// WithSplitContext option which returns each FIX message as a token, as the
+// ScanFIX, and records each of the fields of the message in the Indexes as
+// the triplet of the tag number, the offset of the value in the token and
+// the length of the value, see the FIXField.
+func ScanFIXIndexed(ctx *SplitContext, data []byte, atEOF bool) (int, int, []byte, error) {
+	hint, advance, token, err := ScanFIX(data, atEOF)
+	if token == nil {
+		return hint, advance, token, err
+	}
+	for i := 0; i < len(token); {
+		tag := 0
+		j := i
+		for ; j < len(token) && '0' <= token[j] && token[j] <= '9'; j++ {
+			tag = tag*10 + int(token[j]-'0')
+		}
+		if j == i || j == len(token) || token[j] != '=' {
+			ctx.Indexes = ctx.Indexes[:0]
+			return 0, advance, nil, fmt.Errorf("%w: bad FIX field at offset %d", ErrCorruptFrame, i)
+		}
+		j++
+		k := j + bytes.IndexByte(token[j:], '\x01')
+		ctx.Indexes = append(ctx.Indexes, tag, j, k-j)
+		i = k + 1
+	}
+	return hint, advance, token, err
+}
+
+// FIXField returns the value of the first field of the tag in the FIX
+// message indexed by the ScanFIXIndexed, or nil if there is no such field.
+func FIXField(msg []byte, indexes []int, tag int) []byte {
+	for i := 0; i+2 < len(indexes); i += 3 {
+		if indexes[i] == tag {
+			return msg[indexes[i+1] : indexes[i+1]+indexes[i+2]]
+		}
+	}
+	return nil
+}
+
+// FIXValue returns the value of the first field of the tag in the FIX
+// message, or nil if there is no such field.
+func FIXValue(msg []byte, tag int) []byte {
+	var prefix [24]byte
+	p := append(strconv.AppendInt(append(prefix[:0], '\x01'), int64(tag), 10), '=')
+	i := 0
+	if !bytes.HasPrefix(msg, p[1:]) {
+		i = bytes.Index(msg, p)
+		if i < 0 {
+			return nil
+		}
+		i++
+	}
+	v := msg[i+len(p)-1:]
+	if j := bytes.IndexByte(v, '\x01'); j >= 0 {
+		v = v[:j]
+	}
+	return v
+}
@@ -0,0 +1,89 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+// fixMsg returns the FIX message of the fields separated by the '|'.
+func fixMsg(fields string) string {
+	body := strings.ReplaceAll(fields, "|", "\x01") + "\x01"
+	msg := fmt.Sprintf("8=FIX.4.4\x019=%d\x01%s", len(body), body)
+	var sum byte
+	for i := 0; i < len(msg); i++ {
+		sum += msg[i]
+	}
+	return fmt.Sprintf("%s10=%03d\x01", msg, sum)
+}
+
+func TestScanFIX(t *testing.T) {
+	logon := fixMsg("35=A|34=1|49=SELL|56=BUY|98=0|108=30")
+	order := fixMsg("35=D|34=2|49=SELL|56=BUY|11=ORD1|58=a=b")
+	badSum := strings.Replace(order, "10=", "10=9", 1)[:len(order)-1] + "\x01"
+	protoscantest.TestSplitFunc(t, protoscan.ScanFIX, []protoscantest.Case{
+		{Name: "empty"},
+		{Name: "messages", Input: logon + order, Tokens: []string{logon, order}},
+		{Name: "truncated", Input: logon + order[:20], Tokens: []string{logon}, Err: io.ErrUnexpectedEOF},
+		{Name: "garbage", Input: "xx\x01" + logon, Err: protoscan.ErrNeedResync},
+		{Name: "checksum", Input: logon + badSum, Tokens: []string{logon}, Err: protoscan.ErrCorruptFrame},
+		{Name: "body length", Input: "8=FIX.4.4\x019=x\x01", Err: protoscan.ErrNeedResync},
+	})
+	protoscantest.TestSplitFunc(t, protoscan.ScanFIX, []protoscantest.Case{
+		{Name: "recovery", Input: "xx\x01" + logon + badSum + order, Tokens: []string{logon, order}},
+	}, protoscan.WithRecovery(nil))
+}
+
+func TestFIXValue(t *testing.T) {
+	msg := []byte(fixMsg("35=D|34=2|11=ORD1|58=a=b|111=x"))
+	for tag, want := range map[int]string{8: "FIX.4.4", 35: "D", 11: "ORD1", 58: "a=b", 111: "x", 1: "", 12: ""} {
+		if got := string(protoscan.FIXValue(msg, tag)); got != want {
+			t.Errorf("tag %d: expected %q; got %q", tag, want, got)
+		}
+	}
+}
+
+func TestScanFIXIndexed(t *testing.T) {
+	order := fixMsg("35=D|34=2|49=SELL|56=BUY|11=ORD1|58=")
+	bad := fixMsg("35=D|x|11=ORD2")
+	s := protoscan.New(
+		strings.NewReader(order+bad+order),
+		protoscan.WithSplitContext(protoscan.ScanFIXIndexed),
+		protoscan.WithRecovery(nil),
+	)
+	var n int
+	for ; s.Scan(); n++ {
+		msg, indexes := s.Token(), s.Indexes()
+		if len(indexes) != 3*9 {
+			t.Fatalf("expected 9 fields; got indexes %v", indexes)
+		}
+		for tag, want := range map[int]string{8: "FIX.4.4", 9: "37", 35: "D", 49: "SELL", 56: "BUY", 11: "ORD1", 58: "", 10: order[len(order)-4 : len(order)-1]} {
+			if got := string(protoscan.FIXField(msg, indexes, tag)); got != want {
+				t.Errorf("tag %d: expected %q; got %q", tag, want, got)
+			}
+		}
+		if protoscan.FIXField(msg, indexes, 55) != nil {
+			t.Errorf("unexpected field 55")
+		}
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	if n != 2 || s.Resyncs() != 1 {
+		t.Fatalf("expected 2 messages and 1 resync; got %d and %d", n, s.Resyncs())
+	}
+}
+
+func FuzzScanFIX(f *testing.F) {
+	f.Add([]byte(fixMsg("35=0|34=1")))
+	f.Add([]byte("xx\x018=FIX\x019=1\x01x10=000\x01"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanFIX))
+}
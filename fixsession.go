@@ -0,0 +1,135 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"errors"
+	"strconv"
+)
+
+// ErrFIXSeqNum is returned by the Track of the FIXSession if the message
+// has no valid MsgSeqNum(34).
+var ErrFIXSeqNum = errors.New("protoscan: FIX message without valid MsgSeqNum")
+
+// FIXEventKind is the kind of the event of the FIX session.
+type FIXEventKind int
+
+// Kinds of the events of the FIX session.
+const (
+	FIXLogon         FIXEventKind = iota // Logon(A) message.
+	FIXLogout                            // Logout(5) message.
+	FIXHeartbeat                         // Heartbeat(0) message.
+	FIXTestRequest                       // TestRequest(1) message.
+	FIXResendRequest                     // ResendRequest(2) message.
+	FIXSequenceReset                     // SequenceReset(4) message.
+	FIXSequenceGap                       // MsgSeqNum higher than expected.
+	FIXSequenceLow                       // MsgSeqNum lower than expected, not a possible duplicate.
+)
+
+func (k FIXEventKind) String() string {
+	switch k {
+	case FIXLogon:
+		return "logon"
+	case FIXLogout:
+		return "logout"
+	case FIXHeartbeat:
+		return "heartbeat"
+	case FIXTestRequest:
+		return "test request"
+	case FIXResendRequest:
+		return "resend request"
+	case FIXSequenceReset:
+		return "sequence reset"
+	case FIXSequenceGap:
+		return "sequence gap"
+	case FIXSequenceLow:
+		return "sequence low"
+	}
+	return "unknown"
+}
+
+// FIXEvent is the event of the FIX session.
+type FIXEvent struct {
+	Kind     FIXEventKind
+	SeqNum   int    // MsgSeqNum(34) of the message.
+	Expected int    // MsgSeqNum expected before the message, 0 if unknown.
+	Msg      []byte // The message, valid until the next call to Scan.
+}
+
+// FIXSession tracks the session layer of the FIX messages sent by one side
+// of the connection, split by the ScanFIX: it recognizes the session-level
+// messages and the gaps of the MsgSeqNum without being a FIX engine.
+type FIXSession struct {
+	handler func(FIXEvent)
+	next    int // Expected MsgSeqNum of the next message, 0 if unknown.
+}
+
+// NewFIXSession returns the FIXSession calling the handler on the events.
+// The MsgSeqNum expected first is taken from the first message.
+func NewFIXSession(handler func(FIXEvent)) *FIXSession {
+	return &FIXSession{handler: handler}
+}
+
+// NextSeqNum returns the MsgSeqNum expected of the next message, or 0 if
+// unknown.
+func (f *FIXSession) NextSeqNum() int {
+	return f.next
+}
+
+// Track tracks the FIX message, calling the handler on the session-level
+// message and on the MsgSeqNum not expected. The gap is reported before
+// the message which revealed it.
+func (f *FIXSession) Track(msg []byte) error {
+	seq, err := strconv.Atoi(string(FIXValue(msg, 34)))
+	if err != nil || seq <= 0 {
+		return ErrFIXSeqNum
+	}
+	msgType := string(FIXValue(msg, 35))
+	if msgType == "A" && string(FIXValue(msg, 141)) == "Y" {
+		// ResetSeqNumFlag.
+		f.next = seq
+	}
+	expected := f.next
+	switch {
+	case f.next == 0:
+		f.next = seq + 1
+	case seq > f.next:
+		f.emit(FIXSequenceGap, seq, expected, msg)
+		f.next = seq + 1
+	case seq < f.next:
+		if msgType != "4" && string(FIXValue(msg, 43)) != "Y" {
+			// Not a PossDupFlag.
+			f.emit(FIXSequenceLow, seq, expected, msg)
+		}
+	default:
+		f.next++
+	}
+	switch msgType {
+	case "A":
+		f.emit(FIXLogon, seq, expected, msg)
+	case "5":
+		f.emit(FIXLogout, seq, expected, msg)
+	case "0":
+		f.emit(FIXHeartbeat, seq, expected, msg)
+	case "1":
+		f.emit(FIXTestRequest, seq, expected, msg)
+	case "2":
+		f.emit(FIXResendRequest, seq, expected, msg)
+	case "4":
+		if n, err := strconv.Atoi(string(FIXValue(msg, 36))); err == nil && n > 0 {
+			// NewSeqNo.
+			f.next = n
+		}
+		f.emit(FIXSequenceReset, seq, expected, msg)
+	}
+	return nil
+}
+
+// emit calls the handler.
+func (f *FIXSession) emit(kind FIXEventKind, seq, expected int, msg []byte) {
+	if f.handler != nil {
+		f.handler(FIXEvent{Kind: kind, SeqNum: seq, Expected: expected, Msg: msg})
+	}
+}
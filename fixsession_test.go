@@ -0,0 +1,79 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+)
+
+func TestFIXSession(t *testing.T) {
+	stream := fixMsg("35=A|34=1|98=0|108=30") +
+		fixMsg("35=D|34=2|11=ORD1") +
+		fixMsg("35=0|34=3") +
+		fixMsg("35=D|34=6|11=ORD2") +
+		fixMsg("35=2|34=7|7=4|16=5") +
+		fixMsg("35=D|34=4|43=Y|11=ORD0") +
+		fixMsg("35=4|34=5|123=Y|36=9") +
+		fixMsg("35=1|34=9|112=T1") +
+		fixMsg("35=D|34=3|11=ORD3") +
+		fixMsg("35=5|34=10")
+	var events []string
+	f := protoscan.NewFIXSession(func(e protoscan.FIXEvent) {
+		events = append(events, fmt.Sprintf("%v %d/%d", e.Kind, e.SeqNum, e.Expected))
+	})
+	s := protoscan.New(strings.NewReader(stream), protoscan.WithSplit(protoscan.ScanFIX))
+	for s.Scan() {
+		if err := f.Track(s.Token()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	want := []string{
+		"logon 1/0",
+		"heartbeat 3/3",
+		"sequence gap 6/4",
+		"resend request 7/7",
+		"sequence reset 5/8",
+		"test request 9/9",
+		"sequence low 3/10",
+		"logout 10/10",
+	}
+	if got := strings.Join(events, "|"); got != strings.Join(want, "|") {
+		t.Fatalf("expected events %q; got %q", want, events)
+	}
+	if f.NextSeqNum() != 11 {
+		t.Fatalf("expected next MsgSeqNum 11; got %d", f.NextSeqNum())
+	}
+}
+
+func TestFIXSessionReset(t *testing.T) {
+	var events []string
+	f := protoscan.NewFIXSession(func(e protoscan.FIXEvent) {
+		events = append(events, fmt.Sprintf("%v %d/%d", e.Kind, e.SeqNum, e.Expected))
+	})
+	for _, msg := range []string{
+		fixMsg("35=A|34=1"),
+		fixMsg("35=0|34=2"),
+		fixMsg("35=A|34=1|141=Y"),
+		fixMsg("35=0|34=2"),
+	} {
+		if err := f.Track([]byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := strings.Join(events, "|"); got != "logon 1/0|heartbeat 2/2|logon 1/1|heartbeat 2/2" {
+		t.Fatalf("unexpected events %q", got)
+	}
+	if err := f.Track([]byte(fixMsg("35=0"))); !errors.Is(err, protoscan.ErrFIXSeqNum) {
+		t.Fatalf("expected ErrFIXSeqNum; got %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Follower reads the named file as it grows, like the tail -F command.
+// At the end of the file it polls the file for the new data, reopens the
+// file if it was rotated, that is replaced with another file of the same
+// name, and reads the file from the beginning if it was truncated.
+//
+// The data written to the rotated file after it was renamed and before the
+// Follower noticed the new file may be lost. The last incomplete token of
+// the rotated file is continued by the data of the new file.
+type Follower struct {
+	name   string
+	poll   time.Duration
+	file   *os.File
+	info   os.FileInfo
+	offset int64 // Offset of the next read from the file.
+	done   chan struct{}
+	once   sync.Once
+}
+
+// Follow opens the named file for following with the poll interval.
+func Follow(name string, poll time.Duration) (*Follower, error) {
+	f := &Follower{name: name, poll: poll, done: make(chan struct{})}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Read reads the file, waiting for the new data at the end of the file.
+// It returns io.EOF only after the Follower is closed.
+func (f *Follower) Read(p []byte) (int, error) {
+	for {
+		n, err := f.file.Read(p)
+		f.offset += int64(n)
+		if err != nil && err != io.EOF && f.closed() {
+			err = io.EOF
+		}
+		if n > 0 || err != nil && err != io.EOF {
+			return n, err
+		}
+		if ok, err := f.reopen(); err != nil {
+			return 0, err
+		} else if ok {
+			continue
+		}
+		select {
+		case <-f.done:
+			return 0, io.EOF
+		case <-time.After(f.poll):
+		}
+	}
+}
+
+// Close stops the following and closes the file.
+func (f *Follower) Close() error {
+	f.once.Do(func() { close(f.done) })
+	return f.file.Close()
+}
+
+// closed reports whether the Follower is closed.
+func (f *Follower) closed() bool {
+	select {
+	case <-f.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// open opens the named file.
+func (f *Follower) open() error {
+	file, err := os.Open(f.name)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.info, f.offset = file, info, 0
+	return nil
+}
+
+// reopen reopens the rotated file or rewinds the truncated file. It reports
+// whether the file is reopened or rewound.
+func (f *Follower) reopen() (bool, error) {
+	info, err := os.Stat(f.name)
+	if os.IsNotExist(err) {
+		// The file is being rotated.
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !os.SameFile(info, f.info) {
+		old := f.file
+		if err := f.open(); err != nil {
+			if os.IsNotExist(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		old.Close()
+		return true, nil
+	}
+	if info.Size() < f.offset {
+		if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+			return false, err
+		}
+		f.offset = 0
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/protoscan/protoscan"
+)
+
+func TestFollow(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "log")
+	write := func(flag int, text string) {
+		t.Helper()
+		f, err := os.OpenFile(name, flag|os.O_WRONLY|os.O_CREATE, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.WriteString(text); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(0, "a\n")
+	f, err := protoscan.Follow(name, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := make(chan string)
+	s := protoscan.New(f, protoscan.WithSplit(protoscan.ScanLines))
+	go func() {
+		for s.Scan() {
+			lines <- string(s.Token())
+		}
+		close(lines)
+	}()
+	expect := func(want string) {
+		t.Helper()
+		select {
+		case got := <-lines:
+			if got != want {
+				t.Fatalf("expected %q; got %q", want, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no %q", want)
+		}
+	}
+	expect("a")
+	write(os.O_APPEND, "b\n")
+	expect("b")
+
+	// Rotate.
+	if err := os.Rename(name, name+".1"); err != nil {
+		t.Fatal(err)
+	}
+	write(0, "c\n")
+	expect("c")
+
+	// Truncate and let the Follower notice before writing.
+	write(os.O_TRUNC, "")
+	time.Sleep(50 * time.Millisecond)
+	write(os.O_APPEND, "d\n")
+	expect("d")
+
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-lines; ok {
+		t.Fatal("expected the end of the scan")
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+}
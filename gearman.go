@@ -0,0 +1,77 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Magics of the Gearman binary packets.
+var (
+	gearmanReq = []byte("\x00REQ")
+	gearmanRes = []byte("\x00RES")
+)
+
+// ScanGearman is a split function for a Protoscan that returns each packet
+// of the Gearman protocol as a token: the 12-byte header of the "\0REQ" or
+// the "\0RES" magic, the 4-byte type and the 4-byte size, followed by the
+// data of the size. The administrative text commands, which start by a
+// non-zero byte, are returned as the lines, as by the ScanLines. The packet
+// of the unknown magic is reported as the ErrNeedResync, advancing to the
+// next zero byte.
+func ScanGearman(data []byte, atEOF bool) (int, int, []byte, error) {
+	if len(data) == 0 {
+		if atEOF {
+			return 0, 0, nil, nil
+		}
+		return 1, 0, nil, nil
+	}
+	if data[0] != 0 {
+		return ScanLines(data, atEOF)
+	}
+	m := data[:min(len(data), 4)]
+	if !bytes.HasPrefix(gearmanReq, m) && !bytes.HasPrefix(gearmanRes, m) {
+		// Resync to the next zero byte, the magic may start there.
+		i := bytes.IndexByte(data[1:], 0)
+		if i < 0 && !atEOF {
+			return 1, 0, nil, nil
+		}
+		advance := len(data)
+		if i >= 0 {
+			advance = 1 + i
+		}
+		return 0, advance, nil, fmt.Errorf("%w: bad Gearman magic %q", ErrNeedResync, m)
+	}
+	if len(data) < 12 {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return 12 - len(data), 0, nil, nil
+	}
+	size := binary.BigEndian.Uint32(data[8:])
+	n := 12 + int(size)
+	if len(data) < n {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return n - len(data), 0, nil, nil
+	}
+	return 0, n, data[:n], nil
+}
+
+// IsGearmanRequest reports whether the token returned by the ScanGearman
+// is the binary packet of the "\0REQ" magic.
+func IsGearmanRequest(token []byte) bool {
+	return bytes.HasPrefix(token, gearmanReq)
+}
+
+// IsGearmanResponse reports whether the token returned by the ScanGearman
+// is the binary packet of the "\0RES" magic.
+func IsGearmanResponse(token []byte) bool {
+	return bytes.HasPrefix(token, gearmanRes)
+}
@@ -0,0 +1,42 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanGearman(t *testing.T) {
+	// SUBMIT_JOB request and JOB_CREATED response.
+	req := "\x00REQ\x00\x00\x00\x07\x00\x00\x00\x09rev\x00\x00abcd"
+	res := "\x00RES\x00\x00\x00\x08\x00\x00\x00\x03H:1"
+	noop := "\x00RES\x00\x00\x00\x06\x00\x00\x00\x00"
+	protoscantest.TestSplitFunc(t, protoscan.ScanGearman, []protoscantest.Case{
+		{Name: "empty"},
+		{Name: "packets", Input: req + res + noop, Tokens: []string{req, res, noop}},
+		{Name: "admin", Input: "status\r\n" + noop + "workers\n", Tokens: []string{"status", noop, "workers"}},
+		{Name: "truncated", Input: req[:14], Err: io.ErrUnexpectedEOF},
+		{Name: "truncated header", Input: "\x00RE", Err: io.ErrUnexpectedEOF},
+		{Name: "bad magic", Input: "\x00REX\x00\x00\x00\x06\x00\x00\x00\x00", Err: protoscan.ErrNeedResync},
+	})
+	protoscantest.TestSplitFunc(t, protoscan.ScanGearman, []protoscantest.Case{
+		{Name: "recovery", Input: "\x00\x00REX" + res, Tokens: []string{res}},
+	}, protoscan.WithRecovery(nil))
+	if !protoscan.IsGearmanRequest([]byte(req)) || protoscan.IsGearmanRequest([]byte(res)) {
+		t.Error("IsGearmanRequest mismatch")
+	}
+	if !protoscan.IsGearmanResponse([]byte(res)) || protoscan.IsGearmanResponse([]byte(req)) {
+		t.Error("IsGearmanResponse mismatch")
+	}
+}
+
+func FuzzScanGearman(f *testing.F) {
+	f.Add([]byte("\x00REQ\x00\x00\x00\x07\x00\x00\x00\x01xstatus\n"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanGearman))
+}
@@ -1,3 +1,3 @@
 module github.com/protoscan/protoscan
 
-go 1.17
+go 1.21
@@ -0,0 +1,219 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Grapheme_Cluster_Break property values of UAX #29.
+const (
+	gbOther = iota
+	gbCR
+	gbLF
+	gbControl
+	gbExtend
+	gbZWJ
+	gbRegionalIndicator
+	gbPrepend
+	gbSpacingMark
+	gbL
+	gbV
+	gbT
+	gbLV
+	gbLVT
+)
+
+// gbExtendOther holds the runes of the Extend property beyond the
+// nonspacing and the enclosing marks: the Other_Grapheme_Extend spacing
+// marks, the ZWNJ, the halfwidth sound marks, the emoji modifiers and the
+// tags.
+var gbExtendOther = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x09be, 0x09be, 1}, {0x09d7, 0x09d7, 1}, {0x0b3e, 0x0b3e, 1},
+		{0x0b57, 0x0b57, 1}, {0x0bbe, 0x0bbe, 1}, {0x0bd7, 0x0bd7, 1},
+		{0x0cc2, 0x0cc2, 1}, {0x0cd5, 0x0cd6, 1}, {0x0d3e, 0x0d3e, 1},
+		{0x0d57, 0x0d57, 1}, {0x0dcf, 0x0dcf, 1}, {0x0ddf, 0x0ddf, 1},
+		{0x200c, 0x200c, 1}, {0x302e, 0x302f, 1}, {0xff9e, 0xff9f, 1},
+	},
+	R32: []unicode.Range32{
+		{0x1d165, 0x1d165, 1}, {0x1d16e, 0x1d172, 1}, {0x1f3fb, 0x1f3ff, 1},
+		{0xe0020, 0xe007f, 1},
+	},
+}
+
+// gbPrepends holds the runes of the Prepend property.
+var gbPrepends = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x0600, 0x0605, 1}, {0x06dd, 0x06dd, 1}, {0x070f, 0x070f, 1},
+		{0x0890, 0x0891, 1}, {0x08e2, 0x08e2, 1}, {0x0d4e, 0x0d4e, 1},
+	},
+	R32: []unicode.Range32{
+		{0x110bd, 0x110bd, 1}, {0x110cd, 0x110cd, 1}, {0x111c2, 0x111c3, 1},
+		{0x1193f, 0x1193f, 1}, {0x11941, 0x11941, 1}, {0x11a3a, 0x11a3a, 1},
+		{0x11a84, 0x11a89, 1}, {0x11d46, 0x11d46, 1}, {0x11f02, 0x11f02, 1},
+	},
+}
+
+// gbPictographic holds the runes of the Extended_Pictographic property of
+// the emoji data.
+var gbPictographic = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x00a9, 0x00a9, 1}, {0x00ae, 0x00ae, 1}, {0x203c, 0x203c, 1},
+		{0x2049, 0x2049, 1}, {0x2122, 0x2122, 1}, {0x2139, 0x2139, 1},
+		{0x2194, 0x2199, 1}, {0x21a9, 0x21aa, 1}, {0x231a, 0x231b, 1},
+		{0x2328, 0x2328, 1}, {0x2388, 0x2388, 1}, {0x23cf, 0x23cf, 1},
+		{0x23e9, 0x23f3, 1}, {0x23f8, 0x23fa, 1}, {0x24c2, 0x24c2, 1},
+		{0x25aa, 0x25ab, 1}, {0x25b6, 0x25b6, 1}, {0x25c0, 0x25c0, 1},
+		{0x25fb, 0x25fe, 1}, {0x2600, 0x2605, 1}, {0x2607, 0x2612, 1},
+		{0x2614, 0x2685, 1}, {0x2690, 0x2705, 1}, {0x2708, 0x2712, 1},
+		{0x2714, 0x2714, 1}, {0x2716, 0x2716, 1}, {0x271d, 0x271d, 1},
+		{0x2721, 0x2721, 1}, {0x2728, 0x2728, 1}, {0x2733, 0x2734, 1},
+		{0x2744, 0x2744, 1}, {0x2747, 0x2747, 1}, {0x274c, 0x274c, 1},
+		{0x274e, 0x274e, 1}, {0x2753, 0x2755, 1}, {0x2757, 0x2757, 1},
+		{0x2763, 0x2767, 1}, {0x2795, 0x2797, 1}, {0x27a1, 0x27a1, 1},
+		{0x27b0, 0x27b0, 1}, {0x27bf, 0x27bf, 1}, {0x2934, 0x2935, 1},
+		{0x2b05, 0x2b07, 1}, {0x2b1b, 0x2b1c, 1}, {0x2b50, 0x2b50, 1},
+		{0x2b55, 0x2b55, 1}, {0x3030, 0x3030, 1}, {0x303d, 0x303d, 1},
+		{0x3297, 0x3297, 1}, {0x3299, 0x3299, 1},
+	},
+	R32: []unicode.Range32{
+		{0x1f000, 0x1f0ff, 1}, {0x1f10d, 0x1f10f, 1}, {0x1f12f, 0x1f12f, 1},
+		{0x1f16c, 0x1f171, 1}, {0x1f17e, 0x1f17f, 1}, {0x1f18e, 0x1f18e, 1},
+		{0x1f191, 0x1f19a, 1}, {0x1f1ad, 0x1f1e5, 1}, {0x1f201, 0x1f20f, 1},
+		{0x1f21a, 0x1f21a, 1}, {0x1f22f, 0x1f22f, 1}, {0x1f232, 0x1f23a, 1},
+		{0x1f23c, 0x1f23f, 1}, {0x1f249, 0x1f3fa, 1}, {0x1f400, 0x1f53d, 1},
+		{0x1f546, 0x1f64f, 1}, {0x1f680, 0x1f6ff, 1}, {0x1f774, 0x1f77f, 1},
+		{0x1f7d5, 0x1f7ff, 1}, {0x1f80c, 0x1f80f, 1}, {0x1f848, 0x1f84f, 1},
+		{0x1f85a, 0x1f85f, 1}, {0x1f888, 0x1f88f, 1}, {0x1f8ae, 0x1f8ff, 1},
+		{0x1f90c, 0x1f93a, 1}, {0x1f93c, 0x1f945, 1}, {0x1f947, 0x1faff, 1},
+		{0x1fc00, 0x1fffd, 1},
+	},
+}
+
+// graphemeBreak returns the Grapheme_Cluster_Break property of the rune,
+// and whether it is Extended_Pictographic.
+func graphemeBreak(r rune) (int, bool) {
+	switch {
+	case r < 0x7f && r >= 0x20:
+		return gbOther, false
+	case r == '\r':
+		return gbCR, false
+	case r == '\n':
+		return gbLF, false
+	case r == 0x200d:
+		return gbZWJ, false
+	case 0x1f1e6 <= r && r <= 0x1f1ff:
+		return gbRegionalIndicator, false
+	case 0x1100 <= r && r <= 0x115f, 0xa960 <= r && r <= 0xa97c:
+		return gbL, false
+	case 0x1160 <= r && r <= 0x11a7, 0xd7b0 <= r && r <= 0xd7c6:
+		return gbV, false
+	case 0x11a8 <= r && r <= 0x11ff, 0xd7cb <= r && r <= 0xd7fb:
+		return gbT, false
+	case 0xac00 <= r && r <= 0xd7a3:
+		if (r-0xac00)%28 == 0 {
+			return gbLV, false
+		}
+		return gbLVT, false
+	case unicode.Is(gbPrepends, r):
+		return gbPrepend, false
+	case unicode.In(r, unicode.Mn, unicode.Me, gbExtendOther):
+		return gbExtend, false
+	case unicode.Is(unicode.Mc, r), r == 0x0e33, r == 0x0eb3:
+		return gbSpacingMark, false
+	case unicode.In(r, unicode.Cc, unicode.Cf, unicode.Zl, unicode.Zp):
+		return gbControl, false
+	}
+	return gbOther, unicode.Is(gbPictographic, r)
+}
+
+// graphemeState holds the context of the rules GB11, GB12 and GB13 over
+// the cluster.
+type graphemeState struct {
+	prev    int  // Property of the last rune.
+	emoji   int  // 1 after Extended_Pictographic Extend*, 2 after its ZWJ.
+	regions bool // Odd count of the Regional_Indicator ending the cluster.
+}
+
+// add appends the rune of the property to the cluster.
+func (s *graphemeState) add(prop int, pict bool) {
+	switch {
+	case pict:
+		s.emoji = 1
+	case prop == gbExtend && s.emoji == 1:
+	case prop == gbZWJ && s.emoji == 1:
+		s.emoji = 2
+	default:
+		s.emoji = 0
+	}
+	s.regions = prop == gbRegionalIndicator && !s.regions
+	s.prev = prop
+}
+
+// breaks reports whether the cluster breaks before the rune of the
+// property.
+func (s *graphemeState) breaks(next int, pict bool) bool {
+	prev := s.prev
+	switch {
+	case prev == gbCR && next == gbLF:
+		return false
+	case prev == gbControl || prev == gbCR || prev == gbLF,
+		next == gbControl || next == gbCR || next == gbLF:
+		return true
+	case prev == gbL && (next == gbL || next == gbV || next == gbLV || next == gbLVT),
+		(prev == gbLV || prev == gbV) && (next == gbV || next == gbT),
+		(prev == gbLVT || prev == gbT) && next == gbT:
+		return false
+	case next == gbExtend || next == gbZWJ || next == gbSpacingMark || prev == gbPrepend:
+		return false
+	case prev == gbZWJ && s.emoji == 2 && pict:
+		return false
+	case prev == gbRegionalIndicator && next == gbRegionalIndicator:
+		return !s.regions
+	}
+	return true
+}
+
+// ScanGraphemes is a split function for a Protoscan that returns each
+// extended grapheme cluster of the UTF-8-encoded text as a token, as
+// defined by the Unicode Standard Annex #29, so that the base characters
+// with their combining marks, the Hangul syllables, the flags and the
+// emoji sequences joined by the ZWJ are single tokens. The cluster is
+// returned once the rune following it is read, or at EOF. The erroneous
+// UTF-8 encodings are clusters of their own, translated to U+FFFD as by
+// the ScanRunes.
+func ScanGraphemes(data []byte, atEOF bool) (int, int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, 0, nil, nil
+	}
+	if !atEOF && !utf8.FullRune(data) {
+		return 1, 0, nil, nil
+	}
+	r, width := utf8.DecodeRune(data)
+	if r == utf8.RuneError && width == 1 {
+		return 0, 1, []byte(errorRune), nil
+	}
+	var s graphemeState
+	s.add(graphemeBreak(r))
+	for i := width; ; i += width {
+		if i == len(data) {
+			if atEOF {
+				return 0, i, data[:i], nil
+			}
+			return 1, 0, nil, nil
+		}
+		if !atEOF && !utf8.FullRune(data[i:]) {
+			return 1, 0, nil, nil
+		}
+		r, width = utf8.DecodeRune(data[i:])
+		prop, pict := graphemeBreak(r)
+		if r == utf8.RuneError && width == 1 || s.breaks(prop, pict) {
+			return 0, i, data[:i], nil
+		}
+		s.add(prop, pict)
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanGraphemes(t *testing.T) {
+	clusters := []string{
+		"e\u0301\u0327",        // Combining marks.
+		"\r\n",                 // CR LF.
+		"\n",                   // LF.
+		"\u1100\u1161\u11a8",   // Hangul jamo L V T.
+		"\uac01\u11a8",         // Hangul LVT T.
+		"\U0001f1eb\U0001f1f7", // Flag.
+		"\U0001f1e9\U0001f1ea", // Flag.
+		"\U0001f468\u200d\U0001f469\u200d\U0001f467", // Family.
+		"\U0001f44d\U0001f3fd",                       // Emoji modifier.
+		"\u0915\u093f",                               // Spacing mark.
+		"\u0600\u0661",                               // Prepend.
+		"a\u200d",                                    // ZWJ without the emoji.
+		"\U0001f600",
+		"\x00",
+		"\U0001f1fa", // Lone regional indicator.
+	}
+	protoscantest.TestSplitFunc(t, protoscan.ScanGraphemes, []protoscantest.Case{
+		{Name: "empty", Input: "", Tokens: nil},
+		{Name: "ascii", Input: "ab c", Tokens: []string{"a", "b", " ", "c"}},
+		{Name: "clusters", Input: strings.Join(clusters, ""), Tokens: clusters},
+		{Name: "invalid", Input: "a\xffb\xe2\x82", Tokens: []string{"a", "\ufffd", "b", "\ufffd", "\ufffd"}},
+		{Name: "emoji after ZWJ", Input: "a\u200d\U0001f600", Tokens: []string{"a\u200d", "\U0001f600"}},
+		{Name: "regional indicators", Input: "\U0001f1eb\U0001f1f7\U0001f1fa", Tokens: []string{"\U0001f1eb\U0001f1f7", "\U0001f1fa"}},
+	})
+}
+
+func FuzzScanGraphemes(f *testing.F) {
+	f.Add([]byte("e\u0301\r\n\U0001f468\u200d\U0001f469\U0001f1eb\U0001f1f7\uac00"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanGraphemes))
+}
@@ -0,0 +1,51 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// grpcPrefix is the length of the gRPC message prefix: the compressed flag
+// and the 4-byte big-endian length.
+const grpcPrefix = 5
+
+// ScanGRPC is a split function for the Protoscan with the
+// WithSplitContext option which returns the payload of each gRPC
+// length-prefixed message as a token, as carried by the DATA frames of
+// the HTTP/2 stream. The Indexes of the token are its compressed flag, 1
+// if the payload is compressed by the message encoding of the stream,
+// otherwise 0. The other flags stop the scan with the
+// ErrProtocolViolation.
+func ScanGRPC(ctx *SplitContext, data []byte, atEOF bool) (int, int, []byte, error) {
+	if len(data) == 0 && atEOF {
+		return 0, 0, nil, nil
+	}
+	if len(data) < grpcPrefix {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return grpcPrefix - len(data), 0, nil, nil
+	}
+	if data[0] > 1 {
+		return 0, 0, nil, fmt.Errorf("%w: bad gRPC compressed flag %d", ErrProtocolViolation, data[0])
+	}
+	length := binary.BigEndian.Uint32(data[1:])
+	if uint64(length) > math.MaxInt-grpcPrefix {
+		return 0, 0, nil, fmt.Errorf("%w: gRPC message of %d bytes", ErrTooLong, length)
+	}
+	n := grpcPrefix + int(length)
+	if len(data) < n {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return n - len(data), 0, nil, nil
+	}
+	ctx.Indexes = append(ctx.Indexes, int(data[0]))
+	return 0, n, data[grpcPrefix:n], nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanGRPC(t *testing.T) {
+	stream := "\x00\x00\x00\x00\x03abc" + "\x01\x00\x00\x00\x02gz" + "\x00\x00\x00\x00\x00"
+	want := []string{`0 "abc"`, `1 "gz"`, `0 ""`}
+	for _, f := range protoscantest.Fragmentations() {
+		s := protoscan.New(f.Reader([]byte(stream)), protoscan.WithSplitContext(protoscan.ScanGRPC))
+		var got []string
+		for s.Scan() {
+			got = append(got, fmt.Sprintf("%d %q", s.Indexes()[0], s.Token()))
+		}
+		if s.Err() != nil {
+			t.Fatalf("%s: %v", f.Name, s.Err())
+		}
+		if strings.Join(got, "|") != strings.Join(want, "|") {
+			t.Fatalf("%s: expected messages %q; got %q", f.Name, want, got)
+		}
+	}
+}
+
+func TestScanGRPCErrors(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		stream string
+		err    error
+	}{
+		{"short prefix", "\x00\x00\x00", io.ErrUnexpectedEOF},
+		{"short payload", "\x00\x00\x00\x00\x03ab", io.ErrUnexpectedEOF},
+		{"flag", "\x02\x00\x00\x00\x00", protoscan.ErrProtocolViolation},
+	} {
+		s := protoscan.New(strings.NewReader(test.stream), protoscan.WithSplitContext(protoscan.ScanGRPC))
+		for s.Scan() {
+		}
+		if !errors.Is(s.Err(), test.err) {
+			t.Errorf("%s: expected error %v; got %v", test.name, test.err, s.Err())
+		}
+	}
+}
+
+func FuzzScanGRPC(f *testing.F) {
+	protoscantest.AddCorpus(f, protoscantest.GRPCCorpus())
+	f.Fuzz(protoscantest.FuzzContext(protoscan.ScanGRPC))
+}
@@ -0,0 +1,95 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// maxHeader is the maximum length of the header block of the text
+// protocols such as the RTSP, the SIP or the HTTP.
+const maxHeader = 64 << 10
+
+// headerEnd returns the length of the header block at the start of the
+// data, the lines terminated by the CRLF or the LF up to and including
+// the empty line, or -1 if the header block is incomplete. It searches
+// the maxHeader bytes at most, so that the result does not depend on the
+// data buffered, reporting the longer header block as ErrProtocolViolation.
+func headerEnd(data []byte) (int, error) {
+	head := data[:min(len(data), maxHeader)]
+	for i := 0; i < len(head); {
+		j := bytes.IndexByte(head[i:], '\n')
+		if j < 0 {
+			break
+		}
+		if j == 0 || j == 1 && head[i] == '\r' {
+			return i + j + 1, nil
+		}
+		i += j + 1
+	}
+	if len(head) == maxHeader {
+		return -1, fmt.Errorf("%w: header longer than %d bytes", ErrProtocolViolation, maxHeader)
+	}
+	return -1, nil
+}
+
+// headerValue returns the value of the first field of one of the names in
+// the header block, the names compared case-insensitively, and whether the
+// field is present. The first line of the block, the start line, is
+// skipped.
+func headerValue(header []byte, names ...string) ([]byte, bool) {
+	if i := bytes.IndexByte(header, '\n'); i >= 0 {
+		return fieldValue(header[i+1:], names...)
+	}
+	return nil, false
+}
+
+// fieldValue is like the headerValue of the header block without the start
+// line.
+func fieldValue(lines []byte, names ...string) ([]byte, bool) {
+	for len(lines) > 0 {
+		line := lines
+		if i := bytes.IndexByte(lines, '\n'); i >= 0 {
+			line, lines = lines[:i], lines[i+1:]
+		} else {
+			lines = nil
+		}
+		colon := bytes.IndexByte(line, ':')
+		if colon < 0 {
+			continue
+		}
+		name := bytes.TrimSpace(line[:colon])
+		for _, n := range names {
+			if bytes.EqualFold(name, []byte(n)) {
+				return bytes.TrimSpace(line[colon+1:]), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// contentLength returns the value of the Content-Length field, or of one
+// of the other names, of the header block, 0 if absent.
+func contentLength(header []byte, names ...string) (int, error) {
+	if len(names) == 0 {
+		names = []string{"Content-Length"}
+	}
+	v, ok := headerValue(header, names...)
+	if !ok {
+		return 0, nil
+	}
+	return lengthValue(v, names[0])
+}
+
+// lengthValue returns the length of the value of the field of the name.
+func lengthValue(v []byte, name string) (int, error) {
+	n, err := strconv.Atoi(string(v))
+	if err != nil || n < 0 || v[0] == '+' {
+		return 0, fmt.Errorf("%w: bad %s %q", ErrProtocolViolation, name, v)
+	}
+	return n, nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// httpBodiless reports whether the response of the start line has no
+// body: the 1xx, the 204 and the 304 responses.
+func httpBodiless(line []byte) bool {
+	i := bytes.IndexByte(line, ' ')
+	if i < 0 || len(line) < i+4 {
+		return false
+	}
+	code := string(line[i+1 : i+4])
+	return code[0] == '1' || code == "204" || code == "304"
+}
+
+// ScanHTTPMessage is a split function for a Protoscan that returns each
+// HTTP/1.x request or response as a token, the start line and the header
+// fields followed by the body: the chunked body up to its last chunk and
+// trailer section if the Transfer-Encoding ends by chunked, otherwise the
+// body of the Content-Length. The request without them has no body, as
+// have the 1xx, the 204 and the 304 responses, while the other response
+// without them is read until the connection closes, delivered at EOF
+// together with the FinalToken. The responses to the HEAD requests are
+// not told apart, so their Content-Length should be absent. The empty
+// lines before the messages are skipped. The request of the other
+// Transfer-Encoding, the bad Content-Length and the header longer than
+// 64KiB stop the scan with the ErrProtocolViolation.
+func ScanHTTPMessage(data []byte, atEOF bool) (int, int, []byte, error) {
+	start := 0
+	for start < len(data) && (data[start] == '\r' || data[start] == '\n') {
+		start++
+	}
+	if start == len(data) {
+		if atEOF {
+			return 0, start, nil, nil
+		}
+		return 1, 0, nil, nil
+	}
+	msg := data[start:]
+	end, err := headerEnd(msg)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if end < 0 {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return 1, 0, nil, nil
+	}
+	header := msg[:end]
+	response := bytes.HasPrefix(header, []byte("HTTP/"))
+	if response && httpBodiless(header[:bytes.IndexByte(header, '\n')]) {
+		return 0, start + end, header, nil
+	}
+	if te, ok := headerValue(header, "Transfer-Encoding"); ok {
+		codings := bytes.Split(te, []byte(","))
+		if bytes.EqualFold(bytes.TrimSpace(codings[len(codings)-1]), []byte("chunked")) {
+			hint, n, err := chunkedLen(msg[end:], atEOF)
+			if hint > 0 || err != nil {
+				return hint, 0, nil, err
+			}
+			return 0, start + end + n, msg[:end+n], nil
+		}
+		if !response {
+			return 0, 0, nil, fmt.Errorf("%w: HTTP request of Transfer-Encoding %q", ErrProtocolViolation, te)
+		}
+		return httpUntilClose(msg, start, atEOF)
+	}
+	if _, ok := headerValue(header, "Content-Length"); !ok && response {
+		return httpUntilClose(msg, start, atEOF)
+	}
+	length, err := contentLength(header)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	n := end + length
+	if len(msg) < n {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return n - len(msg), 0, nil, nil
+	}
+	return 0, start + n, msg[:n], nil
+}
+
+// httpUntilClose returns the message read until the connection closes,
+// after the start bytes skipped.
+func httpUntilClose(msg []byte, start int, atEOF bool) (int, int, []byte, error) {
+	if !atEOF {
+		return 1, 0, nil, nil
+	}
+	return 0, start + len(msg), msg, FinalToken
+}
@@ -0,0 +1,42 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanHTTPMessage(t *testing.T) {
+	get := "GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"
+	post := "POST /f HTTP/1.1\r\nContent-Length: 3\r\n\r\nabc"
+	chunked := "HTTP/1.1 200 OK\r\nTransfer-Encoding: gzip, chunked\r\n\r\n3\r\nabc\r\n0\r\nX: y\r\n\r\n"
+	continue100 := "HTTP/1.1 100 Continue\r\n\r\n"
+	notModified := "HTTP/1.1 304 Not Modified\r\nContent-Length: 10\r\n\r\n"
+	sized := "HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok"
+	closed := "HTTP/1.0 200 OK\r\n\r\nuntil\r\n\r\nclose"
+	protoscantest.TestSplitFunc(t, protoscan.ScanHTTPMessage, []protoscantest.Case{
+		{Name: "empty", Input: "", Tokens: nil},
+		{
+			Name:   "messages",
+			Input:  "\r\n" + get + post + chunked + continue100 + notModified + sized + closed,
+			Tokens: []string{get, post, chunked, continue100, notModified, sized, closed},
+		},
+		{Name: "close", Input: "HTTP/1.1 200 OK\r\n\r\n", Tokens: []string{"HTTP/1.1 200 OK\r\n\r\n"}},
+		{Name: "short header", Input: "GET / HTTP/1.1\r\n", Err: io.ErrUnexpectedEOF},
+		{Name: "short body", Input: "POST / HTTP/1.1\r\nContent-Length: 3\r\n\r\nab", Err: io.ErrUnexpectedEOF},
+		{Name: "short chunk", Input: "POST / HTTP/1.1\r\nTransfer-Encoding: chunked\r\n\r\n3\r\nab", Err: io.ErrUnexpectedEOF},
+		{Name: "bad length", Input: "POST / HTTP/1.1\r\nContent-Length: x\r\n\r\n", Err: protoscan.ErrProtocolViolation},
+		{Name: "bad coding", Input: "POST / HTTP/1.1\r\nTransfer-Encoding: gzip\r\n\r\n", Err: protoscan.ErrProtocolViolation},
+	})
+}
+
+func FuzzScanHTTPMessage(f *testing.F) {
+	f.Add([]byte("GET / HTTP/1.1\r\n\r\nHTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n1\r\na\r\n0\r\n\r\n"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanHTTPMessage))
+}
@@ -0,0 +1,114 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// icapEncapsulated returns the offset of the last entity of the
+// Encapsulated header value, after the encapsulated HTTP headers, and
+// whether it is the body.
+func icapEncapsulated(v []byte) (int, bool, error) {
+	offset, last := -1, ""
+	for _, entry := range bytes.Split(v, []byte(",")) {
+		name, value, ok := bytes.Cut(bytes.TrimSpace(entry), []byte("="))
+		n, err := strconv.Atoi(string(value))
+		if !ok || err != nil || n < 0 || n < offset || value[0] == '+' || strings.HasSuffix(last, "-body") {
+			return 0, false, fmt.Errorf("%w: bad ICAP Encapsulated %q", ErrProtocolViolation, v)
+		}
+		switch last = string(name); last {
+		case "req-hdr", "res-hdr", "req-body", "res-body", "opt-body", "null-body":
+		default:
+			return 0, false, fmt.Errorf("%w: bad ICAP Encapsulated %q", ErrProtocolViolation, v)
+		}
+		offset = n
+	}
+	if !strings.HasSuffix(last, "-body") {
+		return 0, false, fmt.Errorf("%w: ICAP Encapsulated %q without the body", ErrProtocolViolation, v)
+	}
+	return offset, last != "null-body", nil
+}
+
+// chunkedLen returns the length of the chunked body at the start of the
+// data up to its last chunk and trailer section, or the hint of the bytes
+// missing.
+func chunkedLen(data []byte, atEOF bool) (int, int, error) {
+	for pos := 0; ; {
+		hint, c, err := parseChunk(data[pos:], atEOF)
+		if hint > 0 || err != nil {
+			return hint, 0, err
+		}
+		pos += c.n
+		if c.size == 0 {
+			return 0, pos, nil
+		}
+	}
+}
+
+// ScanICAP is a split function for a Protoscan that returns each ICAP
+// message as a token: the ICAP header block, the HTTP headers it
+// encapsulates at the offsets of its Encapsulated header, and the chunked
+// encapsulated body up to its last chunk, unless the last entity is the
+// null-body. The message without the Encapsulated header ends at its
+// header block. The data starting by a hex digit are the chunked body
+// continuing the preview after the "100 Continue", returned as its own
+// token. The malformed Encapsulated headers and chunks stop the scan with
+// the ErrProtocolViolation.
+func ScanICAP(data []byte, atEOF bool) (int, int, []byte, error) {
+	if len(data) == 0 {
+		if atEOF {
+			return 0, 0, nil, nil
+		}
+		return 1, 0, nil, nil
+	}
+	if unhex(data[0]) >= 0 {
+		hint, n, err := chunkedLen(data, atEOF)
+		if hint > 0 || err != nil {
+			return hint, 0, nil, err
+		}
+		return 0, n, data[:n], nil
+	}
+	n, err := headerEnd(data)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if n < 0 {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return 1, 0, nil, nil
+	}
+	v, ok := headerValue(data[:n], "Encapsulated")
+	if !ok {
+		return 0, n, data[:n], nil
+	}
+	offset, body, err := icapEncapsulated(v)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if offset > maxBuffer {
+		return 0, 0, nil, fmt.Errorf("%w: ICAP encapsulated headers of %d bytes", ErrProtocolViolation, offset)
+	}
+	end := n + offset
+	if end > len(data) {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return end - len(data), 0, nil, nil
+	}
+	if !body {
+		return 0, end, data[:end], nil
+	}
+	hint, m, err := chunkedLen(data[end:], atEOF)
+	if hint > 0 || err != nil {
+		return hint, 0, nil, err
+	}
+	return 0, end + m, data[:end+m], nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanICAP(t *testing.T) {
+	reqHdr := "GET /file HTTP/1.1\r\nHost: example.com\r\n\r\n"
+	resHdr := "HTTP/1.1 200 OK\r\nContent-Type: text/plain\r\n\r\n"
+	respmod := fmt.Sprintf("RESPMOD icap://av/scan ICAP/1.0\r\nHost: av\r\nPreview: 4\r\n"+
+		"Encapsulated: req-hdr=0, res-hdr=%d, res-body=%d\r\n\r\n", len(reqHdr), len(reqHdr)+len(resHdr)) +
+		reqHdr + resHdr + "4\r\nbody\r\n0\r\n\r\n"
+	continuation := "6\r\n, more\r\n0; ieof\r\n\r\n"
+	reqmod := fmt.Sprintf("REQMOD icap://av/scan ICAP/1.0\r\nencapsulated: req-hdr=0, null-body=%d\r\n\r\n", len(reqHdr)) + reqHdr
+	options := "ICAP/1.0 200 OK\r\nMethods: RESPMOD\r\nEncapsulated: opt-body=0\r\n\r\n3;ext\r\nabc\r\n0\r\nX-Trailer: 1\r\n\r\n"
+	noContent := "ICAP/1.0 204 No Content\r\nISTag: \"x\"\r\n\r\n"
+	protoscantest.TestSplitFunc(t, protoscan.ScanICAP, []protoscantest.Case{
+		{Name: "empty", Input: "", Tokens: nil},
+		{
+			Name:   "messages",
+			Input:  respmod + continuation + reqmod + options + noContent,
+			Tokens: []string{respmod, continuation, reqmod, options, noContent},
+		},
+		{Name: "short header", Input: "REQMOD icap://av ICAP/1.0\r\n", Err: io.ErrUnexpectedEOF},
+		{Name: "short encapsulated", Input: reqmod[:len(reqmod)-5], Err: io.ErrUnexpectedEOF},
+		{Name: "short body", Input: respmod[:len(respmod)-3], Err: io.ErrUnexpectedEOF},
+		{
+			Name:  "bad encapsulated",
+			Input: "REQMOD icap://av ICAP/1.0\r\nEncapsulated: req-hdr=10, req-body=5\r\n\r\n",
+			Err:   protoscan.ErrProtocolViolation,
+		},
+		{
+			Name:  "entity after body",
+			Input: "REQMOD icap://av ICAP/1.0\r\nEncapsulated: req-body=0, req-hdr=5\r\n\r\n",
+			Err:   protoscan.ErrProtocolViolation,
+		},
+		{
+			Name:  "no body",
+			Input: "REQMOD icap://av ICAP/1.0\r\nEncapsulated: req-hdr=0\r\n\r\n",
+			Err:   protoscan.ErrProtocolViolation,
+		},
+		{Name: "bad chunk", Input: "2\r\nabc\r\n", Err: protoscan.ErrProtocolViolation},
+	})
+}
+
+func FuzzScanICAP(f *testing.F) {
+	f.Add([]byte("ICAP/1.0 200 OK\r\nEncapsulated: res-hdr=0, res-body=4\r\n\r\nX\r\n\r\n1\r\na\r\n0\r\n\r\n"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanICAP))
+}
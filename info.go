@@ -0,0 +1,65 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import "time"
+
+// TokenInfo holds metadata of a token generated by a call to Scan.
+// The frame of the token is the data the split function advanced over while
+// delivering the token, the offsets count the bytes of the data stream
+// handed to the split function, that is after decompression and
+// transformation.
+type TokenInfo struct {
+	Start int64     // Offset of the first byte of the frame.
+	End   int64     // Offset just past the last byte of the frame.
+	Time  time.Time // Time at which the last byte of the frame was read.
+
+	// Unterminated reports whether the token is the unterminated final
+	// token, set if the WithUnterminated option is UnterminatedFlag.
+	Unterminated bool
+
+	// Indexes are the Indexes set by the split function for the token, see
+	// the Indexes method.
+	Indexes []int
+}
+
+// TokenInfo returns metadata of the last token generated by a call to Scan.
+func (s *Protoscan) TokenInfo() TokenInfo {
+	return s.info
+}
+
+// readMark records the time of a read.
+type readMark struct {
+	end  int64     // Offset just past the last byte read.
+	time time.Time // Time at which the read returned.
+}
+
+// setInfo sets metadata of the token of the frame of advance bytes which
+// ends at the carriage. It forgets the reads of the frame, except the last one.
+func (s *Protoscan) setInfo(advance int) {
+	end := s.offset + int64(s.start)
+	s.info = TokenInfo{Start: end - int64(advance), End: end, Indexes: s.splitCtx.Indexes}
+	s.trimReads(end)
+	for _, r := range s.reads {
+		if r.end >= end {
+			s.info.Time = r.time
+			break
+		}
+	}
+}
+
+// trimReads forgets the reads which end before the offset, so that the
+// marks of the reads stay within the buffered data. The reads after the
+// mark are kept for the Rewind.
+func (s *Protoscan) trimReads(offset int64) {
+	if s.marked {
+		offset = min(offset, s.mark.offset)
+	}
+	i := 0
+	for i < len(s.reads) && s.reads[i].end < offset {
+		i++
+	}
+	s.reads = s.reads[:copy(s.reads, s.reads[i:])]
+}
@@ -0,0 +1,68 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestTokenInfo(t *testing.T) {
+	const text = "abc\r\n\ndefgh\nij"
+	frames := [][2]int64{{0, 5}, {5, 6}, {6, 12}, {12, 14}}
+	before := time.Now()
+	s := protoscan.New(
+		&protoscantest.SlowReader{Max: 2, R: strings.NewReader(text)},
+		protoscan.WithSplit(protoscan.ScanLines),
+		protoscan.WithMaxBuffer(8),
+	)
+	var i int
+	var last time.Time
+	for i = 0; s.Scan(); i++ {
+		info := s.TokenInfo()
+		if info.Start != frames[i][0] || info.End != frames[i][1] {
+			t.Errorf("%d: expected frame %v got [%d %d]", i, frames[i], info.Start, info.End)
+		}
+		if info.Time.Before(before) || info.Time.Before(last) || info.Time.After(time.Now()) {
+			t.Errorf("%d: unexpected time %v", i, info.Time)
+		}
+		last = info.Time
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	if i != len(frames) {
+		t.Fatalf("expected %d tokens; got %d", len(frames), i)
+	}
+}
+
+// Test that the marks of the reads of the skipped data are forgotten.
+func TestTokenInfoSkippedReads(t *testing.T) {
+	skip := func(data []byte, atEOF bool) (int, int, []byte, error) {
+		if len(data) == 0 {
+			if atEOF {
+				return 0, 0, nil, nil
+			}
+			return 1, 0, nil, nil
+		}
+		return 0, len(data), nil, nil
+	}
+	s := protoscan.New(
+		&protoscantest.SlowReader{Max: 1, R: strings.NewReader(strings.Repeat("x", 100000))},
+		protoscan.WithSplit(skip),
+	)
+	for s.Scan() {
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	if n := s.ReadMarks(); n > 2 {
+		t.Fatalf("expected the marks of the skipped reads forgotten; got %d", n)
+	}
+}
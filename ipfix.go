@@ -0,0 +1,162 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	ipfixVersion  = 10
+	ipfixHeader   = 16
+	netflowV9     = 9
+	netflowHeader = 20
+)
+
+// netflowTemplatesKey is the key of the record lengths of the NetFlow v9
+// templates in the SplitContext.
+type netflowTemplatesKey struct{}
+
+// ScanIPFIX is a split function for the Protoscan with the
+// WithSplitContext option which returns each IPFIX message as a token,
+// delimited by the length of its 16-byte header of the version 10. The
+// NetFlow v9 packets, which have the count of the records rather than the
+// length in their 20-byte header, are delimited by walking their FlowSets
+// up to the count: the templates and the options templates are one record
+// each, the data records are counted by the record lengths of the
+// templates of the stream, kept in the SplitContext. The data FlowSets of
+// an unknown template, as the other versions and the bad lengths, stop the
+// scan with the ErrProtocolViolation.
+func ScanIPFIX(ctx *SplitContext, data []byte, atEOF bool) (int, int, []byte, error) {
+	if len(data) == 0 && atEOF {
+		return 0, 0, nil, nil
+	}
+	if len(data) < 2 {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return 2 - len(data), 0, nil, nil
+	}
+	header := ipfixHeader
+	switch v := binary.BigEndian.Uint16(data); v {
+	case ipfixVersion:
+	case netflowV9:
+		header = netflowHeader
+	default:
+		return 0, 0, nil, fmt.Errorf("%w: bad IPFIX version %d", ErrProtocolViolation, v)
+	}
+	if len(data) < header {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return header - len(data), 0, nil, nil
+	}
+	n := int(binary.BigEndian.Uint16(data[2:]))
+	if header == netflowHeader {
+		var hint int
+		var err error
+		n, hint, err = netflowLength(ctx, data, n)
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		if hint > 0 {
+			if atEOF {
+				return 0, 0, nil, io.ErrUnexpectedEOF
+			}
+			return hint, 0, nil, nil
+		}
+	}
+	if n < header {
+		return 0, 0, nil, fmt.Errorf("%w: IPFIX message length %d", ErrProtocolViolation, n)
+	}
+	if n > len(data) {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return n - len(data), 0, nil, nil
+	}
+	return 0, n, data[:n], nil
+}
+
+// netflowLength returns the length of the NetFlow v9 packet of the count
+// of the records, or the hint if the data end before it, recording the
+// record lengths of its templates.
+func netflowLength(ctx *SplitContext, data []byte, count int) (int, int, error) {
+	templates, _ := ctx.Value(netflowTemplatesKey{}).(map[uint16]int)
+	pos := netflowHeader
+	for count > 0 {
+		if len(data)-pos < 4 {
+			return 0, pos + 4 - len(data), nil
+		}
+		id := binary.BigEndian.Uint16(data[pos:])
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if length < 4 {
+			return 0, 0, fmt.Errorf("%w: NetFlow FlowSet length %d", ErrProtocolViolation, length)
+		}
+		if len(data)-pos < length {
+			return 0, pos + length - len(data), nil
+		}
+		set := data[pos+4 : pos+length]
+		pos += length
+		switch {
+		case id == 0:
+			for len(set) >= 4 && count > 0 {
+				// The template ID, the field count and the fields of the
+				// type and the length.
+				fields := int(binary.BigEndian.Uint16(set[2:]))
+				if len(set) < 4+4*fields {
+					return 0, 0, fmt.Errorf("%w: NetFlow template beyond its FlowSet", ErrProtocolViolation)
+				}
+				size := 0
+				for i := 0; i < fields; i++ {
+					size += int(binary.BigEndian.Uint16(set[4+4*i+2:]))
+				}
+				if templates == nil {
+					templates = make(map[uint16]int)
+					ctx.SetValue(netflowTemplatesKey{}, templates)
+				}
+				templates[binary.BigEndian.Uint16(set)] = size
+				set = set[4+4*fields:]
+				count--
+			}
+		case id == 1:
+			// The options template of the scope and the option fields,
+			// padded up to the end of the FlowSet.
+			if len(set) < 6 {
+				return 0, 0, fmt.Errorf("%w: NetFlow options template beyond its FlowSet", ErrProtocolViolation)
+			}
+			scope := int(binary.BigEndian.Uint16(set[2:]))
+			options := int(binary.BigEndian.Uint16(set[4:]))
+			if scope%4 != 0 || options%4 != 0 || len(set) < 6+scope+options {
+				return 0, 0, fmt.Errorf("%w: NetFlow options template beyond its FlowSet", ErrProtocolViolation)
+			}
+			size := 0
+			for i := 6; i < 6+scope+options; i += 4 {
+				size += int(binary.BigEndian.Uint16(set[i+2:]))
+			}
+			if templates == nil {
+				templates = make(map[uint16]int)
+				ctx.SetValue(netflowTemplatesKey{}, templates)
+			}
+			templates[binary.BigEndian.Uint16(set)] = size
+			count--
+		case id >= 256:
+			size, ok := templates[id]
+			if !ok || size == 0 {
+				return 0, 0, fmt.Errorf("%w: NetFlow data FlowSet of unknown template %d", ErrProtocolViolation, id)
+			}
+			// The padding is shorter than a record.
+			count -= len(set) / size
+		default:
+			return 0, 0, fmt.Errorf("%w: NetFlow FlowSet ID %d", ErrProtocolViolation, id)
+		}
+	}
+	if count < 0 {
+		return 0, 0, fmt.Errorf("%w: NetFlow records beyond the count", ErrProtocolViolation)
+	}
+	return pos, 0, nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+// be16 returns the big-endian 2-byte integers.
+func be16(v ...int) string {
+	var b []byte
+	for _, v := range v {
+		b = binary.BigEndian.AppendUint16(b, uint16(v))
+	}
+	return string(b)
+}
+
+// ipfixMsg returns the IPFIX message of the sets.
+func ipfixMsg(sets string) string {
+	return be16(10, 16+len(sets)) + strings.Repeat("\x00", 12) + sets
+}
+
+// netflowPacket returns the NetFlow v9 packet of the count and the
+// FlowSets.
+func netflowPacket(count int, sets string) string {
+	return be16(9, count) + strings.Repeat("\x00", 16) + sets
+}
+
+func scanIPFIX(r io.Reader) ([]string, error) {
+	s := protoscan.New(r, protoscan.WithSplitContext(protoscan.ScanIPFIX))
+	var tokens []string
+	for s.Scan() {
+		tokens = append(tokens, string(s.Token()))
+	}
+	return tokens, s.Err()
+}
+
+func TestScanIPFIX(t *testing.T) {
+	// The template 256 of the 4-byte and the 2-byte fields, and the
+	// options template 257 of the 2-byte scope and the 4-byte option.
+	templates := be16(0, 4+4+8, 256, 2, 8, 4, 7, 2)
+	options := be16(1, 4+6+8+2, 257, 4, 4, 1, 2, 41, 4) + "\x00\x00"
+	data := be16(256, 4+2*6+2) + "aaaabbccccdd\x00\x00"
+	stream := ipfixMsg(be16(2, 4)) +
+		netflowPacket(2, templates+options) +
+		netflowPacket(2, data) +
+		netflowPacket(0, "") +
+		ipfixMsg("")
+	want := []string{
+		ipfixMsg(be16(2, 4)),
+		netflowPacket(2, templates+options),
+		netflowPacket(2, data),
+		netflowPacket(0, ""),
+		ipfixMsg(""),
+	}
+	for _, f := range protoscantest.Fragmentations() {
+		tokens, err := scanIPFIX(f.Reader([]byte(stream)))
+		if err != nil {
+			t.Fatalf("%s: %v", f.Name, err)
+		}
+		if strings.Join(tokens, "|") != strings.Join(want, "|") {
+			t.Fatalf("%s: expected tokens %q; got %q", f.Name, want, tokens)
+		}
+	}
+}
+
+func TestScanIPFIXErrors(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		stream string
+		err    error
+	}{
+		{"version", be16(5, 24), protoscan.ErrProtocolViolation},
+		{"length", be16(10, 15) + strings.Repeat("\x00", 12), protoscan.ErrProtocolViolation},
+		{"short header", be16(10, 16) + "\x00", io.ErrUnexpectedEOF},
+		{"short message", ipfixMsg(be16(2, 4))[:18], io.ErrUnexpectedEOF},
+		{"unknown template", netflowPacket(1, be16(300, 8)+"aaaa"), protoscan.ErrProtocolViolation},
+		{"FlowSet length", netflowPacket(1, be16(0, 2)), protoscan.ErrProtocolViolation},
+		{"reserved FlowSet", netflowPacket(1, be16(5, 4)), protoscan.ErrProtocolViolation},
+		{"short FlowSet", netflowPacket(1, be16(0, 16)+be16(256, 1)), io.ErrUnexpectedEOF},
+		{"count", netflowPacket(1, be16(0, 12, 256, 1, 1, 2)) + netflowPacket(1, be16(256, 8)+"aabb"), protoscan.ErrProtocolViolation},
+	} {
+		if _, err := scanIPFIX(strings.NewReader(test.stream)); !errors.Is(err, test.err) {
+			t.Errorf("%s: expected error %v; got %v", test.name, test.err, err)
+		}
+	}
+}
+
+func FuzzScanIPFIX(f *testing.F) {
+	protoscantest.AddCorpus(f, protoscantest.IPFIXCorpus())
+	f.Fuzz(protoscantest.FuzzContext(protoscan.ScanIPFIX))
+}
@@ -0,0 +1,261 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// ISO8583Encoding is the encoding of the length digits of the ISO 8583
+// variable-length fields and of the length headers of the messages.
+type ISO8583Encoding int
+
+// Encodings of the length digits.
+const (
+	ISO8583ASCII  ISO8583Encoding = iota // A byte per digit, LL is 2 bytes, LLL is 3.
+	ISO8583BCD                           // Two digits per byte, LL is 1 byte, LLL is 2.
+	ISO8583Binary                        // Big-endian binary, LL is 1 byte, LLL is 2.
+)
+
+// ISO8583Field describes a data element of the ISO 8583 message, either of
+// the fixed length or the LLVAR or LLLVAR variable length. The lengths count
+// the bytes of the data.
+type ISO8583Field struct {
+	Fixed  int // Length of the fixed-length field, 0 if variable.
+	Digits int // Count of the length digits, 2 for the LLVAR or 3 for the LLLVAR.
+	Max    int // Maximum length of the variable-length field, 0 if unlimited.
+}
+
+// ISO8583Spec describes the layout of the ISO 8583 messages: the MTI, the
+// primary bitmap, the secondary bitmap if its bit is set, and the data
+// elements of the bits set in the bitmaps.
+type ISO8583Spec struct {
+	MTI       int             // Length of the message type indicator, 4 if 0.
+	HexBitmap bool            // Whether the bitmaps are in 16 hex digits instead of 8 bytes.
+	Lengths   ISO8583Encoding // Encoding of the length digits.
+	Fields    [129]ISO8583Field
+}
+
+// ISO8583FieldError records the ISO 8583 message inconsistent with its
+// fields. The Field is the number of the offending field, 0 for the MTI, 1
+// for the bitmaps, or the last field if the message is longer than its
+// fields. It wraps the ErrCorruptFrame.
+type ISO8583FieldError struct {
+	Field  int
+	Offset int // Offset of the field in the message.
+	Reason string
+}
+
+func (e *ISO8583FieldError) Error() string {
+	return fmt.Sprintf("%v: ISO 8583 field %d at offset %d: %s", ErrCorruptFrame, e.Field, e.Offset, e.Reason)
+}
+
+func (e *ISO8583FieldError) Unwrap() error {
+	return ErrCorruptFrame
+}
+
+// ValidateISO8583 returns the split function which validates the fields of
+// the ISO 8583 messages returned as the tokens by the split, catching the
+// messages shorter than their fields claim at the framing layer. The
+// split returns the message without its length header, as the ScanISO8583
+// does by default. The invalid message is advanced over and reported as
+// the ISO8583FieldError, so that the Protoscan with the WithRecovery
+// option skips it.
+func ValidateISO8583(split SplitFunc, spec *ISO8583Spec) SplitFunc {
+	return func(data []byte, atEOF bool) (int, int, []byte, error) {
+		hint, advance, token, err := split(data, atEOF)
+		if err != nil && err != FinalToken || token == nil {
+			return hint, advance, token, err
+		}
+		if verr := spec.Validate(token); verr != nil {
+			return 0, advance, nil, verr
+		}
+		return hint, advance, token, err
+	}
+}
+
+// Validate checks the fields of the ISO 8583 message against its length,
+// returning the ISO8583FieldError of the first inconsistent field.
+func (spec *ISO8583Spec) Validate(msg []byte) error {
+	_, err := spec.index(msg, nil)
+	return err
+}
+
+// index checks the fields of the ISO 8583 message as the Validate, and
+// appends the triplet of the number, the offset and the length of each of
+// the fields to the indexes, the MTI as the field 0 and the bitmaps as the
+// field 1.
+func (spec *ISO8583Spec) index(msg []byte, indexes []int) ([]int, error) {
+	mti := spec.MTI
+	if mti == 0 {
+		mti = 4
+	}
+	if len(msg) < mti {
+		return indexes, &ISO8583FieldError{Field: 0, Offset: 0, Reason: "message shorter than the MTI"}
+	}
+	indexes = append(indexes, 0, 0, mti)
+	off := mti
+	var bitmap [16]byte
+	n := 8
+	for i := 0; i < n; i += 8 {
+		if !spec.readBitmap(msg, &off, bitmap[i:i+8]) {
+			return indexes, &ISO8583FieldError{Field: 1, Offset: off, Reason: "bad bitmap"}
+		}
+		if i == 0 && bitmap[0]&0x80 != 0 {
+			n = 16
+		}
+	}
+	indexes = append(indexes, 1, mti, off-mti)
+	last := 1
+	for field := 2; field <= 8*n; field++ {
+		if bitmap[(field-1)/8]&(0x80>>((field-1)%8)) == 0 {
+			continue
+		}
+		f := spec.Fields[field]
+		length := f.Fixed
+		if length == 0 {
+			if f.Digits == 0 {
+				return indexes, &ISO8583FieldError{Field: field, Offset: off, Reason: "field not in the spec"}
+			}
+			var ok bool
+			length, ok = spec.readLength(msg, &off, f.Digits)
+			if !ok {
+				return indexes, &ISO8583FieldError{Field: field, Offset: off, Reason: "bad length digits"}
+			}
+			if f.Max > 0 && length > f.Max {
+				return indexes, &ISO8583FieldError{Field: field, Offset: off,
+					Reason: fmt.Sprintf("length %d exceeds maximum of %d", length, f.Max)}
+			}
+		}
+		if length > len(msg)-off {
+			return indexes, &ISO8583FieldError{Field: field, Offset: off,
+				Reason: fmt.Sprintf("length %d exceeds remaining %d bytes of the message", length, len(msg)-off)}
+		}
+		indexes = append(indexes, field, off, length)
+		off += length
+		last = field
+	}
+	if off < len(msg) {
+		return indexes, &ISO8583FieldError{Field: last, Offset: off,
+			Reason: fmt.Sprintf("%d bytes after the last field", len(msg)-off)}
+	}
+	return indexes, nil
+}
+
+// readBitmap reads the 8-byte bitmap at the offset, advancing it.
+func (spec *ISO8583Spec) readBitmap(msg []byte, off *int, bitmap []byte) bool {
+	if !spec.HexBitmap {
+		if len(msg)-*off < 8 {
+			return false
+		}
+		copy(bitmap, msg[*off:*off+8])
+		*off += 8
+		return true
+	}
+	if len(msg)-*off < 16 {
+		return false
+	}
+	if _, err := hex.Decode(bitmap, msg[*off:*off+16]); err != nil {
+		return false
+	}
+	*off += 16
+	return true
+}
+
+// readLength reads the length digits at the offset, advancing it.
+func (spec *ISO8583Spec) readLength(msg []byte, off *int, digits int) (int, bool) {
+	size := digits
+	if spec.Lengths != ISO8583ASCII {
+		size = (digits + 1) / 2
+	}
+	if len(msg)-*off < size {
+		return 0, false
+	}
+	length, ok := iso8583Length(msg[*off:*off+size], spec.Lengths)
+	if !ok {
+		return 0, false
+	}
+	*off += size
+	return length, true
+}
+
+// iso8583Length returns the length of the encoding in the bytes.
+func iso8583Length(b []byte, enc ISO8583Encoding) (int, bool) {
+	length := 0
+	for _, b := range b {
+		switch enc {
+		case ISO8583BCD:
+			hi, lo := int(b>>4), int(b&0x0f)
+			if hi > 9 || lo > 9 {
+				return 0, false
+			}
+			length = length*100 + hi*10 + lo
+		case ISO8583Binary:
+			length = length<<8 | int(b)
+		default:
+			if b < '0' || b > '9' {
+				return 0, false
+			}
+			length = length*10 + int(b-'0')
+		}
+	}
+	return length, true
+}
+
+// ISO8583Config configures the length header of the messages split by the
+// ScanISO8583.
+type ISO8583Config struct {
+	HeaderSize int             // Size of the length header, 2 or 4 bytes, 2 if 0.
+	Encoding   ISO8583Encoding // Encoding of the length header.
+	Inclusive  bool            // Whether the length counts the header itself.
+	KeepHeader bool            // Whether the token includes the length header.
+}
+
+// ScanISO8583 returns the split function for a Protoscan that returns each
+// ISO 8583 message prefixed by the length header of the cfg as a token,
+// without the header unless cfg.KeepHeader. The length header in the ASCII
+// or the BCD digits not decimal, or shorter than itself if inclusive, stops
+// the scan with the ErrProtocolViolation. The fields of the messages may be
+// checked by the ValidateISO8583.
+func ScanISO8583(cfg ISO8583Config) SplitFunc {
+	header := cfg.HeaderSize
+	if header == 0 {
+		header = 2
+	}
+	return func(data []byte, atEOF bool) (int, int, []byte, error) {
+		if header != 2 && header != 4 {
+			return 0, 0, nil, fmt.Errorf("protoscan: ISO 8583 length header of %d bytes", header)
+		}
+		if len(data) == 0 && atEOF {
+			return 0, 0, nil, nil
+		}
+		if len(data) < header {
+			if atEOF {
+				return 0, 0, nil, io.ErrUnexpectedEOF
+			}
+			return header - len(data), 0, nil, nil
+		}
+		length, ok := iso8583Length(data[:header], cfg.Encoding)
+		if !ok || cfg.Inclusive && length < header {
+			return 0, 0, nil, fmt.Errorf("%w: bad ISO 8583 length header %q", ErrProtocolViolation, data[:header])
+		}
+		n := length
+		if !cfg.Inclusive {
+			n += header
+		}
+		if n > len(data) {
+			if atEOF {
+				return 0, 0, nil, io.ErrUnexpectedEOF
+			}
+			return n - len(data), 0, nil, nil
+		}
+		if cfg.KeepHeader {
+			return 0, n, data[:n], nil
+		}
+		return 0, n, data[header:n], nil
+	}
+}
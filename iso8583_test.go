@@ -0,0 +1,134 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+// isoSpec has the fixed PAN-less fields 3 and 11, the LLVAR 2 and the
+// LLLVAR 48 of at most 20 bytes.
+func isoSpec(lengths protoscan.ISO8583Encoding) *protoscan.ISO8583Spec {
+	spec := &protoscan.ISO8583Spec{Lengths: lengths}
+	spec.Fields[2] = protoscan.ISO8583Field{Digits: 2, Max: 19}
+	spec.Fields[3] = protoscan.ISO8583Field{Fixed: 6}
+	spec.Fields[11] = protoscan.ISO8583Field{Fixed: 6}
+	spec.Fields[48] = protoscan.ISO8583Field{Digits: 3, Max: 20}
+	return spec
+}
+
+// bitmap of the fields 2, 3, 11 and 48.
+const isoBitmap = "\x60\x20\x00\x00\x00\x01\x00\x00"
+
+func TestISO8583Validate(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		lengths protoscan.ISO8583Encoding
+		msg     string
+		field   int // Offending field, -1 if valid.
+	}{
+		{"valid", protoscan.ISO8583ASCII, "0200" + isoBitmap + "164111111111111111" + "000000" + "000001" + "005hello", -1},
+		{"bcd", protoscan.ISO8583BCD, "0200" + isoBitmap + "\x16" + "4111111111111111" + "000000" + "000001" + "\x00\x05hello", -1},
+		{"binary", protoscan.ISO8583Binary, "0200" + isoBitmap + "\x10" + "4111111111111111" + "000000" + "000001" + "\x00\x05hello", -1},
+		{"short mti", protoscan.ISO8583ASCII, "02", 0},
+		{"short bitmap", protoscan.ISO8583ASCII, "0200\x60", 1},
+		{"short llvar", protoscan.ISO8583ASCII, "0200" + isoBitmap + "164111", 2},
+		{"bad digits", protoscan.ISO8583ASCII, "0200" + isoBitmap + "1x4111111111111111", 2},
+		{"too long", protoscan.ISO8583ASCII, "0200" + isoBitmap + "20" + strings.Repeat("4", 20), 2},
+		{"short fixed", protoscan.ISO8583ASCII, "0200" + isoBitmap + "164111111111111111" + "000000" + "0001", 11},
+		{"short lllvar", protoscan.ISO8583ASCII, "0200" + isoBitmap + "164111111111111111" + "000000" + "000001" + "010hello", 48},
+		{"bad bcd", protoscan.ISO8583BCD, "0200" + isoBitmap + "\x1f", 2},
+		{"binary too long", protoscan.ISO8583Binary, "0200" + isoBitmap + "\x14" + strings.Repeat("4", 20), 2},
+		{"trailing", protoscan.ISO8583ASCII, "0200" + isoBitmap + "164111111111111111" + "000000" + "000001" + "005hello!", 48},
+		{"unknown", protoscan.ISO8583ASCII, "0200\x00\x00\x00\x00\x00\x00\x00\x01x", 64},
+	} {
+		err := isoSpec(test.lengths).Validate([]byte(test.msg))
+		if test.field < 0 {
+			if err != nil {
+				t.Errorf("%s: %v", test.name, err)
+			}
+			continue
+		}
+		var ferr *protoscan.ISO8583FieldError
+		if !errors.As(err, &ferr) || !errors.Is(err, protoscan.ErrCorruptFrame) {
+			t.Errorf("%s: expected field error; got %v", test.name, err)
+			continue
+		}
+		if ferr.Field != test.field {
+			t.Errorf("%s: expected field %d; got %v", test.name, test.field, err)
+		}
+	}
+}
+
+func TestScanISO8583(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		cfg    protoscan.ISO8583Config
+		header string
+	}{
+		{"ascii", protoscan.ISO8583Config{}, "12"},
+		{"ascii 4", protoscan.ISO8583Config{HeaderSize: 4, Encoding: protoscan.ISO8583ASCII}, "0012"},
+		{"bcd", protoscan.ISO8583Config{Encoding: protoscan.ISO8583BCD}, "\x00\x12"},
+		{"bcd 4", protoscan.ISO8583Config{HeaderSize: 4, Encoding: protoscan.ISO8583BCD}, "\x00\x00\x00\x12"},
+		{"binary", protoscan.ISO8583Config{Encoding: protoscan.ISO8583Binary}, "\x00\x0c"},
+		{"binary 4", protoscan.ISO8583Config{HeaderSize: 4, Encoding: protoscan.ISO8583Binary}, "\x00\x00\x00\x0c"},
+		{"inclusive", protoscan.ISO8583Config{Encoding: protoscan.ISO8583Binary, Inclusive: true}, "\x00\x0e"},
+		{"keep header", protoscan.ISO8583Config{Encoding: protoscan.ISO8583Binary, KeepHeader: true}, "\x00\x0c"},
+	} {
+		msg := "0800" + "00000000"
+		want := []string{msg, msg}
+		if test.cfg.KeepHeader {
+			want = []string{test.header + msg, test.header + msg}
+		}
+		protoscantest.TestSplitFunc(t, protoscan.ScanISO8583(test.cfg), []protoscantest.Case{
+			{Name: test.name, Input: test.header + msg + test.header + msg, Tokens: want},
+			{Name: test.name + " short", Input: test.header + msg[:len(msg)/2], Err: io.ErrUnexpectedEOF},
+		})
+	}
+	protoscantest.TestSplitFunc(t, protoscan.ScanISO8583(protoscan.ISO8583Config{}), []protoscantest.Case{
+		{Name: "bad digits", Input: "1x0800", Err: protoscan.ErrProtocolViolation},
+	})
+	protoscantest.TestSplitFunc(t, protoscan.ScanISO8583(protoscan.ISO8583Config{Encoding: protoscan.ISO8583Binary, Inclusive: true}), []protoscantest.Case{
+		{Name: "inclusive too short", Input: "\x00\x01", Err: protoscan.ErrProtocolViolation},
+	})
+}
+
+// Test that the invalid messages are skipped with the recovery.
+func TestValidateISO8583(t *testing.T) {
+	valid := "0200" + isoBitmap + "164111111111111111" + "000000" + "000001" + "005hello"
+	invalid := "0200" + isoBitmap + "164111111111111111" + "000000" + "000001" + "009hello"
+	var stream []byte
+	for _, msg := range []string{valid, invalid, valid} {
+		stream = append(stream, byte(len(msg)>>8), byte(len(msg)))
+		stream = append(stream, msg...)
+	}
+	var skipped []error
+	s := protoscan.New(
+		strings.NewReader(string(stream)),
+		protoscan.WithSplit(protoscan.ValidateISO8583(protoscan.ScanISO8583(protoscan.ISO8583Config{Encoding: protoscan.ISO8583Binary}), isoSpec(protoscan.ISO8583ASCII))),
+		protoscan.WithRecovery(func(err error, _ []byte) { skipped = append(skipped, err) }),
+	)
+	var n int
+	for ; s.Scan(); n++ {
+		if string(s.Token()) != valid {
+			t.Fatalf("unexpected message %q", s.Token())
+		}
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	if n != 2 || len(skipped) != 1 {
+		t.Fatalf("expected 2 messages and 1 skipped; got %d and %v", n, skipped)
+	}
+	if want := "protoscan: corrupt frame: ISO 8583 field 48 at offset 45: length 9 exceeds remaining 5 bytes of the message"; skipped[0].Error() != want {
+		t.Fatalf("expected error %q; got %q", want, skipped[0])
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import "strconv"
+
+// iso8583Elements holds the names and the layouts of the data elements of
+// the ISO 8583:1987, the numeric fields in the ASCII digits and the binary
+// fields of 64 bits in 8 bytes.
+var iso8583Elements = [129]struct {
+	name  string
+	field ISO8583Field
+}{
+	2:   {"Primary account number", ISO8583Field{Digits: 2, Max: 19}},
+	3:   {"Processing code", ISO8583Field{Fixed: 6}},
+	4:   {"Amount, transaction", ISO8583Field{Fixed: 12}},
+	5:   {"Amount, settlement", ISO8583Field{Fixed: 12}},
+	6:   {"Amount, cardholder billing", ISO8583Field{Fixed: 12}},
+	7:   {"Transmission date and time", ISO8583Field{Fixed: 10}},
+	8:   {"Amount, cardholder billing fee", ISO8583Field{Fixed: 8}},
+	9:   {"Conversion rate, settlement", ISO8583Field{Fixed: 8}},
+	10:  {"Conversion rate, cardholder billing", ISO8583Field{Fixed: 8}},
+	11:  {"System trace audit number", ISO8583Field{Fixed: 6}},
+	12:  {"Time, local transaction", ISO8583Field{Fixed: 6}},
+	13:  {"Date, local transaction", ISO8583Field{Fixed: 4}},
+	14:  {"Date, expiration", ISO8583Field{Fixed: 4}},
+	15:  {"Date, settlement", ISO8583Field{Fixed: 4}},
+	16:  {"Date, conversion", ISO8583Field{Fixed: 4}},
+	17:  {"Date, capture", ISO8583Field{Fixed: 4}},
+	18:  {"Merchant type", ISO8583Field{Fixed: 4}},
+	19:  {"Acquiring institution country code", ISO8583Field{Fixed: 3}},
+	20:  {"PAN extended, country code", ISO8583Field{Fixed: 3}},
+	21:  {"Forwarding institution country code", ISO8583Field{Fixed: 3}},
+	22:  {"Point of service entry mode", ISO8583Field{Fixed: 3}},
+	23:  {"Application PAN sequence number", ISO8583Field{Fixed: 3}},
+	24:  {"Network international identifier", ISO8583Field{Fixed: 3}},
+	25:  {"Point of service condition code", ISO8583Field{Fixed: 2}},
+	26:  {"Point of service capture code", ISO8583Field{Fixed: 2}},
+	27:  {"Authorizing identification response length", ISO8583Field{Fixed: 1}},
+	28:  {"Amount, transaction fee", ISO8583Field{Fixed: 9}},
+	29:  {"Amount, settlement fee", ISO8583Field{Fixed: 9}},
+	30:  {"Amount, transaction processing fee", ISO8583Field{Fixed: 9}},
+	31:  {"Amount, settlement processing fee", ISO8583Field{Fixed: 9}},
+	32:  {"Acquiring institution identification code", ISO8583Field{Digits: 2, Max: 11}},
+	33:  {"Forwarding institution identification code", ISO8583Field{Digits: 2, Max: 11}},
+	34:  {"Primary account number, extended", ISO8583Field{Digits: 2, Max: 28}},
+	35:  {"Track 2 data", ISO8583Field{Digits: 2, Max: 37}},
+	36:  {"Track 3 data", ISO8583Field{Digits: 3, Max: 104}},
+	37:  {"Retrieval reference number", ISO8583Field{Fixed: 12}},
+	38:  {"Authorization identification response", ISO8583Field{Fixed: 6}},
+	39:  {"Response code", ISO8583Field{Fixed: 2}},
+	40:  {"Service restriction code", ISO8583Field{Fixed: 3}},
+	41:  {"Card acceptor terminal identification", ISO8583Field{Fixed: 8}},
+	42:  {"Card acceptor identification code", ISO8583Field{Fixed: 15}},
+	43:  {"Card acceptor name/location", ISO8583Field{Fixed: 40}},
+	44:  {"Additional response data", ISO8583Field{Digits: 2, Max: 25}},
+	45:  {"Track 1 data", ISO8583Field{Digits: 2, Max: 76}},
+	46:  {"Additional data, ISO", ISO8583Field{Digits: 3, Max: 999}},
+	47:  {"Additional data, national", ISO8583Field{Digits: 3, Max: 999}},
+	48:  {"Additional data, private", ISO8583Field{Digits: 3, Max: 999}},
+	49:  {"Currency code, transaction", ISO8583Field{Fixed: 3}},
+	50:  {"Currency code, settlement", ISO8583Field{Fixed: 3}},
+	51:  {"Currency code, cardholder billing", ISO8583Field{Fixed: 3}},
+	52:  {"Personal identification number data", ISO8583Field{Fixed: 8}},
+	53:  {"Security related control information", ISO8583Field{Fixed: 16}},
+	54:  {"Additional amounts", ISO8583Field{Digits: 3, Max: 120}},
+	55:  {"ICC data", ISO8583Field{Digits: 3, Max: 999}},
+	56:  {"Reserved, ISO", ISO8583Field{Digits: 3, Max: 999}},
+	57:  {"Reserved, national", ISO8583Field{Digits: 3, Max: 999}},
+	58:  {"Reserved, national", ISO8583Field{Digits: 3, Max: 999}},
+	59:  {"Reserved, national", ISO8583Field{Digits: 3, Max: 999}},
+	60:  {"Reserved, national", ISO8583Field{Digits: 3, Max: 999}},
+	61:  {"Reserved, private", ISO8583Field{Digits: 3, Max: 999}},
+	62:  {"Reserved, private", ISO8583Field{Digits: 3, Max: 999}},
+	63:  {"Reserved, private", ISO8583Field{Digits: 3, Max: 999}},
+	64:  {"Message authentication code", ISO8583Field{Fixed: 8}},
+	65:  {"Bitmap, extended", ISO8583Field{Fixed: 8}},
+	66:  {"Settlement code", ISO8583Field{Fixed: 1}},
+	67:  {"Extended payment code", ISO8583Field{Fixed: 2}},
+	68:  {"Receiving institution country code", ISO8583Field{Fixed: 3}},
+	69:  {"Settlement institution country code", ISO8583Field{Fixed: 3}},
+	70:  {"Network management information code", ISO8583Field{Fixed: 3}},
+	71:  {"Message number", ISO8583Field{Fixed: 4}},
+	72:  {"Message number, last", ISO8583Field{Fixed: 4}},
+	73:  {"Date, action", ISO8583Field{Fixed: 6}},
+	74:  {"Credits, number", ISO8583Field{Fixed: 10}},
+	75:  {"Credits, reversal number", ISO8583Field{Fixed: 10}},
+	76:  {"Debits, number", ISO8583Field{Fixed: 10}},
+	77:  {"Debits, reversal number", ISO8583Field{Fixed: 10}},
+	78:  {"Transfer, number", ISO8583Field{Fixed: 10}},
+	79:  {"Transfer, reversal number", ISO8583Field{Fixed: 10}},
+	80:  {"Inquiries, number", ISO8583Field{Fixed: 10}},
+	81:  {"Authorizations, number", ISO8583Field{Fixed: 10}},
+	82:  {"Credits, processing fee amount", ISO8583Field{Fixed: 12}},
+	83:  {"Credits, transaction fee amount", ISO8583Field{Fixed: 12}},
+	84:  {"Debits, processing fee amount", ISO8583Field{Fixed: 12}},
+	85:  {"Debits, transaction fee amount", ISO8583Field{Fixed: 12}},
+	86:  {"Credits, amount", ISO8583Field{Fixed: 16}},
+	87:  {"Credits, reversal amount", ISO8583Field{Fixed: 16}},
+	88:  {"Debits, amount", ISO8583Field{Fixed: 16}},
+	89:  {"Debits, reversal amount", ISO8583Field{Fixed: 16}},
+	90:  {"Original data elements", ISO8583Field{Fixed: 42}},
+	91:  {"File update code", ISO8583Field{Fixed: 1}},
+	92:  {"File security code", ISO8583Field{Fixed: 2}},
+	93:  {"Response indicator", ISO8583Field{Fixed: 5}},
+	94:  {"Service indicator", ISO8583Field{Fixed: 7}},
+	95:  {"Replacement amounts", ISO8583Field{Fixed: 42}},
+	96:  {"Message security code", ISO8583Field{Fixed: 8}},
+	97:  {"Amount, net settlement", ISO8583Field{Fixed: 17}},
+	98:  {"Payee", ISO8583Field{Fixed: 25}},
+	99:  {"Settlement institution identification code", ISO8583Field{Digits: 2, Max: 11}},
+	100: {"Receiving institution identification code", ISO8583Field{Digits: 2, Max: 11}},
+	101: {"File name", ISO8583Field{Digits: 2, Max: 17}},
+	102: {"Account identification 1", ISO8583Field{Digits: 2, Max: 28}},
+	103: {"Account identification 2", ISO8583Field{Digits: 2, Max: 28}},
+	104: {"Transaction description", ISO8583Field{Digits: 3, Max: 100}},
+	105: {"Reserved, ISO", ISO8583Field{Digits: 3, Max: 999}},
+	106: {"Reserved, ISO", ISO8583Field{Digits: 3, Max: 999}},
+	107: {"Reserved, ISO", ISO8583Field{Digits: 3, Max: 999}},
+	108: {"Reserved, ISO", ISO8583Field{Digits: 3, Max: 999}},
+	109: {"Reserved, ISO", ISO8583Field{Digits: 3, Max: 999}},
+	110: {"Reserved, ISO", ISO8583Field{Digits: 3, Max: 999}},
+	111: {"Reserved, ISO", ISO8583Field{Digits: 3, Max: 999}},
+	112: {"Reserved, national", ISO8583Field{Digits: 3, Max: 999}},
+	113: {"Reserved, national", ISO8583Field{Digits: 3, Max: 999}},
+	114: {"Reserved, national", ISO8583Field{Digits: 3, Max: 999}},
+	115: {"Reserved, national", ISO8583Field{Digits: 3, Max: 999}},
+	116: {"Reserved, national", ISO8583Field{Digits: 3, Max: 999}},
+	117: {"Reserved, national", ISO8583Field{Digits: 3, Max: 999}},
+	118: {"Reserved, national", ISO8583Field{Digits: 3, Max: 999}},
+	119: {"Reserved, national", ISO8583Field{Digits: 3, Max: 999}},
+	120: {"Reserved, private", ISO8583Field{Digits: 3, Max: 999}},
+	121: {"Reserved, private", ISO8583Field{Digits: 3, Max: 999}},
+	122: {"Reserved, private", ISO8583Field{Digits: 3, Max: 999}},
+	123: {"Reserved, private", ISO8583Field{Digits: 3, Max: 999}},
+	124: {"Reserved, private", ISO8583Field{Digits: 3, Max: 999}},
+	125: {"Reserved, private", ISO8583Field{Digits: 3, Max: 999}},
+	126: {"Reserved, private", ISO8583Field{Digits: 3, Max: 999}},
+	127: {"Reserved, private", ISO8583Field{Digits: 3, Max: 999}},
+	128: {"Message authentication code", ISO8583Field{Fixed: 8}},
+}
+
+// ISO8583Spec1987 returns the spec of the ISO 8583:1987 messages with the
+// binary bitmaps and the numeric fields and the length digits in ASCII.
+func ISO8583Spec1987() *ISO8583Spec {
+	spec := &ISO8583Spec{}
+	for i, e := range iso8583Elements {
+		spec.Fields[i] = e.field
+	}
+	return spec
+}
+
+// ScanISO8583Fields returns the split function for the Protoscan with the
+// WithSplitContext option which validates the ISO 8583 messages returned as
+// the tokens by the split, as the ValidateISO8583, and records each of the
+// fields of the message in the Indexes as the triplet of the field number,
+// the offset of the value in the token and the length of the value. The
+// MTI is recorded as the field 0 and the bitmaps as the field 1.
+func ScanISO8583Fields(split SplitFunc, spec *ISO8583Spec) SplitCtxFunc {
+	return func(ctx *SplitContext, data []byte, atEOF bool) (int, int, []byte, error) {
+		hint, advance, token, err := split(data, atEOF)
+		if err != nil && err != FinalToken || token == nil {
+			return hint, advance, token, err
+		}
+		indexes, verr := spec.index(token, ctx.Indexes)
+		if verr != nil {
+			ctx.Indexes = indexes[:0]
+			return 0, advance, nil, verr
+		}
+		ctx.Indexes = indexes
+		return hint, advance, token, err
+	}
+}
+
+// AnnotateISO8583 is the AnnotateFunc which names the fields of the ISO
+// 8583 message indexed by the ScanISO8583Fields after the ISO 8583:1987.
+// The printable fields are annotated with their values.
+func AnnotateISO8583(token []byte, info TokenInfo) []Annotation {
+	var annotations []Annotation
+	for i := 0; i+2 < len(info.Indexes); i += 3 {
+		a := Annotation{Offset: info.Indexes[i+1], Length: info.Indexes[i+2]}
+		switch field := info.Indexes[i]; {
+		case field == 0:
+			a.Field = "Message type indicator"
+		case field == 1:
+			a.Field = "Bitmap"
+		case field < len(iso8583Elements):
+			a.Field = "DE" + strconv.Itoa(field) + " " + iso8583Elements[field].name
+		default:
+			a.Field = "DE" + strconv.Itoa(field)
+		}
+		if value := a.field(token); printable(value) {
+			a.Value = string(value)
+		}
+		annotations = append(annotations, a)
+	}
+	return annotations
+}
+
+// printable reports whether the bytes are the printable ASCII.
+func printable(b []byte) bool {
+	for _, c := range b {
+		if c < ' ' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanISO8583Fields(t *testing.T) {
+	valid := "0200" + isoBitmap + "164111111111111111" + "000000" + "000001" + "005hello"
+	invalid := "0200" + isoBitmap + "164111111111111111" + "000000" + "000001" + "009hello"
+	var stream []byte
+	for _, msg := range []string{invalid, valid} {
+		stream = append(stream, byte(len(msg)>>8), byte(len(msg)))
+		stream = append(stream, msg...)
+	}
+	var skipped int
+	s := protoscan.New(
+		strings.NewReader(string(stream)),
+		protoscan.WithSplitContext(protoscan.ScanISO8583Fields(protoscan.ScanISO8583(protoscan.ISO8583Config{Encoding: protoscan.ISO8583Binary}), protoscan.ISO8583Spec1987())),
+		protoscan.WithRecovery(func(error, []byte) { skipped++ }),
+		protoscan.WithAnnotator(protoscan.AnnotateISO8583),
+	)
+	if !s.Scan() {
+		t.Fatal(s.Err())
+	}
+	if want := "[0 0 4 1 4 8 2 14 16 3 30 6 11 36 6 48 45 5]"; fmt.Sprint(s.Indexes()) != want {
+		t.Fatalf("expected indexes %s; got %v", want, s.Indexes())
+	}
+	var got []string
+	for _, a := range s.Annotations() {
+		got = append(got, fmt.Sprintf("%s=%q", a.Field, a.Value))
+	}
+	want := `Message type indicator="0200"|Bitmap=""|DE2 Primary account number="4111111111111111"|` +
+		`DE3 Processing code="000000"|DE11 System trace audit number="000001"|DE48 Additional data, private="hello"`
+	if strings.Join(got, "|") != want {
+		t.Fatalf("unexpected annotations %s", got)
+	}
+	if s.Scan() || s.Err() != nil || skipped != 1 {
+		t.Fatalf("expected the end after a skipped message; got %v, %d skipped", s.Err(), skipped)
+	}
+}
+
+func TestAnnotateISO8583(t *testing.T) {
+	if a := protoscan.AnnotateISO8583([]byte("0800"), protoscan.TokenInfo{}); a != nil {
+		t.Fatalf("expected no annotations without indexes; got %v", a)
+	}
+	a := protoscan.AnnotateISO8583([]byte("xy"), protoscan.TokenInfo{Indexes: []int{129, 0, 2}})
+	if len(a) != 1 || a[0].Field != "DE129" || a[0].Value != "xy" {
+		t.Fatalf("unexpected annotations %v", a)
+	}
+}
+
+func FuzzScanISO8583Fields(f *testing.F) {
+	protoscantest.AddCorpus(f, protoscantest.ISO8583Corpus())
+	f.Fuzz(protoscantest.FuzzContext(protoscan.ScanISO8583Fields(protoscan.ScanISO8583(protoscan.ISO8583Config{Encoding: protoscan.ISO8583Binary}), protoscan.ISO8583Spec1987())))
+}
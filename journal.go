@@ -0,0 +1,77 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// ScanJournalExport is a split function for a Protoscan that returns each
+// entry of the systemd journal export format as a token: the fields of the
+// entry up to the empty line separating the entries, which is dropped. The
+// text fields are the "FIELD=value" lines, the binary fields are the field
+// name line followed by the 64-bit little-endian size, the data of the
+// size, which may hold the newlines, and the newline. The binary data not
+// followed by the newline stop the scan with the ErrProtocolViolation. The
+// last entry may end at EOF without the empty line.
+func ScanJournalExport(data []byte, atEOF bool) (int, int, []byte, error) {
+	pos := 0
+	for {
+		if pos == len(data) {
+			if !atEOF {
+				return 1, 0, nil, nil
+			}
+			if pos == 0 {
+				return 0, 0, nil, nil
+			}
+			return 0, pos, data[:pos], nil
+		}
+		i := bytes.IndexByte(data[pos:], '\n')
+		if i < 0 {
+			if atEOF {
+				return 0, 0, nil, io.ErrUnexpectedEOF
+			}
+			return 1, 0, nil, nil
+		}
+		if i == 0 {
+			if pos == 0 {
+				// The empty entry.
+				return 0, 1, nil, nil
+			}
+			return 0, pos + 1, data[:pos], nil
+		}
+		line := data[pos : pos+i]
+		pos += i + 1
+		if bytes.IndexByte(line, '=') >= 0 {
+			continue
+		}
+		// The binary field.
+		if len(data)-pos < 8 {
+			if atEOF {
+				return 0, 0, nil, io.ErrUnexpectedEOF
+			}
+			return 8 - (len(data) - pos), 0, nil, nil
+		}
+		size := binary.LittleEndian.Uint64(data[pos:])
+		if size > math.MaxInt-uint64(pos)-9 {
+			return 0, 0, nil, fmt.Errorf("%w: journal field %q of size %d", ErrProtocolViolation, line, size)
+		}
+		end := pos + 8 + int(size) + 1
+		if end > len(data) {
+			if atEOF {
+				return 0, 0, nil, io.ErrUnexpectedEOF
+			}
+			return end - len(data), 0, nil, nil
+		}
+		if data[end-1] != '\n' {
+			return 0, 0, nil, fmt.Errorf("%w: journal field %q not followed by newline", ErrProtocolViolation, line)
+		}
+		pos = end
+	}
+}
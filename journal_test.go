@@ -0,0 +1,34 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanJournalExport(t *testing.T) {
+	first := "__CURSOR=s=1\n__REALTIME_TIMESTAMP=1\nMESSAGE\n\x0c\x00\x00\x00\x00\x00\x00\x00line1\n\nline2\n_PID=7\n"
+	second := "MESSAGE=hello\n"
+	protoscantest.TestSplitFunc(t, protoscan.ScanJournalExport, []protoscantest.Case{
+		{Name: "empty", Input: "", Tokens: nil},
+		{Name: "entries", Input: first + "\n" + second + "\n", Tokens: []string{first, second}},
+		{Name: "no final empty line", Input: first + "\n" + second, Tokens: []string{first, second}},
+		{Name: "empty lines", Input: "\n\n" + second + "\n\n", Tokens: []string{second}},
+		{Name: "short line", Input: second + "\nMESSAGE=he", Tokens: []string{second}, Err: io.ErrUnexpectedEOF},
+		{Name: "short size", Input: "DATA\n\x02\x00", Err: io.ErrUnexpectedEOF},
+		{Name: "short data", Input: "DATA\n\x02\x00\x00\x00\x00\x00\x00\x00a", Err: io.ErrUnexpectedEOF},
+		{Name: "no newline", Input: "DATA\n\x02\x00\x00\x00\x00\x00\x00\x00abc\n", Err: protoscan.ErrProtocolViolation},
+		{Name: "huge size", Input: "DATA\n\xff\xff\xff\xff\xff\xff\xff\xff", Err: protoscan.ErrProtocolViolation},
+	})
+}
+
+func FuzzScanJournalExport(f *testing.F) {
+	f.Add([]byte("A=1\nB\n\x02\x00\x00\x00\x00\x00\x00\x00\n\n\n\nC=2\n"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanJournalExport))
+}
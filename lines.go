@@ -0,0 +1,34 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import "bytes"
+
+// Line returns the number of the line, counted from 1, at which the frame
+// of the last token generated by a call to Scan starts. The lines are
+// counted by the newlines the split function advanced over, so the number
+// is the line of the token for the line-oriented split functions such as
+// ScanLines. Line returns 0 unless the WithLineCount is set.
+func (s *Protoscan) Line() int {
+	if s.newline == nil {
+		return 0
+	}
+	return s.line
+}
+
+// WithLineCount makes the Protoscan count the lines ended by the newline
+// byte, '\n' for the ScanLines, or 0x15 for the ScanLinesEBCDIC.
+func WithLineCount(newline byte) Option {
+	return func(s *Protoscan) { s.newline = []byte{newline} }
+}
+
+// move moves the carriage forward on n bytes, counting the newlines if
+// the lines are counted.
+func (s *Protoscan) move(n int) {
+	if s.newline != nil {
+		s.lines += bytes.Count(s.buffer[s.start:s.start+n], s.newline)
+	}
+	s.start += n
+}
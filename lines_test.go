@@ -0,0 +1,94 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestLine(t *testing.T) {
+	s := protoscan.New(
+		&protoscantest.SlowReader{Max: 3, R: strings.NewReader("a b\nc\r\n\nd e")},
+		protoscan.WithSplit(protoscan.ScanLines),
+		protoscan.WithLineCount('\n'),
+	)
+	var lines []int
+	for s.Scan() {
+		lines = append(lines, s.Line())
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	if got, want := lines, []int{1, 2, 3, 4}; !equalInts(got, want) {
+		t.Fatalf("expected lines %v; got %v", want, got)
+	}
+}
+
+func TestLineScanError(t *testing.T) {
+	errBad := errors.New("bad")
+	split := func(data []byte, atEOF bool) (int, int, []byte, error) {
+		if len(data) > 0 && data[0] == '!' {
+			return 0, 0, nil, errBad
+		}
+		return protoscan.ScanLines(data, atEOF)
+	}
+	s := protoscan.New(
+		strings.NewReader("a\nb\n!\n"),
+		protoscan.WithSplit(split),
+		protoscan.WithErrorContext(8),
+		protoscan.WithLineCount('\n'),
+	)
+	for s.Scan() {
+	}
+	var scanErr *protoscan.ScanError
+	if !errors.As(s.Err(), &scanErr) || scanErr.Line != 3 {
+		t.Fatalf("expected ScanError at line 3; got %v", s.Err())
+	}
+	if got, want := scanErr.Error(), "protoscan: line 3, offset 4: bad"; got != want {
+		t.Fatalf("expected %q; got %q", want, got)
+	}
+}
+
+func TestLineCount(t *testing.T) {
+	for _, test := range []struct {
+		split protoscan.SplitFunc
+		input string
+		opts  []protoscan.Option
+		want  []int
+	}{
+		{protoscan.ScanLines, "a\nb\nc\n", nil, []int{0, 0, 0}},
+		{protoscan.ScanLines, "a\nb\nc\n", []protoscan.Option{protoscan.WithLineCount('\n')}, []int{1, 2, 3}},
+		{protoscan.ScanLinesEBCDIC, "\x81\x15\x82\x15\x83", []protoscan.Option{protoscan.WithLineCount(0x15)}, []int{1, 2, 3}},
+	} {
+		s := protoscan.New(strings.NewReader(test.input), append(test.opts, protoscan.WithSplit(test.split))...)
+		var lines []int
+		for s.Scan() {
+			lines = append(lines, s.Line())
+		}
+		if s.Err() != nil {
+			t.Fatal(s.Err())
+		}
+		if !equalInts(lines, test.want) {
+			t.Fatalf("%q: expected lines %v; got %v", test.input, test.want, lines)
+		}
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,30 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"context"
+	"log/slog"
+)
+
+// WithLogger sets the logger of the notable events of the scan, such as
+// the growth of the buffer, the dropped tokens, the oversize frames and
+// the errors stopping the scan. The events are logged at the level with
+// the offset in the data stream and the relevant sizes as attributes.
+func WithLogger(l *slog.Logger, level slog.Level) Option {
+	return func(s *Protoscan) {
+		s.logger = l
+		s.logLevel = level
+	}
+}
+
+// log logs the event at the offset of the carriage.
+func (s *Protoscan) log(msg string, attrs ...slog.Attr) {
+	if s.logger == nil {
+		return
+	}
+	attrs = append([]slog.Attr{slog.Int64("offset", s.offset+int64(s.start))}, attrs...)
+	s.logger.LogAttrs(context.Background(), s.logLevel, msg, attrs...)
+}
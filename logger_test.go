@@ -0,0 +1,46 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	s := protoscan.New(
+		strings.NewReader("ab\n\nabcdefghij\n"),
+		protoscan.WithSplit(protoscan.ScanLines),
+		protoscan.WithDrop(func(token []byte) bool { return len(token) == 0 }),
+		protoscan.WithBuffer(make([]byte, 0, 4)),
+		protoscan.WithMaxBuffer(8),
+		protoscan.WithLogger(logger, slog.LevelWarn),
+	)
+	for s.Scan() {
+	}
+	for _, want := range []string{
+		`level=WARN msg="token dropped" offset=4 size=1`,
+		`level=WARN msg="buffer grown" offset=4 from=4 to=8`,
+		`level=WARN msg="frame too long" offset=4 size=9 max=8`,
+		`level=WARN msg="scan stopped" offset=4 error="protoscan: token too long: 9 bytes exceeds maximum of 8"`,
+	} {
+		if !strings.Contains(buf.String(), want+"\n") {
+			t.Errorf("no %s in the log:\n%s", want, buf.String())
+		}
+	}
+}
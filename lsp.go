@@ -0,0 +1,49 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"fmt"
+	"io"
+)
+
+// ScanLSP is a split function for a Protoscan that returns the content of
+// each message of the base protocol of the Language Server Protocol, the
+// JSON-RPC over the standard streams, as a token: the header fields, of
+// which the Content-Length is required, terminated by an empty line,
+// followed by the content of that length. The header without the
+// Content-Length, the bad Content-Length and the header longer than 64KiB
+// stop the scan with the ErrProtocolViolation.
+func ScanLSP(data []byte, atEOF bool) (int, int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, 0, nil, nil
+	}
+	end, err := headerEnd(data)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if end < 0 {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return 1, 0, nil, nil
+	}
+	v, ok := fieldValue(data[:end], "Content-Length")
+	if !ok {
+		return 0, 0, nil, fmt.Errorf("%w: LSP header without Content-Length", ErrProtocolViolation)
+	}
+	length, err := lengthValue(v, "Content-Length")
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	n := end + length
+	if len(data) < n {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return n - len(data), 0, nil, nil
+	}
+	return 0, n, data[end:n], nil
+}
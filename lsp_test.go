@@ -0,0 +1,35 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanLSP(t *testing.T) {
+	initialize := `{"jsonrpc":"2.0","id":1,"method":"initialize"}`
+	protoscantest.TestSplitFunc(t, protoscan.ScanLSP, []protoscantest.Case{
+		{Name: "empty", Input: "", Tokens: nil},
+		{
+			Name: "messages",
+			Input: "Content-Length: 46\r\n\r\n" + initialize +
+				"Content-Type: application/vscode-jsonrpc; charset=utf-8\r\ncontent-length: 2\r\n\r\n{}",
+			Tokens: []string{initialize, "{}"},
+		},
+		{Name: "short header", Input: "Content-Length: 2\r\n", Err: io.ErrUnexpectedEOF},
+		{Name: "short content", Input: "Content-Length: 2\r\n\r\n{", Err: io.ErrUnexpectedEOF},
+		{Name: "no length", Input: "Content-Type: x\r\n\r\n{}", Err: protoscan.ErrProtocolViolation},
+		{Name: "bad length", Input: "Content-Length: x\r\n\r\n", Err: protoscan.ErrProtocolViolation},
+	})
+}
+
+func FuzzScanLSP(f *testing.F) {
+	f.Add([]byte("Content-Length: 2\r\n\r\n{}Content-Length: 0\r\n\r\n"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanLSP))
+}
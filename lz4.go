@@ -0,0 +1,228 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// lz4Decompress appends to the dst the n bytes decompressed from the LZ4
+// block.
+func lz4Decompress(dst, src []byte, n int) ([]byte, error) {
+	start := len(dst)
+	dst, err := lz4Block(dst, src, 0, n)
+	if err == nil && len(dst)-start != n {
+		err = errLZ4Corrupt
+	}
+	return dst, err
+}
+
+// errLZ4Corrupt reports the corrupt LZ4 data.
+var errLZ4Corrupt = fmt.Errorf("%w: corrupt LZ4 block", ErrProtocolViolation)
+
+// lz4Block appends to the dst up to max bytes decompressed from the LZ4
+// block, which may refer to the hist bytes of the dst before the block.
+func lz4Block(dst, src []byte, hist, max int) ([]byte, error) {
+	start := len(dst)
+	for i := 0; i < len(src); {
+		token := src[i]
+		i++
+		lit := int(token >> 4)
+		if lit == 15 {
+			for {
+				if i == len(src) {
+					return dst, errLZ4Corrupt
+				}
+				b := src[i]
+				i++
+				lit += int(b)
+				if b != 255 {
+					break
+				}
+			}
+		}
+		if lit > len(src)-i || len(dst)-start+lit > max {
+			return dst, errLZ4Corrupt
+		}
+		dst = append(dst, src[i:i+lit]...)
+		i += lit
+		if i == len(src) {
+			break
+		}
+		if i+2 > len(src) {
+			return dst, errLZ4Corrupt
+		}
+		offset := int(src[i]) | int(src[i+1])<<8
+		i += 2
+		match := int(token & 15)
+		if match == 15 {
+			for {
+				if i == len(src) {
+					return dst, errLZ4Corrupt
+				}
+				b := src[i]
+				i++
+				match += int(b)
+				if b != 255 {
+					break
+				}
+			}
+		}
+		match += 4
+		if offset == 0 || offset > len(dst)-start+hist || len(dst)-start+match > max {
+			return dst, errLZ4Corrupt
+		}
+		for k := len(dst) - offset; match > 0; match-- {
+			dst = append(dst, dst[k])
+			k++
+		}
+	}
+	return dst, nil
+}
+
+// Magic numbers of the LZ4 frames.
+const (
+	lz4FrameMagic     = 0x184d2204
+	lz4SkippableMagic = 0x184d2a50 // The last 4 bits are user defined.
+)
+
+// lz4History is the size of the window of the dependent LZ4 blocks.
+const lz4History = 64 << 10
+
+type lz4Codec struct{}
+
+func (lz4Codec) Detect(header []byte) bool {
+	return len(header) >= 4 && binary.LittleEndian.Uint32(header) == lz4FrameMagic
+}
+
+func (lz4Codec) NewReader(r io.Reader) (io.Reader, error) {
+	return &lz4FrameReader{r: r}, nil
+}
+
+// lz4FrameReader reads the data decompressed from the LZ4 frames. The
+// checksums of the frames are not verified.
+type lz4FrameReader struct {
+	r       io.Reader
+	frames  int    // Count of the frames started.
+	inFrame bool   // Whether the blocks of a frame are read.
+	max     int    // Maximum size of the blocks of the frame.
+	indep   bool   // Whether the blocks of the frame are independent.
+	sums    byte   // Flags of the checksums of the frame.
+	block   []byte // The compressed block.
+	out     []byte // The history of the dependent blocks and the last block.
+	buf     []byte // The decompressed data not yet read.
+	err     error
+}
+
+func (z *lz4FrameReader) Read(p []byte) (int, error) {
+	for len(z.buf) == 0 {
+		if z.err != nil {
+			return 0, z.err
+		}
+		z.err = z.next()
+	}
+	n := copy(p, z.buf)
+	z.buf = z.buf[n:]
+	return n, nil
+}
+
+// next reads the next block, or the header of the next frame.
+func (z *lz4FrameReader) next() error {
+	var b [9]byte // Fits the content size with the header checksum.
+	if !z.inFrame {
+		if err := z.read(b[:4], z.frames > 0); err != nil {
+			return err
+		}
+		z.frames++
+		magic := binary.LittleEndian.Uint32(b[:])
+		if magic&^0xf == lz4SkippableMagic {
+			if err := z.read(b[:4], false); err != nil {
+				return err
+			}
+			_, err := io.CopyN(io.Discard, z.r, int64(binary.LittleEndian.Uint32(b[:])))
+			return unexpectedEOF(err)
+		}
+		if magic != lz4FrameMagic {
+			return fmt.Errorf("%w: bad LZ4 frame magic %#x", ErrProtocolViolation, magic)
+		}
+		if err := z.read(b[:2], false); err != nil {
+			return err
+		}
+		flg, bd := b[0], b[1]
+		if flg>>6 != 1 || flg&0x03 != 0 || bd&0x8f != 0 || bd>>4 < 4 {
+			return fmt.Errorf("%w: unsupported LZ4 frame descriptor %#x %#x", ErrProtocolViolation, flg, bd)
+		}
+		z.max = 1 << (8 + 2*(bd>>4))
+		z.indep = flg&0x20 != 0
+		z.sums = flg & 0x14
+		z.out = z.out[:0]
+		// Skip the content size and the header checksum.
+		n := 1
+		if flg&0x08 != 0 {
+			n += 8
+		}
+		if err := z.read(b[:n], false); err != nil {
+			return err
+		}
+		z.inFrame = true
+	}
+	if err := z.read(b[:4], false); err != nil {
+		return err
+	}
+	size := binary.LittleEndian.Uint32(b[:])
+	if size == 0 {
+		// The end mark is followed by the content checksum.
+		z.inFrame = false
+		if z.sums&0x04 != 0 {
+			return z.read(b[:4], false)
+		}
+		return nil
+	}
+	raw := size&0x80000000 != 0
+	size &^= 0x80000000
+	if int(size) > z.max {
+		return fmt.Errorf("%w: LZ4 block of %d bytes exceeds maximum of %d", ErrProtocolViolation, size, z.max)
+	}
+	if cap(z.block) < int(size) {
+		z.block = make([]byte, size)
+	}
+	z.block = z.block[:size]
+	if err := z.read(z.block, false); err != nil {
+		return err
+	}
+	if z.sums&0x10 != 0 {
+		if err := z.read(b[:4], false); err != nil {
+			return err
+		}
+	}
+	if z.indep {
+		z.out = z.out[:0]
+	} else if len(z.out) > lz4History {
+		z.out = z.out[:copy(z.out, z.out[len(z.out)-lz4History:])]
+	}
+	hist := len(z.out)
+	if raw {
+		z.out = append(z.out, z.block...)
+	} else {
+		var err error
+		if z.out, err = lz4Block(z.out, z.block, hist, z.max); err != nil {
+			return err
+		}
+	}
+	z.buf = z.out[hist:]
+	return nil
+}
+
+// read reads exactly len(b) bytes, the EOF before the first byte is
+// expected only if eof.
+func (z *lz4FrameReader) read(b []byte, eof bool) error {
+	_, err := io.ReadFull(z.r, b)
+	if err == io.EOF && eof {
+		return err
+	}
+	return unexpectedEOF(err)
+}
@@ -0,0 +1,104 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrRewind is returned by the Rewind when the position is not buffered.
+var ErrRewind = errors.New("protoscan: rewind to unbuffered position")
+
+// Mark is a position of the carriage in the data stream, with the counters
+// of the Protoscan at the position. The marks of the reads after the
+// position are kept while it is marked, so they need not be saved.
+type Mark struct {
+	offset  int64
+	lines   int
+	line    int
+	stats   Stats
+	dropped int
+}
+
+// Offset returns the offset of the position in the data stream.
+func (m Mark) Offset() int64 {
+	return m.offset
+}
+
+// Mark returns the current position of the carriage, that is just past the
+// last token, and keeps the data from the position in the buffer until the
+// Unmark or the next Mark is called, so that the Protoscan may be rewound to
+// the position. The kept data count toward the maximum size of the buffer.
+func (s *Protoscan) Mark() Mark {
+	s.mark = Mark{
+		offset:  s.offset + int64(s.start),
+		lines:   s.lines,
+		line:    s.line,
+		stats:   s.Stats(),
+		dropped: s.dropped,
+	}
+	s.marked = true
+	return s.mark
+}
+
+// Unmark lets the Protoscan discard the data kept by the Mark.
+func (s *Protoscan) Unmark() {
+	s.marked = false
+}
+
+// Rewind moves the carriage back to the position, so that the tokens after
+// the position are delivered by Scan again, and restores the line numbers,
+// the Stats and the count of the dropped tokens. The position must be buffered,
+// that is not older than the last Mark. The scan stopped with an error other
+// than io.EOF cannot be rewound.
+func (s *Protoscan) Rewind(m Mark) error {
+	if s.err != nil && s.err != io.EOF && s.err != FinalToken {
+		return s.err
+	}
+	if m.offset < s.offset || m.offset > s.offset+int64(s.start) {
+		return ErrRewind
+	}
+	if s.err == FinalToken {
+		s.err = nil
+	}
+	s.start = int(m.offset - s.offset)
+	s.lines, s.line = m.lines, m.line
+	s.stats = m.stats
+	s.stats.Histogram = nil
+	copy(s.histogram, m.stats.Histogram)
+	s.dropped = m.dropped
+	s.token = nil
+	s.remainder, s.stopped = nil, false
+	return nil
+}
+
+// ErrNoPeek is returned by the Rollback outside of the transaction.
+var ErrNoPeek = errors.New("protoscan: rollback without BeginPeek")
+
+// BeginPeek starts the transaction: the tokens scanned until the Commit are
+// not consumed and the Rollback makes them delivered by Scan again. The
+// transaction uses the mark of the Mark, so the two do not mix.
+func (s *Protoscan) BeginPeek() {
+	s.Mark()
+	s.peeking = true
+}
+
+// Commit consumes the tokens scanned in the transaction.
+func (s *Protoscan) Commit() {
+	s.peeking = false
+	s.Unmark()
+}
+
+// Rollback ends the transaction moving the carriage back to its start.
+func (s *Protoscan) Rollback() error {
+	if !s.peeking {
+		return ErrNoPeek
+	}
+	err := s.Rewind(s.mark)
+	s.peeking = false
+	s.Unmark()
+	return err
+}
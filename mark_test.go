@@ -0,0 +1,126 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestMarkRewind(t *testing.T) {
+	text := strings.Repeat("abcdefg\n", 100)
+	s := protoscan.New(
+		&protoscantest.SlowReader{Max: 3, R: strings.NewReader(text)},
+		protoscan.WithSplit(protoscan.ScanLines),
+		protoscan.WithLineCount('\n'),
+		protoscan.WithBuffer(make([]byte, 0, 16)),
+	)
+	if !s.Scan() {
+		t.Fatal(s.Err())
+	}
+	m := s.Mark()
+	if m.Offset() != 8 {
+		t.Fatalf("expected mark at 8; got %d", m.Offset())
+	}
+	for i := 0; i < 10; i++ {
+		if !s.Scan() {
+			t.Fatal(s.Err())
+		}
+	}
+	if err := s.Rewind(m); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	for ; s.Scan(); n++ {
+		if string(s.Token()) != "abcdefg" {
+			t.Fatalf("unexpected token %q", s.Token())
+		}
+		if n == 0 && (s.Line() != 2 || s.TokenInfo().Start != 8) {
+			t.Fatalf("unexpected token at line %d, offset %d", s.Line(), s.TokenInfo().Start)
+		}
+		if n == 20 {
+			s.Unmark()
+		}
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	if n != 99 {
+		t.Fatalf("expected 99 tokens after rewind; got %d", n)
+	}
+	if err := s.Rewind(m); !errors.Is(err, protoscan.ErrRewind) {
+		t.Fatalf("expected ErrRewind; got %v", err)
+	}
+}
+
+// Test that the Rewind restores the counters and the reads of the tokens
+// after the mark.
+func TestRewindCounters(t *testing.T) {
+	s := protoscan.New(
+		&protoscantest.SlowReader{Max: 3, R: strings.NewReader("a\nxx\nbc\nd\n")},
+		protoscan.WithSplit(protoscan.ScanLines),
+		protoscan.WithLineCount('\n'),
+		protoscan.WithDrop(func(token []byte) bool { return string(token) == "xx" }),
+		protoscan.WithHistogram(1),
+	)
+	state := func() string {
+		return fmt.Sprintf("%q line %d, %d dropped, %+v, time %t", s.Token(), s.Line(), s.Dropped(), s.Stats(), !s.TokenInfo().Time.IsZero())
+	}
+	if !s.Scan() {
+		t.Fatal(s.Err())
+	}
+	m := s.Mark()
+	var want []string
+	for s.Scan() {
+		want = append(want, state())
+	}
+	if err := s.Rewind(m); err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprintf("line %d, %d dropped, %+v", s.Line(), s.Dropped(), s.Stats()); got != "line 1, 0 dropped, {Tokens:1 Bytes:1 Histogram:[{Max:1 Count:1} {Max:9223372036854775807 Count:0}] Retries:0}" {
+		t.Fatalf("unexpected counters after rewind: %s", got)
+	}
+	var got []string
+	for s.Scan() {
+		got = append(got, state())
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected state after rewind\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestPeek(t *testing.T) {
+	s := protoscan.New(strings.NewReader("a\nb\nc\nd\n"), protoscan.WithSplit(protoscan.ScanLines))
+	scan := func(n int) string {
+		var tokens []string
+		for i := 0; i < n && s.Scan(); i++ {
+			tokens = append(tokens, string(s.Token()))
+		}
+		return strings.Join(tokens, "")
+	}
+	if err := s.Rollback(); !errors.Is(err, protoscan.ErrNoPeek) {
+		t.Fatalf("expected ErrNoPeek; got %v", err)
+	}
+	s.BeginPeek()
+	if got := scan(2); got != "ab" {
+		t.Fatalf("unexpected tokens %q", got)
+	}
+	if err := s.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	s.BeginPeek()
+	if got := scan(3); got != "abc" {
+		t.Fatalf("unexpected tokens %q", got)
+	}
+	s.Commit()
+	if got := scan(10); got != "d" {
+		t.Fatalf("unexpected tokens %q", got)
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	mongoHeader     = 16       // Length of the message header.
+	mongoMaxMessage = 48 << 20 // Maximum length of the message.
+)
+
+// ScanMongoWire is a split function for the Protoscan with the
+// WithSplitContext option which returns each message of the MongoDB wire
+// protocol as a token, delimited by the messageLength of its 16-byte
+// header. The Indexes of the token are its opCode, requestID and
+// responseTo. The length shorter than the header or longer than 48 MiB
+// stops the scan with the ErrProtocolViolation.
+func ScanMongoWire(ctx *SplitContext, data []byte, atEOF bool) (int, int, []byte, error) {
+	if len(data) == 0 && atEOF {
+		return 0, 0, nil, nil
+	}
+	if len(data) < mongoHeader {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return mongoHeader - len(data), 0, nil, nil
+	}
+	n := int32(binary.LittleEndian.Uint32(data))
+	if n < mongoHeader || n > mongoMaxMessage {
+		return 0, 0, nil, fmt.Errorf("%w: MongoDB message length %d", ErrProtocolViolation, n)
+	}
+	if int(n) > len(data) {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return int(n) - len(data), 0, nil, nil
+	}
+	ctx.Indexes = append(ctx.Indexes,
+		int(int32(binary.LittleEndian.Uint32(data[12:]))),
+		int(int32(binary.LittleEndian.Uint32(data[4:]))),
+		int(int32(binary.LittleEndian.Uint32(data[8:]))),
+	)
+	return 0, int(n), data[:n], nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+// mongoMsg returns the message of the opCode, the IDs and the body.
+func mongoMsg(opCode, requestID, responseTo int32, body string) string {
+	b := binary.LittleEndian.AppendUint32(nil, uint32(16+len(body)))
+	b = binary.LittleEndian.AppendUint32(b, uint32(requestID))
+	b = binary.LittleEndian.AppendUint32(b, uint32(responseTo))
+	b = binary.LittleEndian.AppendUint32(b, uint32(opCode))
+	return string(b) + body
+}
+
+func TestScanMongoWire(t *testing.T) {
+	hello := mongoMsg(2013, 1, 0, "\x00\x00\x00\x00\x00\x13\x00\x00\x00\x10hello\x00\x01\x00\x00\x00\x00")
+	reply := mongoMsg(2013, 7, 1, "\x00\x00\x00\x00\x00\x05\x00\x00\x00\x00")
+	compressed := mongoMsg(2012, -2, 0, "")
+	stream := hello + reply + compressed
+	want := []string{"2013 1 0", "2013 7 1", "2012 -2 0"}
+	for _, f := range protoscantest.Fragmentations() {
+		s := protoscan.New(f.Reader([]byte(stream)), protoscan.WithSplitContext(protoscan.ScanMongoWire))
+		var got []string
+		var all string
+		for s.Scan() {
+			ix := s.Indexes()
+			got = append(got, fmt.Sprintf("%d %d %d", ix[0], ix[1], ix[2]))
+			all += string(s.Token())
+		}
+		if s.Err() != nil {
+			t.Fatalf("%s: %v", f.Name, s.Err())
+		}
+		if strings.Join(got, "|") != strings.Join(want, "|") {
+			t.Fatalf("%s: expected headers %q; got %q", f.Name, want, got)
+		}
+		if all != stream {
+			t.Fatalf("%s: tokens do not cover the stream", f.Name)
+		}
+	}
+}
+
+func TestScanMongoWireErrors(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		stream string
+		err    error
+	}{
+		{"short header", "\x20\x00\x00\x00\x01", io.ErrUnexpectedEOF},
+		{"short body", mongoMsg(2013, 1, 0, "body")[:18], io.ErrUnexpectedEOF},
+		{"short length", "\x0f\x00\x00\x00" + strings.Repeat("\x00", 12), protoscan.ErrProtocolViolation},
+		{"negative length", "\xff\xff\xff\xff" + strings.Repeat("\x00", 12), protoscan.ErrProtocolViolation},
+		{"long length", "\x00\x00\x00\x04" + strings.Repeat("\x00", 12), protoscan.ErrProtocolViolation},
+	} {
+		s := protoscan.New(strings.NewReader(test.stream), protoscan.WithSplitContext(protoscan.ScanMongoWire))
+		for s.Scan() {
+		}
+		if !errors.Is(s.Err(), test.err) {
+			t.Errorf("%s: expected error %v; got %v", test.name, test.err, s.Err())
+		}
+	}
+}
+
+func FuzzScanMongoWire(f *testing.F) {
+	protoscantest.AddCorpus(f, protoscantest.MongoCorpus())
+	f.Fuzz(protoscantest.FuzzContext(protoscan.ScanMongoWire))
+}
@@ -0,0 +1,54 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"fmt"
+	"io"
+)
+
+// mqttMaxLength is the count of the bytes of the Remaining Length at most.
+const mqttMaxLength = 4
+
+// ScanMQTT is a split function for a Protoscan that returns each MQTT
+// control packet as a token, the fixed header followed by the variable
+// header and the payload of its Remaining Length, encoded in up to 4
+// bytes of 7 bits. The reserved packet type 0 and the Remaining Length
+// longer than 4 bytes stop the scan with the ErrProtocolViolation.
+func ScanMQTT(data []byte, atEOF bool) (int, int, []byte, error) {
+	if len(data) == 0 {
+		if atEOF {
+			return 0, 0, nil, nil
+		}
+		return 1, 0, nil, nil
+	}
+	if data[0]>>4 == 0 {
+		return 0, 0, nil, fmt.Errorf("%w: reserved MQTT packet type 0", ErrProtocolViolation)
+	}
+	length := 0
+	for i := 1; ; i++ {
+		if i > mqttMaxLength {
+			return 0, 0, nil, fmt.Errorf("%w: MQTT Remaining Length longer than %d bytes", ErrProtocolViolation, mqttMaxLength)
+		}
+		if i == len(data) {
+			if atEOF {
+				return 0, 0, nil, io.ErrUnexpectedEOF
+			}
+			return 1, 0, nil, nil
+		}
+		b := data[i]
+		length |= int(b&0x7f) << (7 * (i - 1))
+		if b&0x80 == 0 {
+			n := i + 1 + length
+			if len(data) < n {
+				if atEOF {
+					return 0, 0, nil, io.ErrUnexpectedEOF
+				}
+				return n - len(data), 0, nil, nil
+			}
+			return 0, n, data[:n], nil
+		}
+	}
+}
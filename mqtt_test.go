@@ -0,0 +1,36 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanMQTT(t *testing.T) {
+	connect := "\x10\x10\x00\x04MQTT\x04\x02\x00\x3c\x00\x04test"
+	publish := "\x30\x82\x01\x00\x03a/b" + strings.Repeat("x", 125)
+	protoscantest.TestSplitFunc(t, protoscan.ScanMQTT, []protoscantest.Case{
+		{Name: "empty", Input: "", Tokens: nil},
+		{
+			Name:   "packets",
+			Input:  connect + publish + "\xc0\x00" + "\xe0\x00",
+			Tokens: []string{connect, publish, "\xc0\x00", "\xe0\x00"},
+		},
+		{Name: "short length", Input: "\x30\x82", Err: io.ErrUnexpectedEOF},
+		{Name: "short packet", Input: "\x30\x03\x00\x01", Err: io.ErrUnexpectedEOF},
+		{Name: "reserved type", Input: "\x00\x00", Err: protoscan.ErrProtocolViolation},
+		{Name: "long length", Input: "\x30\xff\xff\xff\xff\x01", Err: protoscan.ErrProtocolViolation},
+	})
+}
+
+func FuzzScanMQTT(f *testing.F) {
+	f.Add([]byte("\x30\x05\x00\x01abc\xc0\x00"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanMQTT))
+}
@@ -0,0 +1,91 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// NewMulti returns a new Protoscan reading the tokens from the sequence of
+// the readers as from one data stream. The onBoundary, if not nil, is
+// called with the index of the next reader when the reading switches to it.
+//
+// If the WithBoundaryFlush option is set, the split function sees the end
+// of each reader as EOF, so that no token spans two readers, and the
+// onBoundary is called after the last token of the previous reader.
+// The flush is not supported together with the WithTransform and
+// WithDecompression options.
+func NewMulti(readers []io.Reader, onBoundary func(i int), opts ...Option) *Protoscan {
+	r := &multiReader{readers: readers, onBoundary: onBoundary}
+	s := New(r, opts...)
+	r.flush = s.boundaryFlush
+	if r.flush {
+		s.boundary = r.next
+	}
+	return s
+}
+
+// WithBoundaryFlush makes the Protoscan created by the NewMulti flush the
+// tokens at the end of each reader.
+func WithBoundaryFlush() Option {
+	return func(s *Protoscan) { s.boundaryFlush = true }
+}
+
+// errBoundary is the soft EOF reported at the end of the reader of the
+// multiReader which is not the last one.
+var errBoundary = errors.New("protoscan: reader boundary")
+
+// multiReader reads the readers sequentially.
+type multiReader struct {
+	readers    []io.Reader
+	i          int // Index of the current reader.
+	onBoundary func(i int)
+	flush      bool // Whether the end of the reader is reported as errBoundary.
+}
+
+func (r *multiReader) Read(p []byte) (int, error) {
+	n, _, err := r.read(p)
+	return n, err
+}
+
+// read reads from the current reader, with the timestamp if it is a
+// TimestampedReader.
+func (r *multiReader) read(p []byte) (int, time.Time, error) {
+	for r.i < len(r.readers) {
+		var n int
+		var ts time.Time
+		var err error
+		if tr, ok := r.readers[r.i].(TimestampedReader); ok {
+			n, ts, err = tr.ReadTimestamped(p)
+		} else {
+			n, err = r.readers[r.i].Read(p)
+		}
+		if err != io.EOF {
+			return n, ts, err
+		}
+		if r.i == len(r.readers)-1 {
+			r.i++
+			return n, ts, io.EOF
+		}
+		if r.flush {
+			return n, ts, errBoundary
+		}
+		r.next()
+		if n > 0 {
+			return n, ts, nil
+		}
+	}
+	return 0, time.Time{}, io.EOF
+}
+
+// next switches to the next reader.
+func (r *multiReader) next() {
+	r.i++
+	if r.onBoundary != nil {
+		r.onBoundary(r.i)
+	}
+}
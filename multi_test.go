@@ -0,0 +1,51 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestMulti(t *testing.T) {
+	for _, test := range []struct {
+		flush bool
+		want  string
+	}{
+		{flush: false, want: "ab|<1>|cdef|<3>|gh"},
+		{flush: true, want: "ab|cd|<1>|ef|<3>|gh"},
+	} {
+		readers := []io.Reader{
+			&protoscantest.SlowReader{Max: 2, R: strings.NewReader("ab\ncd")},
+			strings.NewReader("ef\n"),
+			strings.NewReader(""),
+			strings.NewReader("gh"),
+		}
+		var events []string
+		opts := []protoscan.Option{protoscan.WithSplit(protoscan.ScanLines)}
+		if test.flush {
+			opts = append(opts, protoscan.WithBoundaryFlush())
+		}
+		s := protoscan.NewMulti(readers, func(i int) {
+			if i != 2 {
+				events = append(events, fmt.Sprintf("<%d>", i))
+			}
+		}, opts...)
+		for s.Scan() {
+			events = append(events, string(s.Token()))
+		}
+		if s.Err() != nil {
+			t.Fatal(s.Err())
+		}
+		if got := strings.Join(events, "|"); got != test.want {
+			t.Errorf("flush %v: expected %q; got %q", test.flush, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+// maxBoundary is the maximum length of the multipart boundary, RFC 2046.
+const maxBoundary = 70
+
+// errBadBoundary is returned by the split function of the ScanMultipart
+// of the empty or too long boundary.
+var errBadBoundary = errors.New("protoscan: bad multipart boundary")
+
+// Kinds of the lines starting by the dash-boundary.
+const (
+	multipartShort = iota // The line is incomplete.
+	multipartOther        // The line is not a delimiter.
+	multipartDelimiter
+	multipartClose
+)
+
+// multipartLine returns the kind of the line starting by the dash-boundary
+// ending at the k.
+func multipartLine(data []byte, k int) int {
+	if k >= len(data) {
+		return multipartShort
+	}
+	switch data[k] {
+	case ' ', '\t', '\r', '\n':
+		return multipartDelimiter
+	case '-':
+		if k+1 == len(data) {
+			return multipartShort
+		}
+		if data[k+1] == '-' {
+			return multipartClose
+		}
+	}
+	return multipartOther
+}
+
+// multipartNext returns the offset of the newline preceding the next
+// delimiter line after the from, or -1 if the data end before it, and the
+// kind of the delimiter.
+func multipartNext(data, dash []byte, from int) (int, int) {
+	for i := from; ; {
+		j := bytes.Index(data[i:], dash)
+		if j < 0 {
+			return -1, multipartShort
+		}
+		j += i
+		if j > from && data[j-1] == '\n' {
+			switch kind := multipartLine(data, j+len(dash)); kind {
+			case multipartShort:
+				return -1, kind
+			case multipartDelimiter, multipartClose:
+				return j - 1, kind
+			}
+		}
+		i = j + 1
+	}
+}
+
+// ScanMultipart returns a split function for a Protoscan that returns
+// each part of the MIME multipart body of the boundary as a token, its
+// header fields and its body, without the CRLF or the LF preceding the
+// next delimiter line. The preamble and the delimiter lines are skipped,
+// and the last part is delivered together with the FinalToken at the
+// close delimiter, leaving the epilogue unread. The empty boundary or the
+// boundary longer than 70 bytes stops the scan with an error.
+func ScanMultipart(boundary string) SplitFunc {
+	dash := []byte("--" + boundary)
+	return func(data []byte, atEOF bool) (int, int, []byte, error) {
+		if boundary == "" || len(boundary) > maxBoundary {
+			return 0, 0, nil, errBadBoundary
+		}
+		kind := multipartOther
+		if bytes.HasPrefix(data, dash) {
+			kind = multipartLine(data, len(dash))
+		} else if bytes.HasPrefix(dash, data) {
+			kind = multipartShort
+		}
+		// The preamble is skipped up to the first delimiter line.
+		skip := 0
+		if kind == multipartOther {
+			next, _ := multipartNext(data, dash, 0)
+			if next < 0 {
+				kind = multipartShort
+			} else {
+				skip = next + 1
+				kind = multipartLine(data, skip+len(dash))
+			}
+		}
+		switch kind {
+		case multipartShort:
+			if atEOF {
+				return 0, 0, nil, io.ErrUnexpectedEOF
+			}
+			return 1, 0, nil, nil
+		case multipartClose:
+			return 0, skip + len(dash) + 2, nil, FinalToken
+		}
+		data = data[skip:]
+		start := 0
+		line := bytes.IndexByte(data[len(dash):], '\n')
+		if line >= 0 {
+			start = len(dash) + line + 1
+		}
+		next, kind := multipartNext(data, dash, start)
+		if line < 0 || next < 0 {
+			if atEOF {
+				return 0, 0, nil, io.ErrUnexpectedEOF
+			}
+			return 1, 0, nil, nil
+		}
+		token := dropCR(data[start:next])
+		if kind == multipartClose {
+			return 0, skip + next + 1 + len(dash) + 2, token, FinalToken
+		}
+		return 0, skip + next + 1, token, nil
+	}
+}
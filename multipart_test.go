@@ -0,0 +1,52 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanMultipart(t *testing.T) {
+	text := "Content-Type: text/plain\r\n\r\nhello\r\n--xyz-not-a-delimiter"
+	file := "Content-Disposition: form-data; name=\"f\"\r\n\r\n\x00\r\n\x01"
+	protoscantest.TestSplitFunc(t, protoscan.ScanMultipart("xyz"), []protoscantest.Case{
+		{Name: "empty", Input: "", Err: io.ErrUnexpectedEOF},
+		{
+			Name:   "parts",
+			Input:  "preamble\r\n--xyz\r\n" + text + "\r\n--xyz \t\r\n" + file + "\r\n--xyz--\r\nepilogue",
+			Tokens: []string{text, file},
+		},
+		{
+			Name:   "LF",
+			Input:  "--xyz\nA: b\n\nc\n--xyz\n\nd\n--xyz--",
+			Tokens: []string{"A: b\n\nc", "\nd"},
+		},
+		{Name: "empty part", Input: "--xyz\r\n\r\n--xyz--", Tokens: []string{""}},
+		{Name: "no parts", Input: "--xyz--\r\n", Tokens: []string{""}},
+		{Name: "short preamble", Input: "preamble\r\n--xy", Err: io.ErrUnexpectedEOF},
+		{Name: "short part", Input: "--xyz\r\n\r\nhello", Err: io.ErrUnexpectedEOF},
+		{
+			Name:   "no close delimiter",
+			Input:  "--xyz\r\n\r\nhello\r\n--xyz\r\n",
+			Tokens: []string{"\r\nhello"},
+			Err:    io.ErrUnexpectedEOF,
+		},
+	})
+}
+
+func TestScanMultipartBoundary(t *testing.T) {
+	protoscantest.TestSplitFunc(t, protoscan.ScanMultipart(""), []protoscantest.Case{
+		{Name: "empty boundary", Input: "--\r\n", Err: protoscan.ErrBadBoundary},
+	})
+}
+
+func FuzzScanMultipart(f *testing.F) {
+	f.Add([]byte("x\r\n--b\r\nA: b\r\n\r\nc\r\n--b--\r\n"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanMultipart("b")))
+}
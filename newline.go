@@ -0,0 +1,56 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+// NewlineForm is the canonical form of the line endings.
+type NewlineForm int
+
+// Forms of the line endings.
+const (
+	LF   NewlineForm = iota // Unix line endings, "\n".
+	CRLF                    // Windows line endings, "\r\n".
+)
+
+// NormalizeNewlines returns the Transformer which replaces the line endings
+// "\r\n", "\r" and "\n" with the form.
+func NormalizeNewlines(form NewlineForm) Transformer {
+	if form == CRLF {
+		return newlineTransformer("\r\n")
+	}
+	return newlineTransformer("\n")
+}
+
+type newlineTransformer string
+
+func (t newlineTransformer) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for nSrc < len(src) {
+		c, width := src[nSrc], 1
+		switch c {
+		case '\r':
+			if nSrc+1 == len(src) && !atEOF {
+				return nDst, nSrc, ErrShortSrc
+			}
+			if nSrc+1 < len(src) && src[nSrc+1] == '\n' {
+				width = 2
+			}
+			fallthrough
+		case '\n':
+			if nDst+len(t) > len(dst) {
+				return nDst, nSrc, ErrShortDst
+			}
+			nDst += copy(dst[nDst:], t)
+		default:
+			if nDst == len(dst) {
+				return nDst, nSrc, ErrShortDst
+			}
+			dst[nDst] = c
+			nDst++
+		}
+		nSrc += width
+	}
+	return nDst, nSrc, nil
+}
+
+func (newlineTransformer) Reset() {}
@@ -0,0 +1,42 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestNormalizeNewlines(t *testing.T) {
+	const text = "a\r\nb\rc\nd\r\r\ne\r"
+	for _, test := range []struct {
+		form protoscan.NewlineForm
+		want string
+	}{
+		{form: protoscan.LF, want: "a\nb\nc\nd\n\ne\n"},
+		{form: protoscan.CRLF, want: "a\r\nb\r\nc\r\nd\r\n\r\ne\r\n"},
+	} {
+		for _, f := range protoscantest.Fragmentations() {
+			s := protoscan.New(
+				f.Reader([]byte(text)),
+				protoscan.WithSplit(protoscan.ScanRawLines),
+				protoscan.WithTransform(protoscan.NormalizeNewlines(test.form)),
+			)
+			var b strings.Builder
+			for s.Scan() {
+				b.Write(s.Token())
+			}
+			if s.Err() != nil {
+				t.Fatal(s.Err())
+			}
+			if b.String() != test.want {
+				t.Errorf("%s: form %d: expected %q; got %q", f.Name, test.form, test.want, b.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	ninePHeader   = 7   // Size, type and tag.
+	ninePTversion = 100 // Type of the Tversion message.
+	ninePRversion = 101 // Type of the Rversion message.
+)
+
+// ninePMsizeKey is the key of the maximum message size negotiated by the
+// Tversion and the Rversion in the SplitContext.
+type ninePMsizeKey struct{}
+
+// Scan9P is a split function for the Protoscan with the WithSplitContext
+// option which returns each message of the 9P2000 protocol as a token,
+// delimited by its 4-byte little-endian size, which includes the size
+// itself. The Indexes of the token are its type and tag. The maximum
+// message size of the Tversion or the Rversion message is kept in the
+// SplitContext; the messages larger than it, or shorter than the header,
+// stop the scan with the ErrProtocolViolation.
+func Scan9P(ctx *SplitContext, data []byte, atEOF bool) (int, int, []byte, error) {
+	if len(data) == 0 && atEOF {
+		return 0, 0, nil, nil
+	}
+	if len(data) < ninePHeader {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return ninePHeader - len(data), 0, nil, nil
+	}
+	size := binary.LittleEndian.Uint32(data)
+	msize, ok := ctx.Value(ninePMsizeKey{}).(uint32)
+	if size < ninePHeader || ok && size > msize || uint64(size) > math.MaxInt {
+		return 0, 0, nil, fmt.Errorf("%w: 9P message size %d", ErrProtocolViolation, size)
+	}
+	n := int(size)
+	if n > len(data) {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return n - len(data), 0, nil, nil
+	}
+	typ := data[4]
+	if (typ == ninePTversion || typ == ninePRversion) && n >= ninePHeader+4 {
+		if msize := binary.LittleEndian.Uint32(data[ninePHeader:]); msize >= ninePHeader {
+			ctx.SetValue(ninePMsizeKey{}, msize)
+		}
+	}
+	ctx.Indexes = append(ctx.Indexes, int(typ), int(binary.LittleEndian.Uint16(data[5:])))
+	return 0, n, data[:n], nil
+}
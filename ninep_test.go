@@ -0,0 +1,74 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+// ninePMsg returns the 9P message of the type, the tag and the body.
+func ninePMsg(typ byte, tag uint16, body string) string {
+	b := binary.LittleEndian.AppendUint32(nil, uint32(7+len(body)))
+	b = append(b, typ)
+	b = binary.LittleEndian.AppendUint16(b, tag)
+	return string(b) + body
+}
+
+func scan9P(r io.Reader) ([]string, error) {
+	s := protoscan.New(r, protoscan.WithSplitContext(protoscan.Scan9P))
+	var got []string
+	for s.Scan() {
+		got = append(got, fmt.Sprintf("%d %d %d", s.Indexes()[0], s.Indexes()[1], len(s.Token())))
+	}
+	return got, s.Err()
+}
+
+func TestScan9P(t *testing.T) {
+	stream := ninePMsg(100, 0xffff, "\x00\x20\x00\x00\x06\x009P2000") +
+		ninePMsg(101, 0xffff, "\x00\x20\x00\x00\x06\x009P2000") +
+		ninePMsg(116, 1, "\x01\x00\x00\x00"+strings.Repeat("\x00", 12)) +
+		ninePMsg(117, 1, "\x03\x00\x00\x00abc")
+	want := []string{"100 65535 19", "101 65535 19", "116 1 23", "117 1 14"}
+	for _, f := range protoscantest.Fragmentations() {
+		got, err := scan9P(f.Reader([]byte(stream)))
+		if err != nil {
+			t.Fatalf("%s: %v", f.Name, err)
+		}
+		if strings.Join(got, "|") != strings.Join(want, "|") {
+			t.Fatalf("%s: expected messages %q; got %q", f.Name, want, got)
+		}
+	}
+}
+
+func TestScan9PErrors(t *testing.T) {
+	version := ninePMsg(100, 0xffff, "\x10\x00\x00\x00\x06\x009P2000")
+	for _, test := range []struct {
+		name   string
+		stream string
+		err    error
+	}{
+		{"short size", "\x10\x00\x00\x00\x75", io.ErrUnexpectedEOF},
+		{"short body", ninePMsg(117, 1, "abc")[:8], io.ErrUnexpectedEOF},
+		{"size", "\x06\x00\x00\x00\x75\x01\x00", protoscan.ErrProtocolViolation},
+		{"msize", version + ninePMsg(117, 1, strings.Repeat("x", 10)), protoscan.ErrProtocolViolation},
+	} {
+		if _, err := scan9P(strings.NewReader(test.stream)); !errors.Is(err, test.err) {
+			t.Errorf("%s: expected error %v; got %v", test.name, test.err, err)
+		}
+	}
+}
+
+func FuzzScan9P(f *testing.F) {
+	protoscantest.AddCorpus(f, protoscantest.NinePCorpus())
+	f.Fuzz(protoscantest.FuzzContext(protoscan.Scan9P))
+}
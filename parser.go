@@ -0,0 +1,85 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import "sync"
+
+// ParseFunc decodes the token into the value. The value may hold the data
+// of the previously decoded token, so the function must overwrite it all.
+type ParseFunc[T any] func(token []byte, v *T) error
+
+// Parser decodes the tokens of the Protoscan into the values of the type.
+type Parser[T any] struct {
+	scan  *Protoscan
+	parse ParseFunc[T]
+	pool  *sync.Pool
+	value *T
+	err   error
+}
+
+// ParserOption changes parser.
+type ParserOption[T any] func(*Parser[T])
+
+// WithParserPool makes the Parser take the values from the pool of the
+// values allocated by the function, and the Release return them to the
+// pool, so that the decoding loop does not allocate.
+func WithParserPool[T any](alloc func() *T) ParserOption[T] {
+	return func(p *Parser[T]) {
+		p.pool = &sync.Pool{New: func() interface{} { return alloc() }}
+	}
+}
+
+// NewParser returns a new Parser decoding the tokens of the Protoscan.
+func NewParser[T any](s *Protoscan, parse ParseFunc[T], opts ...ParserOption[T]) *Parser[T] {
+	p := &Parser[T]{scan: s, parse: parse}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Scan advances the Parser to the next token and decodes it into the value
+// available through the Value method. It returns false when the scan stops
+// or the decoding fails.
+func (p *Parser[T]) Scan() bool {
+	p.value = nil
+	if p.err != nil || !p.scan.Scan() {
+		return false
+	}
+	var v *T
+	if p.pool != nil {
+		v = p.pool.Get().(*T)
+	} else {
+		v = new(T)
+	}
+	if err := p.parse(p.scan.Token(), v); err != nil {
+		p.Release(v)
+		p.err = err
+		return false
+	}
+	p.value = v
+	return true
+}
+
+// Value returns the value decoded by the last call to Scan.
+func (p *Parser[T]) Value() *T {
+	return p.value
+}
+
+// Release returns the value to the pool set by the WithParserPool, the
+// value must not be used afterwards.
+func (p *Parser[T]) Release(v *T) {
+	if p.pool != nil && v != nil {
+		p.pool.Put(v)
+	}
+}
+
+// Err returns the first error of the decoding or of the scan.
+func (p *Parser[T]) Err() error {
+	if p.err != nil {
+		return p.err
+	}
+	return p.scan.Err()
+}
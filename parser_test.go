@@ -0,0 +1,74 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+)
+
+type tick struct {
+	symbol []byte
+	price  int
+}
+
+var errTick = errors.New("bad tick")
+
+func parseTick(token []byte, v *tick) error {
+	i := bytes.IndexByte(token, ' ')
+	if i < 0 {
+		return errTick
+	}
+	price, err := strconv.Atoi(string(token[i+1:]))
+	if err != nil {
+		return err
+	}
+	v.symbol = append(v.symbol[:0], token[:i]...)
+	v.price = price
+	return nil
+}
+
+func TestParser(t *testing.T) {
+	p := protoscan.NewParser(
+		protoscan.New(strings.NewReader("AAA 1\nBBB 22\nCCC\n"), protoscan.WithSplit(protoscan.ScanLines)),
+		parseTick,
+		protoscan.WithParserPool(func() *tick { return &tick{symbol: make([]byte, 0, 8)} }),
+	)
+	var got []string
+	for p.Scan() {
+		v := p.Value()
+		got = append(got, string(v.symbol)+"="+strconv.Itoa(v.price))
+		p.Release(v)
+	}
+	if !errors.Is(p.Err(), errTick) {
+		t.Fatalf("expected errTick; got %v", p.Err())
+	}
+	if strings.Join(got, " ") != "AAA=1 BBB=22" {
+		t.Fatalf("unexpected values %q", got)
+	}
+}
+
+func TestParserAllocs(t *testing.T) {
+	text := strings.Repeat("AAA 1\n", 1000)
+	r := strings.NewReader(text)
+	s := protoscan.New(r, protoscan.WithSplit(protoscan.ScanLines))
+	p := protoscan.NewParser(s, parseTick, protoscan.WithParserPool(func() *tick { return new(tick) }))
+	p.Scan()
+	p.Release(p.Value())
+	allocs := testing.AllocsPerRun(100, func() {
+		if !p.Scan() {
+			t.Fatal(p.Err())
+		}
+		p.Release(p.Value())
+	})
+	if allocs > 0 {
+		t.Fatalf("expected no allocations; got %v", allocs)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// ProgressKind tells which side of the scan made no progress.
+type ProgressKind int
+
+// Kinds of the lack of progress.
+const (
+	SplitNoProgress ProgressKind = iota // Split function returned empty tokens without advancing.
+	ReadNoProgress                      // Reader returned no data and no error.
+)
+
+func (k ProgressKind) String() string {
+	switch k {
+	case SplitNoProgress:
+		return "split"
+	case ReadNoProgress:
+		return "read"
+	}
+	return "unknown"
+}
+
+// ProgressError records the scan stopped without progressing. It wraps the
+// ErrNoProgress if the split function made no progress, and the
+// io.ErrNoProgress if the reader did.
+type ProgressError struct {
+	Kind  ProgressKind
+	Count int           // Count of the successive empty tokens or reads.
+	Idle  time.Duration // Total sleep after the empty reads set by the WithIdleBackoff.
+}
+
+func (e *ProgressError) Error() string {
+	if e.Kind == SplitNoProgress {
+		return fmt.Sprintf("%v: %d empty tokens", ErrNoProgress, e.Count)
+	}
+	if e.Idle > 0 {
+		return fmt.Sprintf("%v: %d empty reads in %v", io.ErrNoProgress, e.Count, e.Idle)
+	}
+	return fmt.Sprintf("%v: %d empty reads", io.ErrNoProgress, e.Count)
+}
+
+func (e *ProgressError) Unwrap() error {
+	if e.Kind == SplitNoProgress {
+		return ErrNoProgress
+	}
+	return io.ErrNoProgress
+}
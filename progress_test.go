@@ -0,0 +1,59 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestProgressError(t *testing.T) {
+	empty := func(data []byte, atEOF bool) (int, int, []byte, error) {
+		return 0, 0, []byte{}, nil
+	}
+	for _, test := range []struct {
+		name  string
+		scan  *protoscan.Protoscan
+		kind  protoscan.ProgressKind
+		count int
+		is    error
+		isNot error
+	}{
+		{
+			name:  "split",
+			scan:  protoscan.New(strings.NewReader("abc"), protoscan.WithSplit(empty), protoscan.WithMaxIdle(10)),
+			kind:  protoscan.SplitNoProgress,
+			count: 11,
+			is:    protoscan.ErrNoProgress,
+			isNot: io.ErrNoProgress,
+		},
+		{
+			name:  "read",
+			scan:  protoscan.New(protoscantest.EndlessZeros{}, protoscan.WithMaxIdle(10)),
+			kind:  protoscan.ReadNoProgress,
+			count: 11,
+			is:    io.ErrNoProgress,
+			isNot: protoscan.ErrNoProgress,
+		},
+	} {
+		for test.scan.Scan() {
+		}
+		var progressErr *protoscan.ProgressError
+		if !errors.As(test.scan.Err(), &progressErr) {
+			t.Fatalf("%s: expected ProgressError; got %v", test.name, test.scan.Err())
+		}
+		if progressErr.Kind != test.kind || progressErr.Count != test.count {
+			t.Errorf("%s: expected %v after %d; got %v after %d", test.name, test.kind, test.count, progressErr.Kind, progressErr.Count)
+		}
+		if !errors.Is(progressErr, test.is) || errors.Is(progressErr, test.isNot) {
+			t.Errorf("%s: expected %v wrapping %v", test.name, progressErr, test.is)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"bytes"
+	"strings"
+)
+
+// promSuffixes are the suffixes of the samples of the metric families:
+// the histograms, the summaries, the counters and the OpenMetrics info and
+// gauge histograms.
+var promSuffixes = []string{"_bucket", "_sum", "_count", "_total", "_created", "_info", "_gcount", "_gsum"}
+
+// promLineName returns the metric name of the line of the exposition
+// format and whether it is the HELP, the TYPE or the UNIT comment. The
+// name is empty for the other comments and the empty lines.
+func promLineName(line []byte) (string, bool) {
+	if len(line) > 0 && line[0] == '#' {
+		f := bytes.Fields(line[1:])
+		if len(f) >= 2 {
+			switch string(f[0]) {
+			case "HELP", "TYPE", "UNIT":
+				return string(f[1]), true
+			}
+		}
+		return "", false
+	}
+	line = bytes.TrimLeft(line, " \t")
+	if i := bytes.IndexAny(line, "{ \t"); i >= 0 {
+		line = line[:i]
+	}
+	return string(line), false
+}
+
+// promBelongs reports whether the metric name belongs to the family.
+func promBelongs(family, name string, meta bool) bool {
+	if name == family {
+		return true
+	}
+	if meta || !strings.HasPrefix(name, family) {
+		return false
+	}
+	for _, suffix := range promSuffixes {
+		if name[len(family):] == suffix {
+			return true
+		}
+	}
+	return false
+}
+
+// ScanPromFamilies is a split function for a Protoscan that returns each
+// metric family of the Prometheus or OpenMetrics text exposition format as
+// a token: its HELP, TYPE and UNIT comments with the subsequent samples of
+// the family, the histogram and summary samples of the suffixed names
+// included, so that a family is never split across the tokens. The family
+// ends at the line of another metric, which is read before the family is
+// returned. The other comments stay with the family preceding them, the
+// "# EOF" line is its own token, and the empty lines between the families
+// are dropped, as is the final newline of the token.
+func ScanPromFamilies(data []byte, atEOF bool) (int, int, []byte, error) {
+	var family string
+	start, end := -1, 0
+	pos := 0
+	for {
+		if pos == len(data) {
+			if atEOF {
+				break
+			}
+			return 1, 0, nil, nil
+		}
+		i := bytes.IndexByte(data[pos:], '\n')
+		next := pos + i + 1
+		if i < 0 {
+			if !atEOF {
+				return 1, 0, nil, nil
+			}
+			i, next = len(data)-pos, len(data)
+		}
+		line := dropCR(data[pos : pos+i])
+		if string(bytes.TrimRight(line, " \t")) == "# EOF" {
+			if start >= 0 {
+				break
+			}
+			return 0, next, line, nil
+		}
+		name, meta := promLineName(line)
+		if family != "" && name != "" && !promBelongs(family, name, meta) {
+			break
+		}
+		if family == "" && name != "" {
+			family = name
+		}
+		if len(bytes.TrimSpace(line)) > 0 {
+			if start < 0 {
+				start = pos
+			}
+			end = pos + len(line)
+		}
+		pos = next
+	}
+	if start < 0 {
+		return 0, pos, nil, nil
+	}
+	return 0, pos, data[start:end], nil
+}
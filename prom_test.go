@@ -0,0 +1,59 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanPromFamilies(t *testing.T) {
+	histogram := "# HELP rpc_seconds RPC latency.\n" +
+		"# TYPE rpc_seconds histogram\n" +
+		`rpc_seconds_bucket{le="0.1"} 3` + "\n" +
+		`rpc_seconds_bucket{le="+Inf"} 5` + "\n" +
+		"rpc_seconds_sum 0.7\n" +
+		"rpc_seconds_count 5"
+	counter := "# TYPE requests counter\n" +
+		"# a comment\n" +
+		`requests_total{code="200"} 10` + "\n" +
+		"requests_created 1.7e9"
+	protoscantest.TestSplitFunc(t, protoscan.ScanPromFamilies, []protoscantest.Case{
+		{Name: "empty", Input: "", Tokens: nil},
+		{Name: "blank", Input: "\n\n", Tokens: nil},
+		{
+			Name:   "families",
+			Input:  histogram + "\n" + counter + "\n\nup 1\nup{job=\"b\"} 0\nother 2\n",
+			Tokens: []string{histogram, counter, "up 1\nup{job=\"b\"} 0", "other 2"},
+		},
+		{
+			Name:   "new help",
+			Input:  "# HELP a A.\na 1\n# HELP b B.\nb 2",
+			Tokens: []string{"# HELP a A.\na 1", "# HELP b B.\nb 2"},
+		},
+		{
+			Name:   "leading comment",
+			Input:  "# generated\n# TYPE a gauge\na 1\n",
+			Tokens: []string{"# generated\n# TYPE a gauge\na 1"},
+		},
+		{
+			Name:   "openmetrics",
+			Input:  "# TYPE a gauge\na 1\n# EOF\n",
+			Tokens: []string{"# TYPE a gauge\na 1", "# EOF"},
+		},
+		{
+			Name:   "crlf",
+			Input:  "# TYPE a gauge\r\na 1\r\nb 2\r\n",
+			Tokens: []string{"# TYPE a gauge\r\na 1", "b 2"},
+		},
+	})
+}
+
+func FuzzScanPromFamilies(f *testing.F) {
+	f.Add([]byte("# HELP a A.\n# TYPE a summary\na{quantile=\"0.5\"} 1\na_sum 2\na_count 3\nb 1\n"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanPromFamilies))
+}
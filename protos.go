@@ -7,9 +7,14 @@ package protoscan
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"io"
+	"log/slog"
 	"sync"
+	"sync/atomic"
+	"time"
 	"unicode/utf8"
 )
 
@@ -37,6 +42,90 @@ type Protoscan struct {
 	start     int       // Number of bytes from the beginning of the buffer by which the carriage is shifted.
 	end       int       // Number of bytes that been read from the reader and then buffered.
 	empties   int       // Count of successive empty tokens.
+	maxIdle   int       // The number of allowed successive empty reads or empty tokens.
+
+	minLookahead int     // The minimum number of bytes handed to Split unless at EOF.
+	adaptive     bool    // Whether the reads are sized by the average size of the frames.
+	avgFrame     float64 // Moving average of the size of the frames.
+
+	transformer   Transformer    // The transformer of the raw data stream.
+	codecs        []Codec        // The codecs of the compressed data stream.
+	detectCharset bool           // Whether the character set of the data stream is detected.
+	charsetReader *charsetReader // The reader of the data stream of the detected character set.
+
+	closer       io.Closer     // Closed when the scan stops with an error.
+	idleTimeout  time.Duration // Maximum duration of a single read from the connection.
+	tokenTimeout time.Duration // Maximum duration of reading a single token from the connection.
+	tokenStart   time.Time     // Time of the last call to Scan, set if tokenTimeout is.
+	totalTimeout time.Duration // Maximum duration of the scan.
+	created      time.Time     // Time of the creation, set if totalTimeout is.
+	abort        func()        // Called when the deadline passes while a read is blocked.
+	aborted      atomic.Bool   // Whether the abort is called during the read.
+
+	drop    func(token []byte) bool // Reports whether the token is dropped.
+	dropped int                     // Count of dropped tokens.
+
+	offset int64      // Offset of the beginning of the buffer in the data stream.
+	reads  []readMark // Marks of the reads of the buffered data.
+	info   TokenInfo  // Metadata of the last token.
+
+	stamper TimestampedReader // The reader, if it knows the time of the reads.
+
+	errorContext int    // Number of bytes around the carriage captured by the ScanError.
+	behind       []byte // Last bytes before the carriage shifted out of the buffer.
+
+	lastHint, lastAdvance int // Hint and advance returned by the last call to Split.
+
+	tracer Tracer // The tracer of the frames.
+
+	line    int    // Line at which the last token starts.
+	lines   int    // Count of the newlines before the carriage.
+	newline []byte // The byte ending the lines, nil if the lines are not counted.
+
+	idleBackoff *BackoffPolicy // The policy of the sleeps after the empty reads.
+	idleSlept   time.Duration  // Total sleep after the successive empty reads.
+
+	retryPolicy *RetryPolicy // The policy of the retries of the failed reads.
+	retried     int          // Count of the successive retries.
+
+	boundaryFlush bool   // Whether the tokens are flushed at the end of each reader of the NewMulti.
+	boundary      func() // Switches to the next reader of the NewMulti.
+
+	eofPoll    time.Duration // Interval of the polling of the reader after io.EOF.
+	eofTimeout time.Duration // Maximum wait for the new data after io.EOF.
+	eofWaited  time.Duration // Wait for the new data since the last data read.
+
+	ctx context.Context // Context of the ScanContext cancelling the waits, nil otherwise.
+
+	stats     Stats    // Statistics of the delivered tokens.
+	histogram []Bucket // Histogram of the sizes of the delivered tokens.
+
+	recovery  bool                            // Whether the recoverable errors of Split are skipped.
+	onRecover func(err error, skipped []byte) // Called on the recovery.
+	resyncs   int                             // Count of the recoveries.
+
+	splitCtx SplitContext // Context handed to the SplitCtxFunc.
+
+	strictEOF        bool             // Whether the data not consumed at EOF is an error.
+	unterminatedMode UnterminatedMode // Handling of the unterminated final token.
+
+	remainder []byte // Data not consumed when the scan stopped.
+	stopped   bool   // Whether the remainder is set.
+
+	batch    [][]byte // Tokens generated by the last call to ScanN.
+	batching bool     // Whether ScanN is in progress.
+	batchMax int      // Maximum count of the tokens of the batch.
+
+	mark    Mark // Position kept in the buffer.
+	marked  bool // Whether the mark is set.
+	peeking bool // Whether the transaction of the BeginPeek is open.
+
+	logger   *slog.Logger // The logger of the notable events.
+	logLevel slog.Level   // The level of the logged events.
+	reported bool         // Whether the error stopping the scan is traced and logged.
+
+	annotator   AnnotateFunc // The annotator of the tokens.
+	annotations []Annotation // Annotations of the last token.
 }
 
 // SplitFunc is the signature of the split function used to tokenize the
@@ -56,6 +145,11 @@ type Protoscan struct {
 // a SplitFunc can return hint as number of bytes which must read (N, 0)
 // to signal the Protoscan to read more data from the reader into the slice
 // and try again with a longer slice starting at the same point in the input.
+// The buffer is grown at once to hold the hinted bytes and the Protoscan
+// reads until all of them are read, or the reader fails or returns EOF,
+// before calling the split function again, so a split function of a
+// length-prefixed protocol which hints the rest of the frame is called
+// once more per frame.
 type SplitFunc func(data []byte, atEOF bool) (hint int, advance int, token []byte, err error)
 
 func New(r io.Reader, opts ...Option) *Protoscan {
@@ -69,6 +163,20 @@ func New(r io.Reader, opts ...Option) *Protoscan {
 	for _, opt := range opts {
 		opt(s)
 	}
+	if s.codecs != nil {
+		s.reader = &decompressReader{reader: s.reader, codecs: s.codecs}
+	}
+	if s.detectCharset {
+		s.charsetReader = &charsetReader{reader: s.reader}
+		s.reader = s.charsetReader
+	}
+	if s.transformer != nil {
+		s.reader = newTransformReader(s.reader, s.transformer)
+	}
+	s.stamper, _ = s.reader.(TimestampedReader)
+	if s.totalTimeout > 0 {
+		s.created = time.Now()
+	}
 	return s
 }
 
@@ -90,7 +198,23 @@ func WithMaxBuffer(max int) Option {
 	return func(s *Protoscan) { s.maxBuffer = max }
 }
 
-// Errors returned by Protoscan.
+// WithMinLookahead makes the Protoscan call the split function with at
+// least n bytes of data unless at EOF, so that the split functions of the
+// protocols with the fixed-size headers may index the headers without
+// checking the length of the data.
+func WithMinLookahead(n int) Option {
+	return func(s *Protoscan) { s.minLookahead = n }
+}
+
+// WithMaxIdle sets the number of allowed consecutive empty reads or
+// consecutive empty scans without progressing, after which the scan stops
+// with io.ErrNoProgress or ErrNoProgress. Zero means the default of 1000.
+func WithMaxIdle(n int) Option {
+	return func(s *Protoscan) { s.maxIdle = n }
+}
+
+// Errors returned by Protoscan. The errors are wrapped with the details of
+// the failure, so they should be matched with errors.Is.
 var (
 	ErrTooLong         = errors.New("protoscan: token too long")
 	ErrNegativeAdvance = errors.New("protoscan: SplitFunc returns negative advance count of the data input")
@@ -120,14 +244,14 @@ func (s *Protoscan) Token() []byte {
 
 // Err returns the first non-EOF error that was encountered by the Protoscan.
 func (s *Protoscan) Err() error {
-	if s.err == io.EOF || s.err == FinalToken {
+	if s.err == io.EOF || s.err == FinalToken || s.err == errBoundary {
 		return nil
 	}
 	return s.err
 }
 
-// maxConsecutiveIdling is the number of allowed consecutive empty reads
-// or consecutive empty scans without progressing.
+// maxConsecutiveIdling is the default number of allowed consecutive empty
+// reads or consecutive empty scans without progressing.
 const maxConsecutiveIdling = 1000
 
 // Scan advances the Protoscan to the next token, which will then be
@@ -142,80 +266,254 @@ const maxConsecutiveIdling = 1000
 // occurred during scanning, except that if it was io.EOF, Err
 // will return nil.
 func (s *Protoscan) Scan() bool {
+	if s.tokenTimeout > 0 {
+		s.tokenStart = time.Now()
+	}
+	if s.tracer != nil && s.err == nil {
+		s.tracer.StartFrame(s.offset + int64(s.start))
+	}
+	if s.scan() {
+		s.annotate()
+		return true
+	}
+	s.annotations = nil
+	if s.err != nil {
+		// The batch stops short of the end without stopping the scan.
+		s.setRemainder()
+	}
+	if err := s.Err(); err != nil && !s.reported {
+		s.reported = true
+		if s.tracer != nil {
+			s.tracer.Error(s.offset+int64(s.start), err)
+		}
+		s.log("scan stopped", slog.Any("error", err))
+	}
+	if s.closer != nil && s.Err() != nil {
+		s.closer.Close()
+	}
+	return false
+}
+
+// scan advances the Protoscan to the next token.
+func (s *Protoscan) scan() bool {
 	if s.maxBuffer == 0 {
 		s.maxBuffer = maxBuffer
 	}
-	if s.err == FinalToken {
+	if s.maxIdle == 0 {
+		s.maxIdle = maxConsecutiveIdling
+	}
+	if s.err == FinalToken || s.stopped && s.err != io.EOF {
+		// Do not split past the error delivered after the last batch.
 		return false
 	}
-	// Loop until we have a token.
+	// Loop until we have a token, or the batch is full.
 	for {
-		hint, advance, token, err := s.split(s.buffer[s.start:s.end], s.err == io.EOF)
+		var hint, advance int
+		var token []byte
+		var err error
+		if n := s.minLookahead - (s.end - s.start); n > 0 && s.err == nil {
+			// Read up to the minimum lookahead before splitting.
+			hint = n
+		} else {
+			hint, advance, token, err = s.split(s.buffer[s.start:s.end], s.err == io.EOF || s.err == errBoundary)
+		}
 		s.token = token
+		s.lastHint, s.lastAdvance = hint, advance
+		if err != nil && s.recover(err, advance) {
+			s.empties = 0
+			continue
+		}
 		if err != nil {
+			if err == FinalToken && advance >= 0 && s.start+advance <= s.end {
+				if token != nil {
+					s.line = s.lines + 1
+				}
+				s.move(advance)
+				s.setInfo(advance)
+				if s.tracer != nil && token != nil {
+					s.tracer.EndFrame(s.info, token)
+				}
+				if token != nil {
+					s.count(token)
+				}
+			}
+			if err != FinalToken && s.errorContext > 0 {
+				err = s.scanError(err)
+			}
 			s.setErr(err)
-			pool.Put(&s.buffer)
-			return err == FinalToken
+			if err == FinalToken {
+				if s.batching {
+					s.batch = append(s.batch, token)
+				}
+				return true
+			}
+			s.setRemainder()
+			if s.batched() {
+				// The tokens of the batch point to the buffer, so it is
+				// left to the garbage collector.
+				return true
+			}
+			s.release()
+			return false
 		}
 		if err = s.advance(advance); err != nil {
 			s.setErr(err)
-			return false
+			return s.batched()
+		}
+		unterminated := token != nil && advance > 0 && s.unterminated()
+		if unterminated && s.unterminatedMode == UnterminatedGap {
+			s.token = nil
+			return s.batched()
 		}
-		s.start += advance
+		line := s.lines + 1
+		s.move(advance)
 		if token != nil && advance > 0 {
 			s.empties = 0
+			s.line = line
+			s.setInfo(advance)
+			s.info.Unterminated = unterminated
+			if s.adaptive {
+				s.average(advance)
+			}
+			if s.tracer != nil {
+				s.tracer.EndFrame(s.info, token)
+			}
+			if s.drop != nil && s.drop(token) {
+				s.dropped++
+				s.log("token dropped", slog.Int("size", advance))
+				if s.tracer != nil {
+					s.tracer.StartFrame(s.offset + int64(s.start))
+				}
+				continue
+			}
+			s.count(token)
+			if s.batching {
+				s.batch = append(s.batch, token)
+				if len(s.batch) < s.batchMax {
+					if s.tracer != nil {
+						s.tracer.StartFrame(s.offset + int64(s.start))
+					}
+					continue
+				}
+			}
 			return true
 		} else if advance > 0 {
 			s.empties = 0
 		} else {
 			s.empties++
-			if s.empties > maxConsecutiveIdling {
-				s.setErr(ErrNoProgress)
-				return false
+			if s.empties > s.maxIdle {
+				s.setErr(&ProgressError{Kind: SplitNoProgress, Count: s.empties})
+				return s.batched()
 			}
 		}
+		if s.err == errBoundary {
+			s.err = nil
+			s.boundary()
+		}
+		if s.err == io.EOF && s.strictEOF && s.start < s.end {
+			s.setErr(s.partialFrame())
+		}
 		if s.err != nil {
-			return false
+			return s.batched()
+		}
+		// Stop the batch at the end of the buffered data.
+		if s.batched() {
+			return true
 		}
 		// Shift data to beginning of buffer if there's lots of empty space
-		// or space is needed.
+		// or space is needed. Data after the mark is kept.
 		if s.start > 0 && (s.end == len(s.buffer) || s.start > len(s.buffer)/2) {
-			copy(s.buffer, s.buffer[s.start:s.end])
-			s.end -= s.start
-			s.start = 0
+			shift := s.start
+			if s.marked && s.mark.offset-s.offset < int64(shift) {
+				shift = int(s.mark.offset - s.offset)
+			}
+			if s.errorContext > 0 {
+				s.keepBehind(shift)
+			}
+			// The reads of the skipped data end before the carriage.
+			s.trimReads(s.offset + int64(s.start))
+			copy(s.buffer, s.buffer[shift:s.end])
+			s.offset += int64(shift)
+			s.end -= shift
+			s.start -= shift
 		}
 		err = s.hint(hint)
+		if errors.Is(err, ErrTooLong) {
+			s.log("frame too long", slog.Int("size", s.end+hint), slog.Int("max", s.maxBuffer))
+		}
 		if err != nil {
 			s.setErr(err)
-			return false
+			return s.batched()
 		}
 		claim := s.end + hint
+		// The reads may fill the window beyond the claim.
+		window := claim
+		if s.adaptive {
+			window = s.window(claim)
+		}
 		// Is the buffer cannot holds the token of the hinted size? If so, resize.
-		if len(s.buffer) < claim {
-			pool.Put(&s.buffer)
-			s.buffer = append(s.buffer, make([]byte, claim-len(s.buffer))...)
+		if len(s.buffer) < window {
+			buf := append(s.buffer, make([]byte, window-len(s.buffer))...)
+			if cap(s.buffer) < window {
+				s.log("buffer grown", slog.Int("from", cap(s.buffer)), slog.Int("to", cap(buf)))
+				s.release()
+			}
+			s.buffer = buf
 		}
 		// Finally we can read some input. Make sure we don't get stuck with
 		// a misbehaving Reader. Officially we don't need to do this, but let's
 		// be extra careful: Protoscan is for safe, simple jobs.
 		for s.end < claim {
-			n, err := s.reader.Read(s.buffer[s.end:claim])
-			if n < 0 || len(s.buffer)-s.end < n {
-				s.setErr(ErrBadReadCount)
+			var n int
+			var ts time.Time
+			timer := s.abortTimer()
+			if s.stamper != nil {
+				n, ts, err = s.stamper.ReadTimestamped(s.buffer[s.end:window])
+			} else {
+				n, err = s.reader.Read(s.buffer[s.end:window])
+			}
+			if timer != nil && !timer.Stop() && s.aborted.Load() {
+				s.setErr(fmt.Errorf("%w: %w", ErrAborted, err))
 				break
 			}
-			s.end += n
-			if err != nil {
-				s.setErr(err)
+			if n < 0 || len(s.buffer)-s.end < n {
+				s.setErr(fmt.Errorf("%w: %d of %d", ErrBadReadCount, n, window-s.end))
 				break
 			}
+			s.end += n
 			if n > 0 {
+				if ts.IsZero() {
+					ts = time.Now()
+				}
+				s.reads = append(s.reads, readMark{end: s.offset + int64(s.end), time: ts})
 				s.empties = 0
+				s.idleSlept = 0
+				s.retried = 0
+				s.eofWaited = 0
+			}
+			if err != nil && !s.retry(err) {
+				if n == 0 && s.waitEOF(err) {
+					continue
+				}
+				if n == 0 || err != io.EOF || s.eofPoll <= 0 {
+					s.setErr(err)
+				}
 				break
 			}
+			if n > 0 || err != nil {
+				// Read all the hinted bytes before splitting again.
+				continue
+			}
 			s.empties++
-			if s.empties > maxConsecutiveIdling {
-				s.setErr(io.ErrNoProgress)
+			if s.idleBackoff != nil {
+				if !s.backoff() {
+					s.setErr(&ProgressError{Kind: ReadNoProgress, Count: s.empties, Idle: s.idleSlept})
+					break
+				}
+				continue
+			}
+			if s.empties > s.maxIdle {
+				s.setErr(&ProgressError{Kind: ReadNoProgress, Count: s.empties})
 				break
 			}
 		}
@@ -224,14 +522,21 @@ func (s *Protoscan) Scan() bool {
 
 var pool = sync.Pool{New: func() interface{} { return &[]byte{} }}
 
+// release returns the buffer to the pool. The buffer must not be used
+// afterwards, unless it is replaced.
+func (s *Protoscan) release() {
+	buf := s.buffer[:0]
+	pool.Put(&buf)
+}
+
 // advance validates moving of the carriage forward on n bytes of the buffer.
 // It reports whether the advance was legal.
 func (s *Protoscan) advance(n int) error {
 	if n < 0 {
-		return ErrNegativeAdvance
+		return fmt.Errorf("%w: %d", ErrNegativeAdvance, n)
 	}
 	if s.start+n > s.end {
-		return ErrAdvanceTooFar
+		return fmt.Errorf("%w: %d of %d", ErrAdvanceTooFar, n, s.end-s.start)
 	}
 	return nil
 }
@@ -239,19 +544,19 @@ func (s *Protoscan) advance(n int) error {
 // hint validates hint.
 func (s *Protoscan) hint(n int) error {
 	if n < 0 {
-		return ErrNegativeHint
+		return fmt.Errorf("%w: %d", ErrNegativeHint, n)
 	}
 	// Guarantee no buffer overflow.
 	const maxInt = int(^uint(0) >> 1)
 	if s.end+n > s.maxBuffer || s.end+n > maxInt {
-		return ErrTooLong
+		return fmt.Errorf("%w: %d bytes exceeds maximum of %d", ErrTooLong, s.end+n, s.maxBuffer)
 	}
 	return nil
 }
 
 // setErr records the first error encountered.
 func (s *Protoscan) setErr(err error) {
-	if s.err == nil || s.err == io.EOF {
+	if s.err == nil || s.err == io.EOF || s.err == errBoundary {
 		s.err = err
 	}
 }
@@ -345,6 +650,23 @@ func dropCR(data []byte) []byte {
 	return data
 }
 
+// ScanRawLines is a split function for a Protoscan that returns each line of
+// text including its trailing newline, with any carriage returns kept. The
+// last non-empty line of input will be returned even if it has no newline.
+// Concatenation of the tokens reproduces the input byte by byte.
+func ScanRawLines(data []byte, atEOF bool) (int, int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, 0, nil, nil
+	}
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return 0, i + 1, data[0 : i+1], nil
+	}
+	if atEOF {
+		return 0, len(data), data, nil
+	}
+	return 1, 0, nil, nil
+}
+
 // ScanWords is a split function for a Protoscan that returns each
 // space-separated word of text, with surrounding spaces deleted.
 // It will never return an empty string. The definition of space is set by
@@ -375,6 +697,37 @@ func ScanWords(data []byte, atEOF bool) (int, int, []byte, error) {
 	return 1, 0, nil, nil
 }
 
+// ScanWordsKeepGaps is a split function for a Protoscan that returns each
+// space-separated word of text and each run of spaces between the words as
+// separate tokens, so that the concatenation of the tokens reproduces the
+// input. The token is a gap if its first rune is a space. The definition of
+// space is set by unicode.IsSpace.
+func ScanWordsKeepGaps(data []byte, atEOF bool) (int, int, []byte, error) {
+	if len(data) == 0 {
+		if atEOF {
+			return 0, 0, nil, nil
+		}
+		return 1, 0, nil, nil
+	}
+	r, width := utf8.DecodeRune(data)
+	gap := isSpace(r)
+	// Scan until the kind of the rune changes.
+	for i := width; i < len(data); i += width {
+		if !atEOF && !utf8.FullRune(data[i:]) {
+			break
+		}
+		r, width = utf8.DecodeRune(data[i:])
+		if isSpace(r) != gap {
+			return 0, i, data[:i], nil
+		}
+	}
+	if atEOF {
+		return 0, len(data), data, nil
+	}
+	// Request more data.
+	return 1, 0, nil, nil
+}
+
 // isSpace reports whether the character is a Unicode white space character.
 // We avoid dependency on the unicode package, but check validity of the implementation
 // in the tests.
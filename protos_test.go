@@ -15,6 +15,7 @@ import (
 	"unicode/utf8"
 
 	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
 )
 
 const smallMaxTokenSize = 256 // Much smaller for more efficient testing.
@@ -146,20 +147,6 @@ func TestScanWords(t *testing.T) {
 	}
 }
 
-// slowReader is a reader that returns only a few bytes at a time, to test the incremental
-// reads in Scanner.Scan.
-type slowReader struct {
-	max int
-	buf io.Reader
-}
-
-func (sr *slowReader) Read(s []byte) (n int, err error) {
-	if len(s) > sr.max {
-		s = s[0:sr.max]
-	}
-	return sr.buf.Read(s)
-}
-
 // genLine writes to buf a predictable but non-trivial line of text of length
 // n, including the terminal newline and an occasional carriage return.
 // If addNewline is false, the \r and \n are not emitted.
@@ -201,7 +188,7 @@ func TestScanLongLines(t *testing.T) {
 		buf.Write(tmp.Bytes())
 		lineNum++
 	}
-	s := protoscan.New(&slowReader{1, buf},
+	s := protoscan.New(&protoscantest.SlowReader{Max: 1, R: buf},
 		protoscan.WithSplit(protoscan.ScanLines),
 		protoscan.WithMaxBuffer(smallMaxTokenSize),
 	)
@@ -241,7 +228,7 @@ func TestScanLineTooLong(t *testing.T) {
 		lineNum++
 	}
 	s := protoscan.New(
-		&slowReader{3, buf},
+		&protoscantest.SlowReader{Max: 3, R: buf},
 		protoscan.WithSplit(protoscan.ScanLines),
 		protoscan.WithMaxBuffer(smallMaxTokenSize),
 	)
@@ -261,7 +248,7 @@ func TestScanLineTooLong(t *testing.T) {
 		}
 	}
 	err := s.Err()
-	if err != protoscan.ErrTooLong {
+	if !errors.Is(err, protoscan.ErrTooLong) {
 		t.Fatalf("expected ErrTooLong; got %s", err)
 	}
 }
@@ -270,7 +257,7 @@ func TestScanLineTooLong(t *testing.T) {
 func testNoNewline(text string, lines []string, t *testing.T) {
 	buf := strings.NewReader(text)
 	s := protoscan.New(
-		&slowReader{7, buf},
+		&protoscantest.SlowReader{Max: 7, R: buf},
 		protoscan.WithSplit(protoscan.ScanLines),
 	)
 	for lineNum := 0; s.Scan(); lineNum++ {
@@ -353,7 +340,7 @@ func TestSplitError(t *testing.T) {
 	const text = "abcdefghijklmnopqrstuvwxyz"
 	buf := strings.NewReader(text)
 	s := protoscan.New(
-		&slowReader{1, buf},
+		&protoscantest.SlowReader{Max: 1, R: buf},
 		protoscan.WithSplit(errorSplit),
 	)
 	var i int
@@ -417,22 +404,16 @@ func TestNonEOFWithEmptyRead(t *testing.T) {
 }
 
 // Test that Scan finishes if we have endless empty reads.
-type endlessZeros struct{}
-
-func (endlessZeros) Read(s []byte) (int, error) {
-	return 0, nil
-}
-
 func TestBadReader(t *testing.T) {
 	s := protoscan.New(
-		endlessZeros{},
+		protoscantest.EndlessZeros{},
 		protoscan.WithSplit(protoscan.ScanLines),
 	)
 	for s.Scan() {
 		t.Fatal("read should fail")
 	}
 	err := s.Err()
-	if err != io.ErrNoProgress {
+	if !errors.Is(err, io.ErrNoProgress) {
 		t.Errorf("unexpected error: %v", err)
 	}
 }
@@ -520,7 +501,7 @@ func TestDontLoopForever(t *testing.T) {
 			t.Fatal("looping")
 		}
 	}
-	if s.Err() != protoscan.ErrNoProgress {
+	if !errors.Is(s.Err(), protoscan.ErrNoProgress) {
 		t.Fatal("after scan:", s.Err())
 	}
 }
@@ -589,31 +570,11 @@ func TestHugeBuffer(t *testing.T) {
 	}
 }
 
-// negativeEOFReader returns an invalid -1 at the end, as though it
-// were wrapping the read system call.
-type negativeEOFReader int
-
-func (r *negativeEOFReader) Read(s []byte) (int, error) {
-	if *r > 0 {
-		c := int(*r)
-		if c > len(s) {
-			c = len(s)
-		}
-		for i := 0; i < c; i++ {
-			s[i] = 'a'
-		}
-		s[c-1] = '\n'
-		*r -= negativeEOFReader(c)
-		return c, nil
-	}
-	return -1, io.EOF
-}
-
 // Test that the scanner doesn't panic and returns ErrBadReadCount
 // on a reader that returns a negative count of bytes read
 // (issue https://github.com/golang/go/issues/38053).
 func TestNegativeEOFReader(t *testing.T) {
-	r := negativeEOFReader(10)
+	r := protoscantest.NegativeEOFReader(10)
 	s := protoscan.New(&r, protoscan.WithSplit(protoscan.ScanLines))
 	c := 0
 	var l []string
@@ -625,27 +586,180 @@ func TestNegativeEOFReader(t *testing.T) {
 			break
 		}
 	}
-	if got, want := s.Err(), protoscan.ErrBadReadCount; got != want {
+	if got, want := s.Err(), protoscan.ErrBadReadCount; !errors.Is(got, want) {
 		t.Errorf("Err: got %v, want %v", got, want)
 	}
 }
 
-// largeReader returns an invalid count that is larger than the number
-// of bytes requested.
-type largeReader struct{}
-
-func (largeReader) Read(s []byte) (int, error) {
-	return len(s) + 1, nil
-}
-
 // Test that the scanner doesn't panic and returns ErrBadReadCount
 // on a reader that returns an impossibly large count of bytes read
 // (issue https://github.com/golang/go/issues/38053).
 func TestLargeReader(t *testing.T) {
-	s := protoscan.New(largeReader{}, protoscan.WithSplit(protoscan.ScanLines))
+	s := protoscan.New(protoscantest.LargeReader{}, protoscan.WithSplit(protoscan.ScanLines))
 	for s.Scan() {
 	}
-	if got, want := s.Err(), protoscan.ErrBadReadCount; got != want {
+	if got, want := s.Err(), protoscan.ErrBadReadCount; !errors.Is(got, want) {
 		t.Errorf("Err: got %v, want %v", got, want)
 	}
 }
+
+// Test that the errors of the Protoscan wrap the sentinel errors.
+func TestErrorsIs(t *testing.T) {
+	for _, test := range []struct {
+		split protoscan.SplitFunc
+		want  error
+		msg   string
+	}{
+		{
+			split: func([]byte, bool) (int, int, []byte, error) { return -1, 0, nil, nil },
+			want:  protoscan.ErrNegativeHint,
+			msg:   "protoscan: SplitFunc hinted negative size of the token: -1",
+		},
+		{
+			split: func([]byte, bool) (int, int, []byte, error) { return 0, -1, nil, nil },
+			want:  protoscan.ErrNegativeAdvance,
+			msg:   "protoscan: SplitFunc returns negative advance count of the data input: -1",
+		},
+		{
+			split: func(data []byte, _ bool) (int, int, []byte, error) { return 0, len(data) + 1, nil, nil },
+			want:  protoscan.ErrAdvanceTooFar,
+			msg:   "protoscan: SplitFunc returns advance count beyond input: 1 of 0",
+		},
+		{
+			split: func([]byte, bool) (int, int, []byte, error) { return 100, 0, nil, nil },
+			want:  protoscan.ErrTooLong,
+			msg:   "protoscan: token too long: 100 bytes exceeds maximum of 10",
+		},
+	} {
+		s := protoscan.New(strings.NewReader("abc"), protoscan.WithSplit(test.split), protoscan.WithMaxBuffer(10))
+		for s.Scan() {
+		}
+		if err := s.Err(); !errors.Is(err, test.want) || err.Error() != test.msg {
+			t.Errorf("expected %q wrapping %v; got %v", test.msg, test.want, err)
+		}
+	}
+}
+
+func TestScanRawLines(t *testing.T) {
+	protoscantest.TestSplitFunc(t, protoscan.ScanRawLines, []protoscantest.Case{
+		{Input: "", Tokens: nil},
+		{Input: "abc", Tokens: []string{"abc"}},
+		{Input: "abc\r\ndef\n\nghi\r", Tokens: []string{"abc\r\n", "def\n", "\n", "ghi\r"}},
+	})
+}
+
+func FuzzScanRawLines(f *testing.F) {
+	protoscantest.AddCorpus(f, protoscantest.LinesCorpus())
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanRawLines))
+}
+
+func TestScanWordsKeepGaps(t *testing.T) {
+	protoscantest.TestSplitFunc(t, protoscan.ScanWordsKeepGaps, []protoscantest.Case{
+		{Input: "", Tokens: nil},
+		{Input: "  lorem\tipsum   dolor", Tokens: []string{"  ", "lorem", "\t", "ipsum", "   ", "dolor"}},
+		{Input: "\xffa ", Tokens: []string{"\xffa", " "}},
+	})
+}
+
+func FuzzScanWordsKeepGaps(f *testing.F) {
+	protoscantest.AddCorpus(f, protoscantest.WordsCorpus())
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanWordsKeepGaps))
+}
+
+// zerosThenReader returns n empty reads before reading from the reader.
+type zerosThenReader struct {
+	n int
+	r io.Reader
+}
+
+func (z *zerosThenReader) Read(p []byte) (int, error) {
+	if z.n > 0 {
+		z.n--
+		return 0, nil
+	}
+	return z.r.Read(p)
+}
+
+func TestMaxIdle(t *testing.T) {
+	for _, test := range []struct {
+		empties, maxIdle int
+		err              error
+	}{
+		{empties: 1500, maxIdle: 2000},
+		{empties: 1500, err: io.ErrNoProgress},
+		{empties: 10, maxIdle: 5, err: io.ErrNoProgress},
+	} {
+		s := protoscan.New(
+			&zerosThenReader{n: test.empties, r: strings.NewReader("abc\n")},
+			protoscan.WithSplit(protoscan.ScanLines),
+			protoscan.WithMaxIdle(test.maxIdle),
+		)
+		for s.Scan() {
+		}
+		if err := s.Err(); !errors.Is(err, test.err) || (err == nil) != (test.err == nil) {
+			t.Errorf("%d empty reads, max %d: expected error %v; got %v", test.empties, test.maxIdle, test.err, err)
+		}
+	}
+}
+
+func TestMinLookahead(t *testing.T) {
+	// The split function indexes the 2-byte header unconditionally.
+	split := func(data []byte, atEOF bool) (int, int, []byte, error) {
+		if atEOF && len(data) < 2 {
+			return 0, len(data), nil, nil
+		}
+		n := 2 + (int(data[0])<<8 | int(data[1]))
+		if len(data) < n {
+			return n - len(data), 0, nil, nil
+		}
+		return 0, n, data[2:n], nil
+	}
+	s := protoscan.New(
+		&protoscantest.SlowReader{Max: 1, R: strings.NewReader("\x00\x03abc\x00\x00\x00\x01d\x00")},
+		protoscan.WithSplit(split),
+		protoscan.WithMinLookahead(2),
+	)
+	var tokens []string
+	for s.Scan() {
+		tokens = append(tokens, string(s.Token()))
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	if got := strings.Join(tokens, "|"); got != "abc||d" {
+		t.Fatalf("unexpected tokens %q", got)
+	}
+}
+
+// Test that the hinted bytes are read before splitting again.
+func TestHintFilled(t *testing.T) {
+	var calls int
+	split := func(data []byte, atEOF bool) (int, int, []byte, error) {
+		calls++
+		if len(data) < 1 {
+			if atEOF {
+				return 0, 0, nil, nil
+			}
+			return 1, 0, nil, nil
+		}
+		n := 1 + int(data[0])
+		if len(data) < n {
+			return n - len(data), 0, nil, nil
+		}
+		return 0, n, data[1:n], nil
+	}
+	frame := "\xff" + strings.Repeat("x", 0xff)
+	s := protoscan.New(
+		&protoscantest.SlowReader{Max: 7, R: strings.NewReader(strings.Repeat(frame, 10))},
+		protoscan.WithSplit(split),
+	)
+	var n int
+	for ; s.Scan(); n++ {
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	if n != 10 || calls > 3*10+2 {
+		t.Fatalf("%d tokens split in %d calls", n, calls)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package protoscanbench benchmarks split functions over configurable token
+// size distributions and reader chunkings.
+//
+// Besides the time and the allocations per operation, which is a scan of
+// the whole stream, it reports the metrics per token: ns/token, reads/token,
+// splits/token (calls of the split function) and scanned-B/token (bytes
+// handed to the split function, which exceeds the size of the token when
+// the split function rescans the buffered data).
+package protoscanbench
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/protoscan/protoscan"
+)
+
+// Distribution returns the size of the next token.
+type Distribution func(r *rand.Rand) int
+
+// Fixed returns the distribution of tokens of the same size.
+func Fixed(size int) Distribution {
+	return func(*rand.Rand) int { return size }
+}
+
+// Uniform returns the distribution of token sizes uniform in [min, max].
+func Uniform(min, max int) Distribution {
+	return func(r *rand.Rand) int { return min + r.Intn(max-min+1) }
+}
+
+// Exponential returns the distribution of token sizes exponential with
+// the mean, capped by the max.
+func Exponential(mean, max int) Distribution {
+	return func(r *rand.Rand) int {
+		n := int(r.ExpFloat64() * float64(mean))
+		if n > max {
+			n = max
+		}
+		return n
+	}
+}
+
+// Config configures a benchmark.
+type Config struct {
+	// Encode appends the encoded token of the payload size to dst.
+	Encode func(dst []byte, size int) []byte
+	// Sizes is the distribution of payload sizes, Fixed(64) if nil.
+	Sizes Distribution
+	// Tokens is the number of tokens of the stream, 1000 if zero.
+	Tokens int
+	// Chunks are the maximum sizes of reads benchmarked, each one in its own
+	// sub-benchmark. Zero means the reads are not limited. DefaultChunks
+	// if empty.
+	Chunks []int
+	// Options are the options of the Protoscan.
+	Options []protoscan.Option
+	// Seed seeds the random sizes.
+	Seed int64
+}
+
+// DefaultChunks are the read sizes benchmarked unless configured.
+var DefaultChunks = []int{0, 1, 16, 1500}
+
+// Stream returns the encoded stream of the configured tokens.
+func Stream(cfg Config) []byte {
+	sizes := cfg.Sizes
+	if sizes == nil {
+		sizes = Fixed(64)
+	}
+	tokens := cfg.Tokens
+	if tokens == 0 {
+		tokens = 1000
+	}
+	r := rand.New(rand.NewSource(cfg.Seed))
+	var stream []byte
+	for i := 0; i < tokens; i++ {
+		stream = cfg.Encode(stream, sizes(r))
+	}
+	return stream
+}
+
+// Run runs the sub-benchmark of the split function for each configured
+// chunking of the stream.
+func Run(b *testing.B, split protoscan.SplitFunc, cfg Config) {
+	stream := Stream(cfg)
+	chunks := cfg.Chunks
+	if len(chunks) == 0 {
+		chunks = DefaultChunks
+	}
+	for _, chunk := range chunks {
+		name := "chunk=all"
+		if chunk > 0 {
+			name = fmt.Sprintf("chunk=%d", chunk)
+		}
+		b.Run(name, func(b *testing.B) {
+			bench(b, split, stream, chunk, cfg.Options)
+		})
+	}
+}
+
+// bench scans the stream b.N times.
+func bench(b *testing.B, split protoscan.SplitFunc, stream []byte, chunk int, opts []protoscan.Option) {
+	var splits, scanned, tokens int
+	count := func(data []byte, atEOF bool) (int, int, []byte, error) {
+		splits++
+		scanned += len(data)
+		return split(data, atEOF)
+	}
+	opts = append(opts[:len(opts):len(opts)], protoscan.WithSplit(count))
+	r := &countingReader{}
+	b.ReportAllocs()
+	b.SetBytes(int64(len(stream)))
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		r.reset(stream, chunk)
+		s := protoscan.New(r, opts...)
+		for s.Scan() {
+			tokens++
+		}
+		if err := s.Err(); err != nil {
+			b.Fatal(err)
+		}
+	}
+	elapsed := time.Since(start)
+	b.StopTimer()
+	if tokens == 0 {
+		return
+	}
+	b.ReportMetric(float64(elapsed.Nanoseconds())/float64(tokens), "ns/token")
+	b.ReportMetric(float64(r.reads)/float64(tokens), "reads/token")
+	b.ReportMetric(float64(splits)/float64(tokens), "splits/token")
+	b.ReportMetric(float64(scanned)/float64(tokens), "scanned-B/token")
+}
+
+// countingReader reads the stream in chunks counting the reads.
+type countingReader struct {
+	r     bytes.Reader
+	chunk int
+	reads int
+}
+
+func (r *countingReader) reset(stream []byte, chunk int) {
+	r.r.Reset(stream)
+	r.chunk = chunk
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	r.reads++
+	if r.chunk > 0 && len(p) > r.chunk {
+		p = p[:r.chunk]
+	}
+	n, err := r.r.Read(p)
+	if err == nil && r.r.Len() == 0 {
+		err = io.EOF
+	}
+	return n, err
+}
@@ -0,0 +1,39 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscanbench_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscanbench"
+)
+
+func encodeLine(dst []byte, size int) []byte {
+	dst = append(dst, bytes.Repeat([]byte{'x'}, size)...)
+	return append(dst, '\n')
+}
+
+func BenchmarkScanLines(b *testing.B) {
+	protoscanbench.Run(b, protoscan.ScanLines, protoscanbench.Config{
+		Encode: encodeLine,
+		Sizes:  protoscanbench.Exponential(80, 1000),
+	})
+}
+
+func TestStream(t *testing.T) {
+	stream := protoscanbench.Stream(protoscanbench.Config{
+		Encode: encodeLine,
+		Sizes:  protoscanbench.Uniform(0, 10),
+		Tokens: 100,
+	})
+	if n := bytes.Count(stream, []byte{'\n'}); n != 100 {
+		t.Fatalf("expected 100 tokens; got %d", n)
+	}
+	if len(stream) < 100 || len(stream) > 1100 {
+		t.Fatalf("unexpected stream length %d", len(stream))
+	}
+}
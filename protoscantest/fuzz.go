@@ -0,0 +1,268 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscantest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/protoscan/protoscan"
+)
+
+// Fuzz returns the fuzz target asserting the invariants of the split
+// function for arbitrary input: the split function does not panic, does not
+// return a negative hint or an advance beyond the data, the scan does not
+// stop with protoscan.ErrNoProgress, and the tokens and the error do not
+// depend on the fragmentation of the input. Typical usage:
+//
+//	func FuzzSplit(f *testing.F) {
+//		protoscantest.AddCorpus(f, protoscantest.LinesCorpus())
+//		f.Fuzz(protoscantest.Fuzz(split))
+//	}
+func Fuzz(split protoscan.SplitFunc, opts ...protoscan.Option) func(t *testing.T, data []byte) {
+	return func(t *testing.T, data []byte) {
+		t.Helper()
+		fs := Fragmentations()
+		want, wantErr := scan(t, split, fs[0].Reader(data), opts)
+		if errors.Is(wantErr, protoscan.ErrNoProgress) {
+			t.Fatalf("%s: %v", fs[0].Name, wantErr)
+		}
+		for _, f := range fs[1:] {
+			got, err := scan(t, split, f.Reader(data), opts)
+			if errors.Is(err, protoscan.ErrNoProgress) {
+				t.Fatalf("%s: %v", f.Name, err)
+			}
+			if (err == nil) != (wantErr == nil) {
+				t.Fatalf("%s: expected error %v; got %v", f.Name, wantErr, err)
+			}
+			if !equal(got, want) {
+				t.Fatalf("%s: expected tokens %q; got %q", f.Name, want, got)
+			}
+		}
+	}
+}
+
+// FuzzContext returns the fuzz target asserting the invariants of Fuzz for
+// the split function receiving the context of the scan, the Indexes of the
+// tokens included, which must not depend on the fragmentation either.
+// Typical usage:
+//
+//	func FuzzSplit(f *testing.F) {
+//		protoscantest.AddCorpus(f, protoscantest.GRPCCorpus())
+//		f.Fuzz(protoscantest.FuzzContext(protoscan.ScanGRPC))
+//	}
+func FuzzContext(split protoscan.SplitCtxFunc, opts ...protoscan.Option) func(t *testing.T, data []byte) {
+	return func(t *testing.T, data []byte) {
+		t.Helper()
+		fs := Fragmentations()
+		want, wantErr := scanContext(t, split, fs[0].Reader(data), opts)
+		if errors.Is(wantErr, protoscan.ErrNoProgress) {
+			t.Fatalf("%s: %v", fs[0].Name, wantErr)
+		}
+		for _, f := range fs[1:] {
+			got, err := scanContext(t, split, f.Reader(data), opts)
+			if errors.Is(err, protoscan.ErrNoProgress) {
+				t.Fatalf("%s: %v", f.Name, err)
+			}
+			if (err == nil) != (wantErr == nil) {
+				t.Fatalf("%s: expected error %v; got %v", f.Name, wantErr, err)
+			}
+			if !equal(got, want) {
+				t.Fatalf("%s: expected tokens %q; got %q", f.Name, want, got)
+			}
+		}
+	}
+}
+
+// AddCorpus adds the seeds to the seed corpus of the fuzz test.
+func AddCorpus(f *testing.F, seeds [][]byte) {
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+}
+
+// LinesCorpus returns the seed corpus of the protoscan.ScanLines.
+func LinesCorpus() [][]byte {
+	return [][]byte{
+		nil,
+		[]byte("\n"),
+		[]byte("\r\n"),
+		[]byte("abc"),
+		[]byte("abc\ndef\r\n\nghi\r"),
+		bytes.Repeat([]byte("lorem ipsum\r\n"), 16),
+	}
+}
+
+// WordsCorpus returns the seed corpus of the protoscan.ScanWords.
+func WordsCorpus() [][]byte {
+	return [][]byte{
+		nil,
+		[]byte(" "),
+		[]byte("abc"),
+		[]byte(" abc\tdef\nghi\rjkl\fmno\vpqr\u0085stu \n"),
+		[]byte(" lorem　ipsum "),
+	}
+}
+
+// RunesCorpus returns the seed corpus of the protoscan.ScanRunes.
+func RunesCorpus() [][]byte {
+	corpus := [][]byte{
+		nil,
+		[]byte("abc¼☹\x81�日本語\x82abc"),
+		{0xf0, 0x9f},
+		{0xed, 0xa0, 0x80},
+	}
+	for _, r := range []rune{0x7f, 0x80, 0x7ff, 0x800, 0xffff, 0x10000, utf8.MaxRune} {
+		corpus = append(corpus, utf8.AppendRune(nil, r))
+	}
+	return corpus
+}
+
+// le32 returns the little-endian 4-byte integers.
+func le32(v ...int) string {
+	var b []byte
+	for _, v := range v {
+		b = binary.LittleEndian.AppendUint32(b, uint32(v))
+	}
+	return string(b)
+}
+
+// be16 returns the big-endian 2-byte integers.
+func be16(v ...int) string {
+	var b []byte
+	for _, v := range v {
+		b = binary.BigEndian.AppendUint16(b, uint16(v))
+	}
+	return string(b)
+}
+
+// seeds returns the seeds of the strings, with the empty seed and the
+// stream of all the strings.
+func seeds(s ...string) [][]byte {
+	corpus := [][]byte{nil, []byte(strings.Join(s, ""))}
+	for _, s := range s {
+		corpus = append(corpus, []byte(s))
+	}
+	return corpus
+}
+
+// ClickHouseCorpus returns the seed corpus of the client side of the
+// protoscan.ScanClickHouseNative.
+func ClickHouseCorpus() [][]byte {
+	packet := func(fields ...interface{}) string {
+		var b []byte
+		for _, f := range fields {
+			switch f := f.(type) {
+			case int:
+				b = binary.AppendUvarint(b, uint64(f))
+			case string:
+				b = binary.AppendUvarint(b, uint64(len(f)))
+				b = append(b, f...)
+			}
+		}
+		return string(b)
+	}
+	// The empty data block, uncompressed without the query enabling the
+	// compression.
+	block := "\x01\x00\x02\xff\xff\xff\xff\x00" + packet(0, 0)
+	return seeds(
+		packet(0, "cli", 23, 8, 54460, "default", "default", "")+packet(""),
+		packet(2, "")+block,
+		packet(4),
+	)
+}
+
+// CQLCorpus returns the seed corpus of the protoscan.ScanCQLFrame.
+func CQLCorpus() [][]byte {
+	frame := func(version, opcode byte, body string) string {
+		b := []byte{version, 0, 0, 1, opcode}
+		if version&0x7f < 3 {
+			b = []byte{version, 0, 1, opcode}
+		}
+		return string(binary.BigEndian.AppendUint32(b, uint32(len(body)))) + body
+	}
+	return seeds(
+		frame(0x04, 0x07, "\x00\x00\x00\x08SELECT 1\x00\x01"),
+		frame(0x84, 0x08, "\x00\x00\x00\x01"),
+		frame(0x02, 0x05, ""),
+		frame(0x05, 0x01, "\x00\x01\x00\x0bCQL_VERSION\x00\x053.0.0"),
+	)
+}
+
+// GRPCCorpus returns the seed corpus of the protoscan.ScanGRPC.
+func GRPCCorpus() [][]byte {
+	return seeds(
+		"\x00\x00\x00\x00\x03abc",
+		"\x01\x00\x00\x00\x02gz",
+		"\x00\x00\x00\x00\x00",
+	)
+}
+
+// IPFIXCorpus returns the seed corpus of the protoscan.ScanIPFIX, of the
+// IPFIX messages and the NetFlow v9 packets.
+func IPFIXCorpus() [][]byte {
+	templates := be16(0, 4+4+8, 256, 2, 8, 4, 7, 2)
+	data := be16(256, 4+2*6+2) + "aaaabbccccdd\x00\x00"
+	ipfix := func(sets string) string { return be16(10, 16+len(sets)) + strings.Repeat("\x00", 12) + sets }
+	netflow := func(count int, sets string) string { return be16(9, count) + strings.Repeat("\x00", 16) + sets }
+	return seeds(
+		ipfix(be16(2, 4)),
+		netflow(1, templates),
+		netflow(2, data),
+		ipfix(""),
+	)
+}
+
+// ISO8583Corpus returns the seed corpus of the ISO 8583 messages with the
+// 2-byte binary length header, of the fields of the 1987 version.
+func ISO8583Corpus() [][]byte {
+	msg := func(body string) string { return be16(len(body)) + body }
+	bitmap := "\x60\x20\x00\x00\x00\x01\x00\x00"
+	return seeds(
+		msg("0200"+bitmap+"164111111111111111"+"000000"+"000001"+"005hello"),
+		msg("0800"+"\x20\x20\x00\x00\x00\x00\x00\x00"+"990000"+"000001"),
+		msg("0200"+bitmap+"164111111111111111"+"000000"+"000001"+"009hello"),
+	)
+}
+
+// MongoCorpus returns the seed corpus of the protoscan.ScanMongoWire.
+func MongoCorpus() [][]byte {
+	msg := func(opCode, requestID, responseTo int, body string) string {
+		return le32(16+len(body), requestID, responseTo, opCode) + body
+	}
+	return seeds(
+		msg(2013, 1, 0, "\x00\x00\x00\x00\x00\x13\x00\x00\x00\x10hello\x00\x01\x00\x00\x00\x00"),
+		msg(2013, 7, 1, "\x00\x00\x00\x00\x00\x05\x00\x00\x00\x00"),
+		msg(2012, 8, 0, ""),
+	)
+}
+
+// NinePCorpus returns the seed corpus of the protoscan.Scan9P.
+func NinePCorpus() [][]byte {
+	msg := func(typ byte, tag int, body string) string {
+		return le32(7+len(body)) + string([]byte{typ, byte(tag), byte(tag >> 8)}) + body
+	}
+	return seeds(
+		msg(100, 0xffff, "\x00\x20\x00\x00\x06\x009P2000"),
+		msg(101, 0xffff, "\x00\x20\x00\x00\x06\x009P2000"),
+		msg(116, 1, "\x01\x00\x00\x00"+strings.Repeat("\x00", 12)),
+		msg(117, 1, "\x03\x00\x00\x00abc"),
+	)
+}
+
+// RTSPCorpus returns the seed corpus of the
+// protoscan.ScanRTSPInterleaved.
+func RTSPCorpus() [][]byte {
+	return seeds(
+		"RTSP/1.0 200 OK\r\nCSeq: 3\r\ncontent-length: 4\r\n\r\nv=0\n",
+		"$\x00\x00\x05rtp\r\n",
+		"$\x01\x00\x00",
+		"GET_PARAMETER rtsp://h/s RTSP/1.0\r\nCSeq: 4\r\n\r\n",
+	)
+}
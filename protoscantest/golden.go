@@ -0,0 +1,82 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscantest
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+)
+
+// UpdateGoldenEnv is the environment variable which, if set to a non-empty
+// value, makes the Golden rewrite the golden files instead of comparing.
+const UpdateGoldenEnv = "PROTOSCAN_UPDATE_GOLDEN"
+
+// Dump scans the reader with the split function and writes the canonical
+// dump of the tokens, a line per token holding the offset and the length of
+// the frame of the token followed by the Go-escaped token. The error which
+// stopped the scan, if any, is written on the last line.
+func Dump(w io.Writer, r io.Reader, split protoscan.SplitFunc, opts ...protoscan.Option) error {
+	bw := bufio.NewWriter(w)
+	s := protoscan.New(r, append(opts, protoscan.WithSplit(split))...)
+	for s.Scan() {
+		info := s.TokenInfo()
+		fmt.Fprintf(bw, "%08d %6d %q\n", info.Start, info.End-info.Start, s.Token())
+	}
+	if err := s.Err(); err != nil {
+		fmt.Fprintf(bw, "error: %v\n", err)
+	}
+	return bw.Flush()
+}
+
+// Golden dumps the tokens of the input file and compares the dump with
+// the golden file. The golden file is rewritten if the environment variable
+// named by the UpdateGoldenEnv is set.
+func Golden(t *testing.T, input, golden string, split protoscan.SplitFunc, opts ...protoscan.Option) {
+	t.Helper()
+	f, err := os.Open(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var got bytes.Buffer
+	if err := Dump(&got, f, split, opts...); err != nil {
+		t.Fatal(err)
+	}
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		if err := os.WriteFile(golden, got.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(got.Bytes(), want) {
+		return
+	}
+	gotLines := strings.Split(got.String(), "\n")
+	wantLines := strings.Split(string(want), "\n")
+	for i := 0; i < len(gotLines) || i < len(wantLines); i++ {
+		var g, w string
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if g != w {
+			t.Fatalf("%s:%d: dump differs from golden file (set %s=1 to update)\n-%s\n+%s",
+				golden, i+1, UpdateGoldenEnv, w, g)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package protoscantest provides utilities for testing split functions.
+package protoscantest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+)
+
+// Case is a test case of a split function.
+type Case struct {
+	Name   string   // Name of the case, the input is quoted if empty.
+	Input  string   // Data stream scanned.
+	Tokens []string // Tokens expected in order.
+	Err    error    // Error expected to stop the scan, matched by errors.Is.
+}
+
+// Fragmentation describes how the input of a case is delivered to the
+// Protoscan by the reads.
+type Fragmentation struct {
+	Name string
+	// Reader returns the reader of the input.
+	Reader func(input []byte) io.Reader
+}
+
+// Fragmentations returns the fragmentations of the input used by
+// the TestSplitFunc: whole input at once, the reads of every size from one
+// to eight bytes, random chunking and the reads returning the last bytes
+// together with io.EOF.
+func Fragmentations() []Fragmentation {
+	fs := []Fragmentation{{
+		Name:   "whole",
+		Reader: func(input []byte) io.Reader { return bytes.NewReader(input) },
+	}}
+	for n := 1; n <= 8; n++ {
+		n := n
+		fs = append(fs, Fragmentation{
+			Name:   fmt.Sprintf("chunk%d", n),
+			Reader: func(input []byte) io.Reader { return &chunkReader{data: input, sizes: []int{n}} },
+		})
+	}
+	for seed := int64(1); seed <= 4; seed++ {
+		seed := seed
+		fs = append(fs, Fragmentation{
+			Name: fmt.Sprintf("random%d", seed),
+			Reader: func(input []byte) io.Reader {
+				rnd := rand.New(rand.NewSource(seed))
+				sizes := make([]int, 64)
+				for i := range sizes {
+					sizes[i] = 1 + rnd.Intn(16)
+				}
+				return &chunkReader{data: input, sizes: sizes}
+			},
+		})
+	}
+	fs = append(fs, Fragmentation{
+		Name:   "early-eof",
+		Reader: func(input []byte) io.Reader { return &chunkReader{data: input, sizes: []int{3}, eof: true} },
+	})
+	return fs
+}
+
+// chunkReader reads the data in chunks of the sizes, repeating the sizes
+// as needed. It returns io.EOF along with the last chunk if eof is set.
+type chunkReader struct {
+	data  []byte
+	sizes []int
+	i     int
+	eof   bool
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	if n := r.sizes[r.i%len(r.sizes)]; n < len(p) {
+		p = p[:n]
+	}
+	r.i++
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	if r.eof && len(r.data) == 0 {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// TestSplitFunc runs the cases through every fragmentation returned by the
+// Fragmentations, checking the tokens and the error of each, and the values
+// returned by the split function on each call: the hint and the advance
+// must not be negative, the advance must not exceed the data.
+func TestSplitFunc(t *testing.T, split protoscan.SplitFunc, cases []Case, opts ...protoscan.Option) {
+	t.Helper()
+	for _, c := range cases {
+		name := c.Name
+		if name == "" {
+			name = fmt.Sprintf("%q", c.Input)
+		}
+		for _, f := range Fragmentations() {
+			t.Run(name+"/"+f.Name, func(t *testing.T) {
+				t.Helper()
+				tokens, err := scan(t, split, f.Reader([]byte(c.Input)), opts)
+				if !errors.Is(err, c.Err) {
+					t.Errorf("expected error %v; got %v", c.Err, err)
+				}
+				if !equal(tokens, c.Tokens) {
+					t.Errorf("expected tokens %q; got %q", c.Tokens, tokens)
+				}
+			})
+		}
+	}
+}
+
+// scan returns the tokens scanned by the checked split function.
+func scan(t *testing.T, split protoscan.SplitFunc, r io.Reader, opts []protoscan.Option) ([]string, error) {
+	t.Helper()
+	check := func(data []byte, atEOF bool) (int, int, []byte, error) {
+		hint, advance, token, err := split(data, atEOF)
+		if hint < 0 {
+			t.Errorf("negative hint %d on %q (atEOF %t)", hint, data, atEOF)
+		}
+		if advance < 0 || advance > len(data) {
+			t.Errorf("advance %d out of data %q (atEOF %t)", advance, data, atEOF)
+		}
+		return hint, advance, token, err
+	}
+	s := protoscan.New(r, append(opts, protoscan.WithSplit(check))...)
+	var tokens []string
+	for s.Scan() {
+		tokens = append(tokens, string(s.Token()))
+	}
+	return tokens, s.Err()
+}
+
+// scanContext returns the tokens scanned by the checked split function
+// receiving the context, each followed by its Indexes.
+func scanContext(t *testing.T, split protoscan.SplitCtxFunc, r io.Reader, opts []protoscan.Option) ([]string, error) {
+	t.Helper()
+	check := func(ctx *protoscan.SplitContext, data []byte, atEOF bool) (int, int, []byte, error) {
+		hint, advance, token, err := split(ctx, data, atEOF)
+		if hint < 0 {
+			t.Errorf("negative hint %d on %q (atEOF %t)", hint, data, atEOF)
+		}
+		if advance < 0 || advance > len(data) {
+			t.Errorf("advance %d out of data %q (atEOF %t)", advance, data, atEOF)
+		}
+		return hint, advance, token, err
+	}
+	s := protoscan.New(r, append(opts, protoscan.WithSplitContext(check))...)
+	var tokens []string
+	for s.Scan() {
+		tokens = append(tokens, fmt.Sprint(string(s.Token()), s.Indexes()))
+	}
+	return tokens, s.Err()
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
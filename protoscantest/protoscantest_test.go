@@ -0,0 +1,60 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscantest_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanLines(t *testing.T) {
+	protoscantest.TestSplitFunc(t, protoscan.ScanLines, []protoscantest.Case{
+		{Input: "", Tokens: nil},
+		{Input: "abc", Tokens: []string{"abc"}},
+		{Input: "abc\r\ndef\n\nghi", Tokens: []string{"abc", "def", "", "ghi"}},
+	})
+}
+
+func TestScanWords(t *testing.T) {
+	protoscantest.TestSplitFunc(t, protoscan.ScanWords, []protoscantest.Case{
+		{Input: "  lorem\tipsum \n dolor ", Tokens: []string{"lorem", "ipsum", "dolor"}},
+	})
+}
+
+var errDigit = errors.New("digit")
+
+func TestSplitError(t *testing.T) {
+	split := func(data []byte, atEOF bool) (int, int, []byte, error) {
+		if len(data) > 0 && '0' <= data[0] && data[0] <= '9' {
+			return 0, 0, nil, errDigit
+		}
+		return protoscan.ScanBytes(data, atEOF)
+	}
+	protoscantest.TestSplitFunc(t, split, []protoscantest.Case{
+		{Name: "digit", Input: "ab1c", Tokens: []string{"a", "b"}, Err: errDigit},
+	})
+}
+
+func FuzzScanLines(f *testing.F) {
+	protoscantest.AddCorpus(f, protoscantest.LinesCorpus())
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanLines))
+}
+
+func FuzzScanWords(f *testing.F) {
+	protoscantest.AddCorpus(f, protoscantest.WordsCorpus())
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanWords))
+}
+
+func FuzzScanRunes(f *testing.F) {
+	protoscantest.AddCorpus(f, protoscantest.RunesCorpus())
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanRunes))
+}
+
+func TestGolden(t *testing.T) {
+	protoscantest.Golden(t, "testdata/lines.txt", "testdata/lines.golden", protoscan.ScanLines)
+}
@@ -0,0 +1,111 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscantest
+
+import (
+	"io"
+	"math/rand"
+	"time"
+)
+
+// SlowReader is a reader that returns only a few bytes at a time, to test
+// the incremental reads in Protoscan.Scan.
+type SlowReader struct {
+	Max int       // Maximum number of bytes returned by a read.
+	R   io.Reader // Underlying reader.
+}
+
+func (sr *SlowReader) Read(p []byte) (int, error) {
+	if len(p) > sr.Max {
+		p = p[0:sr.Max]
+	}
+	return sr.R.Read(p)
+}
+
+// RandomReader is a reader that returns short reads of random size.
+type RandomReader struct {
+	Max  int        // Maximum number of bytes returned by a read.
+	R    io.Reader  // Underlying reader.
+	Rand *rand.Rand // Source of the sizes of reads, the global one if nil.
+}
+
+func (rr *RandomReader) Read(p []byte) (int, error) {
+	var n int
+	if rr.Rand != nil {
+		n = 1 + rr.Rand.Intn(rr.Max)
+	} else {
+		n = 1 + rand.Intn(rr.Max)
+	}
+	if len(p) > n {
+		p = p[0:n]
+	}
+	return rr.R.Read(p)
+}
+
+// ErrorReader is a reader that fails with the error once N bytes were read.
+type ErrorReader struct {
+	N   int64     // Number of bytes read successfully.
+	Err error     // Error returned after N bytes, and forever after.
+	R   io.Reader // Underlying reader.
+}
+
+func (er *ErrorReader) Read(p []byte) (int, error) {
+	if er.N <= 0 {
+		return 0, er.Err
+	}
+	if int64(len(p)) > er.N {
+		p = p[0:er.N]
+	}
+	n, err := er.R.Read(p)
+	er.N -= int64(n)
+	return n, err
+}
+
+// DelayReader is a reader that sleeps before each read.
+type DelayReader struct {
+	Delay time.Duration // Duration of the sleep.
+	R     io.Reader     // Underlying reader.
+}
+
+func (dr *DelayReader) Read(p []byte) (int, error) {
+	time.Sleep(dr.Delay)
+	return dr.R.Read(p)
+}
+
+// EndlessZeros is a reader that returns zero bytes and no error forever.
+type EndlessZeros struct{}
+
+func (EndlessZeros) Read(p []byte) (int, error) {
+	return 0, nil
+}
+
+// NegativeEOFReader returns the lines of 'a' of its length in total,
+// then an invalid -1 at the end, as though it were wrapping the read system
+// call (issue https://github.com/golang/go/issues/38053).
+type NegativeEOFReader int
+
+func (r *NegativeEOFReader) Read(p []byte) (int, error) {
+	if *r > 0 {
+		c := int(*r)
+		if c > len(p) {
+			c = len(p)
+		}
+		for i := 0; i < c; i++ {
+			p[i] = 'a'
+		}
+		p[c-1] = '\n'
+		*r -= NegativeEOFReader(c)
+		return c, nil
+	}
+	return -1, io.EOF
+}
+
+// LargeReader returns an invalid count that is larger than the number
+// of bytes requested.
+type LargeReader struct{}
+
+func (LargeReader) Read(p []byte) (int, error) {
+	return len(p) + 1, nil
+}
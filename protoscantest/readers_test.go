@@ -0,0 +1,69 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscantest_test
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+var errInjected = errors.New("injected")
+
+// Test that the error injected in the middle of the stream stops the scan
+// after the tokens read before it.
+func TestErrorReader(t *testing.T) {
+	s := protoscan.New(
+		&protoscantest.ErrorReader{N: 9, Err: errInjected, R: strings.NewReader("abc\ndef\nghi\n")},
+		protoscan.WithSplit(protoscan.ScanLines),
+	)
+	var lines []string
+	for s.Scan() {
+		lines = append(lines, string(s.Token()))
+	}
+	if s.Err() != errInjected {
+		t.Fatalf("expected %v; got %v", errInjected, s.Err())
+	}
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines; got %q", lines)
+	}
+}
+
+func TestRandomReader(t *testing.T) {
+	const text = "lorem ipsum dolor sit amet"
+	r := &protoscantest.RandomReader{Max: 3, R: strings.NewReader(text), Rand: rand.New(rand.NewSource(1))}
+	p := make([]byte, 8)
+	var got []byte
+	for {
+		n, err := r.Read(p)
+		if n < 0 || n > 3 {
+			t.Fatalf("unexpected count %d", n)
+		}
+		got = append(got, p[:n]...)
+		if err == io.EOF {
+			break
+		}
+	}
+	if string(got) != text {
+		t.Fatalf("unexpected data %q", got)
+	}
+}
+
+func TestDelayReader(t *testing.T) {
+	r := &protoscantest.DelayReader{Delay: 10 * time.Millisecond, R: strings.NewReader("a")}
+	start := time.Now()
+	if _, err := r.Read(make([]byte, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) < 10*time.Millisecond {
+		t.Fatal("read is not delayed")
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pscan
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/netip"
+	"time"
+)
+
+// ErrPcap is returned when the pcap capture is malformed or uses the
+// unsupported link type.
+var ErrPcap = errors.New("pscan: malformed or unsupported pcap capture")
+
+// ErrGap is returned by the ConvertPcap when the TCP stream misses the
+// segments which were not captured.
+var ErrGap = errors.New("pscan: gap in the TCP stream")
+
+// Flow is the direction of a TCP connection.
+type Flow struct {
+	Src, Dst netip.AddrPort
+}
+
+// Link types of the pcap captures.
+const (
+	linkNull     = 0
+	linkEthernet = 1
+	linkRaw      = 101
+	linkLinuxSLL = 113
+)
+
+// ConvertPcap writes the TCP stream of the first flow in the classic
+// libpcap capture for which match returns true, or of the first flow
+// carrying data if match is nil, to the capture. The payload of each
+// segment is written as a read at the time of the packet, in the order of
+// the sequence numbers, with the retransmitted bytes removed. It returns the
+// converted flow.
+func ConvertPcap(w *Writer, r io.Reader, match func(Flow) bool) (Flow, error) {
+	var header [24]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return Flow{}, ErrPcap
+	}
+	var order binary.ByteOrder
+	var nano bool
+	switch binary.LittleEndian.Uint32(header[:]) {
+	case 0xa1b2c3d4:
+		order = binary.LittleEndian
+	case 0xd4c3b2a1:
+		order = binary.BigEndian
+	case 0xa1b23c4d:
+		order, nano = binary.LittleEndian, true
+	case 0x4d3cb2a1:
+		order, nano = binary.BigEndian, true
+	default:
+		return Flow{}, ErrPcap
+	}
+	link := order.Uint32(header[20:])
+	c := converter{w: w, match: match}
+	var record [16]byte
+	for {
+		if _, err := io.ReadFull(r, record[:]); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return c.flow, ErrPcap
+		}
+		sec, frac := order.Uint32(record[:]), order.Uint32(record[4:])
+		if !nano {
+			frac *= 1000
+		}
+		n := order.Uint32(record[8:])
+		if n > maxRead {
+			return c.flow, ErrPcap
+		}
+		packet := make([]byte, n)
+		if _, err := io.ReadFull(r, packet); err != nil {
+			return c.flow, ErrPcap
+		}
+		ip, ok := linkPayload(link, packet)
+		if !ok {
+			return c.flow, ErrPcap
+		}
+		if err := c.packet(ip, time.Unix(int64(sec), int64(frac))); err != nil {
+			return c.flow, err
+		}
+	}
+	if len(c.pending) > 0 {
+		return c.flow, ErrGap
+	}
+	return c.flow, w.Flush()
+}
+
+// linkPayload returns the IP packet of the link layer frame, or nil if the
+// frame does not carry the IP packet.
+func linkPayload(link uint32, frame []byte) ([]byte, bool) {
+	switch link {
+	case linkNull:
+		if len(frame) < 4 {
+			return nil, true
+		}
+		return frame[4:], true
+	case linkEthernet:
+		if len(frame) < 14 {
+			return nil, true
+		}
+		typ, frame := binary.BigEndian.Uint16(frame[12:]), frame[14:]
+		for typ == 0x8100 && len(frame) >= 4 {
+			// Skip the VLAN tag.
+			typ, frame = binary.BigEndian.Uint16(frame[2:]), frame[4:]
+		}
+		if typ != 0x0800 && typ != 0x86dd {
+			return nil, true
+		}
+		return frame, true
+	case linkRaw:
+		return frame, true
+	case linkLinuxSLL:
+		if len(frame) < 16 {
+			return nil, true
+		}
+		return frame[16:], true
+	}
+	return nil, false
+}
+
+// segment is the TCP payload waiting for the missing preceding segments.
+type segment struct {
+	data []byte
+	time time.Time
+}
+
+// converter reassembles the TCP stream of the flow.
+type converter struct {
+	w       *Writer
+	match   func(Flow) bool
+	flow    Flow
+	found   bool
+	next    uint32 // Sequence number of the next byte of the stream.
+	pending map[uint32]segment
+}
+
+func (c *converter) packet(ip []byte, t time.Time) error {
+	var flow Flow
+	var tcp []byte
+	switch {
+	case len(ip) >= 20 && ip[0]>>4 == 4:
+		ihl := int(ip[0]&0x0f) * 4
+		total := int(binary.BigEndian.Uint16(ip[2:]))
+		if ip[9] != 6 || ihl < 20 || total < ihl || total > len(ip) {
+			return nil
+		}
+		if binary.BigEndian.Uint16(ip[6:])&0x3fff != 0 {
+			// Fragments are not reassembled.
+			return nil
+		}
+		src, _ := netip.AddrFromSlice(ip[12:16])
+		dst, _ := netip.AddrFromSlice(ip[16:20])
+		flow.Src, flow.Dst = netip.AddrPortFrom(src, 0), netip.AddrPortFrom(dst, 0)
+		tcp = ip[ihl:total]
+	case len(ip) >= 40 && ip[0]>>4 == 6:
+		total := 40 + int(binary.BigEndian.Uint16(ip[4:]))
+		if ip[6] != 6 || total > len(ip) {
+			return nil
+		}
+		src, _ := netip.AddrFromSlice(ip[8:24])
+		dst, _ := netip.AddrFromSlice(ip[24:40])
+		flow.Src, flow.Dst = netip.AddrPortFrom(src, 0), netip.AddrPortFrom(dst, 0)
+		tcp = ip[40:total]
+	default:
+		return nil
+	}
+	if len(tcp) < 20 {
+		return nil
+	}
+	off := int(tcp[12]>>4) * 4
+	if off < 20 || off > len(tcp) {
+		return nil
+	}
+	flow.Src = netip.AddrPortFrom(flow.Src.Addr(), binary.BigEndian.Uint16(tcp[0:]))
+	flow.Dst = netip.AddrPortFrom(flow.Dst.Addr(), binary.BigEndian.Uint16(tcp[2:]))
+	seq := binary.BigEndian.Uint32(tcp[4:])
+	syn := tcp[13]&0x02 != 0
+	payload := tcp[off:]
+	if !c.found {
+		if c.match != nil && !c.match(flow) || c.match == nil && len(payload) == 0 {
+			return nil
+		}
+		c.flow, c.found, c.next = flow, true, seq
+		c.pending = make(map[uint32]segment)
+	} else if flow != c.flow {
+		return nil
+	}
+	if syn {
+		c.next = seq + 1
+		return nil
+	}
+	if len(payload) == 0 {
+		return nil
+	}
+	c.pending[seq] = segment{data: payload, time: t}
+	return c.flush()
+}
+
+// flush writes the pending segments which continue the stream.
+func (c *converter) flush() error {
+	for progress := true; progress; {
+		progress = false
+		for seq, seg := range c.pending {
+			skip := int32(c.next - seq)
+			if skip < 0 {
+				continue
+			}
+			delete(c.pending, seq)
+			progress = true
+			if int(skip) >= len(seg.data) {
+				// Retransmission of the written bytes.
+				continue
+			}
+			data := seg.data[skip:]
+			if err := c.w.WriteRead(data, seg.time); err != nil {
+				return err
+			}
+			c.next += uint32(len(data))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pscan_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/protoscan/protoscan/pscan"
+)
+
+// pcapWriter writes the Ethernet IPv4 TCP packets to the pcap capture.
+type pcapWriter struct {
+	bytes.Buffer
+	sec uint32
+}
+
+func newPcap() *pcapWriter {
+	w := new(pcapWriter)
+	header := make([]byte, 24)
+	binary.LittleEndian.PutUint32(header, 0xa1b2c3d4)
+	binary.LittleEndian.PutUint16(header[4:], 2)
+	binary.LittleEndian.PutUint16(header[6:], 4)
+	binary.LittleEndian.PutUint32(header[16:], 65535)
+	binary.LittleEndian.PutUint32(header[20:], 1)
+	w.Write(header)
+	return w
+}
+
+func (w *pcapWriter) packet(src, dst uint16, seq uint32, flags byte, payload string) {
+	tcp := make([]byte, 20, 20+len(payload))
+	binary.BigEndian.PutUint16(tcp, src)
+	binary.BigEndian.PutUint16(tcp[2:], dst)
+	binary.BigEndian.PutUint32(tcp[4:], seq)
+	tcp[12] = 5 << 4
+	tcp[13] = flags
+	tcp = append(tcp, payload...)
+	ip := make([]byte, 20, 20+len(tcp))
+	ip[0] = 0x45
+	binary.BigEndian.PutUint16(ip[2:], uint16(20+len(tcp)))
+	ip[9] = 6
+	copy(ip[12:], []byte{10, 0, 0, 1})
+	copy(ip[16:], []byte{10, 0, 0, 2})
+	ip = append(ip, tcp...)
+	frame := append(make([]byte, 12), 0x08, 0x00)
+	frame = append(frame, ip...)
+	w.sec++
+	record := make([]byte, 16)
+	binary.LittleEndian.PutUint32(record, w.sec)
+	binary.LittleEndian.PutUint32(record[8:], uint32(len(frame)))
+	binary.LittleEndian.PutUint32(record[12:], uint32(len(frame)))
+	w.Write(record)
+	w.Write(frame)
+}
+
+const (
+	syn = 0x02
+	ack = 0x10
+)
+
+func TestConvertPcap(t *testing.T) {
+	p := newPcap()
+	p.packet(40000, 80, 99, syn, "")
+	p.packet(80, 40000, 500, syn|ack, "")
+	p.packet(40000, 80, 100, ack, "GET / ")
+	p.packet(80, 40000, 501, ack, "noise")
+	// The segment out of order, then the retransmission of the overlap.
+	p.packet(40000, 80, 113, ack, "1\r\n\r\n")
+	p.packet(40000, 80, 103, ack, " / HTTP/1.")
+	p.packet(40000, 80, 100, ack, "GET / ")
+
+	var buf bytes.Buffer
+	w, err := pscan.NewWriter(&buf, time.Unix(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	flow, err := pscan.ConvertPcap(w, p, func(f pscan.Flow) bool { return f.Dst.Port() == 80 })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := netip.MustParseAddrPort("10.0.0.1:40000"); flow.Src != want {
+		t.Fatalf("unexpected flow %v", flow)
+	}
+	r, err := pscan.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reads []string
+	for {
+		data, ts, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		reads = append(reads, string(data)+"@"+ts.UTC().Format("05"))
+	}
+	if got := strings.Join(reads, "|"); got != "GET / @03|HTTP/1.@06|1\r\n\r\n@06" {
+		t.Fatalf("unexpected reads %q", got)
+	}
+}
+
+func TestConvertPcapGap(t *testing.T) {
+	p := newPcap()
+	p.packet(40000, 80, 100, ack, "abc")
+	p.packet(40000, 80, 110, ack, "xyz")
+	w, err := pscan.NewWriter(io.Discard, time.Unix(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pscan.ConvertPcap(w, p, nil); !errors.Is(err, pscan.ErrGap) {
+		t.Fatalf("expected ErrGap; got %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pscan implements the .pscan capture format, which records the
+// bytes of a data stream together with the boundaries and the times of the
+// reads, so that the fragmentation-dependent framing bugs may be reproduced
+// by replaying the identical reads.
+//
+// The capture starts with the 8-byte header "PSCAN", the version byte and
+// two zero bytes, followed by the start time as the big-endian int64 count
+// of nanoseconds since the Unix epoch. Each read is then recorded as the
+// uvarint count of nanoseconds since the previous read, or since the start
+// for the first one, the uvarint size and the bytes of the read.
+package pscan
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/protoscan/protoscan"
+)
+
+// version is the version of the format.
+const version = 1
+
+var magic = []byte("PSCAN")
+
+// ErrFormat is returned when the capture is malformed.
+var ErrFormat = errors.New("pscan: malformed capture")
+
+// maxRead is the maximum size of the recorded read.
+const maxRead = 1 << 24
+
+// Writer writes the reads to the capture.
+type Writer struct {
+	w    *bufio.Writer
+	last time.Time
+	buf  []byte
+}
+
+// NewWriter writes the header of the capture started at the time and
+// returns the Writer of the capture.
+func NewWriter(w io.Writer, start time.Time) (*Writer, error) {
+	cw := &Writer{w: bufio.NewWriter(w), last: start}
+	header := append(append([]byte(nil), magic...), version, 0, 0)
+	header = binary.BigEndian.AppendUint64(header, uint64(start.UnixNano()))
+	if _, err := cw.w.Write(header); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+// WriteRead writes the read of the data at the time, which must not be
+// before the time of the previous read.
+func (w *Writer) WriteRead(data []byte, t time.Time) error {
+	if len(data) > maxRead {
+		return fmt.Errorf("pscan: read of %d bytes exceeds maximum of %d", len(data), maxRead)
+	}
+	d := t.Sub(w.last)
+	if d < 0 {
+		d = 0
+	}
+	w.last = w.last.Add(d)
+	w.buf = binary.AppendUvarint(w.buf[:0], uint64(d))
+	w.buf = binary.AppendUvarint(w.buf, uint64(len(data)))
+	if _, err := w.w.Write(w.buf); err != nil {
+		return err
+	}
+	_, err := w.w.Write(data)
+	return err
+}
+
+// Flush writes the buffered reads to the underlying writer.
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+// Recorder reads from the reader and writes each of the reads to the
+// capture, so that the reads of a Protoscan may be captured.
+type Recorder struct {
+	R   io.Reader // Underlying reader.
+	W   *Writer   // Writer of the capture.
+	Err error     // First error of the writes of the capture.
+}
+
+func (r *Recorder) Read(p []byte) (int, error) {
+	n, _, err := r.ReadTimestamped(p)
+	return n, err
+}
+
+// ReadTimestamped reads from the underlying reader recording the time of
+// the read, which is the one passed through from the underlying reader if
+// it is the protoscan.TimestampedReader.
+func (r *Recorder) ReadTimestamped(p []byte) (int, time.Time, error) {
+	var n int
+	var ts time.Time
+	var err error
+	if tr, ok := r.R.(protoscan.TimestampedReader); ok {
+		n, ts, err = tr.ReadTimestamped(p)
+	} else {
+		n, err = r.R.Read(p)
+	}
+	if n > 0 && r.Err == nil {
+		t := ts
+		if t.IsZero() {
+			t = time.Now()
+		}
+		r.Err = r.W.WriteRead(p[:n], t)
+	}
+	return n, ts, err
+}
+
+// Reader replays the reads of the capture.
+type Reader struct {
+	r       *bufio.Reader
+	start   time.Time
+	last    time.Time
+	pending []byte    // Rest of the current read not yet returned.
+	time    time.Time // Time of the current read.
+	err     error
+}
+
+// NewReader reads the header of the capture and returns the Reader of the
+// capture.
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+	var header [16]byte
+	if _, err := io.ReadFull(br, header[:]); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrFormat
+		}
+		return nil, err
+	}
+	if string(header[:5]) != string(magic) || header[5] != version {
+		return nil, ErrFormat
+	}
+	start := time.Unix(0, int64(binary.BigEndian.Uint64(header[8:])))
+	return &Reader{r: br, start: start, last: start}, nil
+}
+
+// Start returns the start time of the capture.
+func (r *Reader) Start() time.Time {
+	return r.start
+}
+
+// Next returns the data and the time of the next read of the capture, or
+// io.EOF at the end of the capture. The data is valid until the next call.
+func (r *Reader) Next() ([]byte, time.Time, error) {
+	if r.err != nil {
+		return nil, time.Time{}, r.err
+	}
+	d, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		if err != io.EOF {
+			err = ErrFormat
+		}
+		r.err = err
+		return nil, time.Time{}, err
+	}
+	n, err := binary.ReadUvarint(r.r)
+	if err != nil || n > maxRead {
+		r.err = ErrFormat
+		return nil, time.Time{}, r.err
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		r.err = ErrFormat
+		return nil, time.Time{}, r.err
+	}
+	r.last = r.last.Add(time.Duration(d))
+	return data, r.last, nil
+}
+
+// Read returns the bytes of the next read of the capture, so the reads of
+// a Protoscan are the recorded ones as long as p is large enough to hold
+// them.
+func (r *Reader) Read(p []byte) (int, error) {
+	n, _, err := r.ReadTimestamped(p)
+	return n, err
+}
+
+// ReadTimestamped is the Read which returns also the recorded time of the
+// read, so that a Protoscan replays the times in its TokenInfo.
+func (r *Reader) ReadTimestamped(p []byte) (int, time.Time, error) {
+	for len(r.pending) == 0 {
+		data, t, err := r.Next()
+		if err != nil {
+			return 0, time.Time{}, err
+		}
+		r.pending, r.time = data, t
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, r.time, nil
+}
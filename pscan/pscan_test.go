@@ -0,0 +1,127 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pscan_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+	"github.com/protoscan/protoscan/pscan"
+)
+
+func TestRoundTrip(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	var buf bytes.Buffer
+	w, err := pscan.NewWriter(&buf, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reads := []string{"ab", "c\nd", "", "ef\n"}
+	for i, read := range reads {
+		if err := w.WriteRead([]byte(read), start.Add(time.Duration(i)*time.Millisecond)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := pscan.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Start().Equal(start) {
+		t.Fatalf("unexpected start %v", r.Start())
+	}
+	for i, read := range reads {
+		data, ts, err := r.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != read {
+			t.Fatalf("%d: expected read %q; got %q", i, read, data)
+		}
+		if want := start.Add(time.Duration(i) * time.Millisecond); !ts.Equal(want) {
+			t.Fatalf("%d: expected time %v; got %v", i, want, ts)
+		}
+	}
+	if _, _, err := r.Next(); err != io.EOF {
+		t.Fatalf("expected EOF; got %v", err)
+	}
+}
+
+// Test that the replayed reads are the recorded ones.
+func TestRecorderReplay(t *testing.T) {
+	text := "lorem ipsum\ndolor sit\namet\n"
+	var buf bytes.Buffer
+	w, err := pscan.NewWriter(&buf, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := &pscan.Recorder{R: &protoscantest.SlowReader{Max: 4, R: strings.NewReader(text)}, W: w}
+	var recorded []string
+	for s := protoscan.New(rec, protoscan.WithSplit(protoscan.ScanLines)); s.Scan(); {
+		recorded = append(recorded, string(s.Token()))
+	}
+	if rec.Err != nil {
+		t.Fatal(rec.Err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := pscan.NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reads []string
+	for {
+		data, _, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) > 4 {
+			t.Fatalf("unexpected read %q larger than the recorded reads", data)
+		}
+		reads = append(reads, string(data))
+	}
+	if got := strings.Join(reads, ""); got != text {
+		t.Fatalf("unexpected recorded data %q", got)
+	}
+	r, err = pscan.NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var replayed []string
+	s := protoscan.New(r, protoscan.WithSplit(protoscan.ScanLines))
+	for s.Scan() {
+		replayed = append(replayed, string(s.Token()))
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	if got, want := strings.Join(replayed, "|"), strings.Join(recorded, "|"); got != want {
+		t.Fatalf("expected tokens %q; got %q", want, got)
+	}
+}
+
+func TestMalformed(t *testing.T) {
+	for _, capture := range []string{"", "PSCAN", "PSCAM\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00", "PSCAN\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x05ab"} {
+		r, err := pscan.NewReader(strings.NewReader(capture))
+		if err == nil {
+			_, _, err = r.Next()
+		}
+		if !errors.Is(err, pscan.ErrFormat) {
+			t.Fatalf("%q: expected ErrFormat; got %v", capture, err)
+		}
+	}
+}
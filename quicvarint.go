@@ -0,0 +1,53 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// ScanVarintQUIC returns the split function for a Protoscan that returns
+// each payload prefixed by its length in the RFC 9000 variable-length
+// integer encoding: the two most significant bits of the first byte tell
+// the length of the integer, 1, 2, 4 or 8 bytes. The payload longer than
+// the max, if positive, stops the scan with the ErrTooLong.
+func ScanVarintQUIC(max int) SplitFunc {
+	return func(data []byte, atEOF bool) (int, int, []byte, error) {
+		if len(data) == 0 {
+			if atEOF {
+				return 0, 0, nil, nil
+			}
+			return 1, 0, nil, nil
+		}
+		size := 1 << (data[0] >> 6)
+		if len(data) < size {
+			if atEOF {
+				return 0, 0, nil, io.ErrUnexpectedEOF
+			}
+			return size - len(data), 0, nil, nil
+		}
+		length := uint64(data[0] & 0x3f)
+		for _, b := range data[1:size] {
+			length = length<<8 | uint64(b)
+		}
+		limit := uint64(math.MaxInt - size)
+		if max > 0 {
+			limit = uint64(max)
+		}
+		if length > limit {
+			return 0, 0, nil, fmt.Errorf("%w: payload of %d bytes exceeds maximum of %d", ErrTooLong, length, limit)
+		}
+		n := size + int(length)
+		if len(data) < n {
+			if atEOF {
+				return 0, 0, nil, io.ErrUnexpectedEOF
+			}
+			return n - len(data), 0, nil, nil
+		}
+		return 0, n, data[size:n], nil
+	}
+}
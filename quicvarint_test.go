@@ -0,0 +1,36 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanVarintQUIC(t *testing.T) {
+	long := strings.Repeat("x", 300)
+	protoscantest.TestSplitFunc(t, protoscan.ScanVarintQUIC(0), []protoscantest.Case{
+		{Name: "empty"},
+		{Name: "1-byte", Input: "\x03abc\x00\x01d", Tokens: []string{"abc", "", "d"}},
+		{Name: "2-byte", Input: "\x41\x2c" + long, Tokens: []string{long}},
+		{Name: "4-byte", Input: "\x80\x00\x00\x02ab", Tokens: []string{"ab"}},
+		{Name: "8-byte", Input: "\xc0\x00\x00\x00\x00\x00\x00\x01a", Tokens: []string{"a"}},
+		{Name: "truncated prefix", Input: "\x01a\x80\x00", Tokens: []string{"a"}, Err: io.ErrUnexpectedEOF},
+		{Name: "truncated payload", Input: "\x05abc", Err: io.ErrUnexpectedEOF},
+	})
+	protoscantest.TestSplitFunc(t, protoscan.ScanVarintQUIC(2), []protoscantest.Case{
+		{Name: "max", Input: "\x02ab\x03abc", Tokens: []string{"ab"}, Err: protoscan.ErrTooLong},
+		{Name: "overflow", Input: "\xff\xff\xff\xff\xff\xff\xff\xff", Err: protoscan.ErrTooLong},
+	})
+}
+
+func FuzzScanVarintQUIC(f *testing.F) {
+	f.Add([]byte("\x03abc\x41\x01x"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanVarintQUIC(1 << 10)))
+}
@@ -0,0 +1,407 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Opcodes of the Redis RDB dump.
+const (
+	rdbSlotInfo     = 0xf4
+	rdbFunction2    = 0xf5
+	rdbModuleAux    = 0xf7
+	rdbIdle         = 0xf8
+	rdbFreq         = 0xf9
+	rdbAux          = 0xfa
+	rdbResizeDB     = 0xfb
+	rdbExpireTimeMS = 0xfc
+	rdbExpireTime   = 0xfd
+	rdbSelectDB     = 0xfe
+	rdbEOF          = 0xff
+)
+
+// rdbParser walks the entry of the RDB dump.
+type rdbParser struct {
+	cursor
+}
+
+// length returns the length encoded by the RDB length encoding, and whether
+// it is the special encoding of the string.
+func (p *rdbParser) length() (uint64, bool, error) {
+	b, err := p.readByte()
+	if err != nil {
+		return 0, false, err
+	}
+	switch b >> 6 {
+	case 0:
+		return uint64(b & 0x3f), false, nil
+	case 1:
+		c, err := p.readByte()
+		return uint64(b&0x3f)<<8 | uint64(c), false, err
+	case 3:
+		return uint64(b & 0x3f), true, nil
+	}
+	size := 4
+	switch b {
+	case 0x80:
+	case 0x81:
+		size = 8
+	default:
+		return 0, false, fmt.Errorf("%w: bad RDB length 0x%02x", ErrProtocolViolation, b)
+	}
+	if err := p.skip(uint64(size)); err != nil {
+		return 0, false, err
+	}
+	if size == 4 {
+		return uint64(binary.BigEndian.Uint32(p.data[p.pos-4:])), false, nil
+	}
+	return binary.BigEndian.Uint64(p.data[p.pos-8:]), false, nil
+}
+
+// count returns the length which is not the special encoding.
+func (p *rdbParser) count() (uint64, error) {
+	n, special, err := p.length()
+	if err == nil && special {
+		err = fmt.Errorf("%w: RDB special encoding of a length", ErrProtocolViolation)
+	}
+	return n, err
+}
+
+// skipString skips the string, either raw, the integer or the LZF
+// compressed.
+func (p *rdbParser) skipString() error {
+	n, special, err := p.length()
+	if err != nil {
+		return err
+	}
+	if !special {
+		return p.skip(n)
+	}
+	switch n {
+	case 0, 1, 2:
+		return p.skip(1 << n)
+	case 3:
+		clen, err := p.count()
+		if err != nil {
+			return err
+		}
+		if _, err := p.count(); err != nil {
+			return err
+		}
+		return p.skip(clen)
+	}
+	return fmt.Errorf("%w: bad RDB string encoding %d", ErrProtocolViolation, n)
+}
+
+// skipStrings skips n strings.
+func (p *rdbParser) skipStrings(n uint64) error {
+	for ; n > 0; n-- {
+		if err := p.skipString(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skipCounts skips n lengths.
+func (p *rdbParser) skipCounts(n int) error {
+	for ; n > 0; n-- {
+		if _, err := p.count(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skipValue skips the value of the type.
+func (p *rdbParser) skipValue(typ byte) error {
+	switch typ {
+	case 0, 9, 10, 11, 12, 13, 16, 17, 20:
+		// The string, or the ziplist, the listpack, the intset encoded as
+		// the string.
+		return p.skipString()
+	case 1, 2, 14:
+		n, err := p.count()
+		if err != nil {
+			return err
+		}
+		return p.skipStrings(n)
+	case 3:
+		n, err := p.count()
+		if err != nil {
+			return err
+		}
+		for ; n > 0; n-- {
+			if err := p.skipString(); err != nil {
+				return err
+			}
+			// The score as the string of the 1-byte length, or the NaN or
+			// the infinities.
+			b, err := p.readByte()
+			if err != nil {
+				return err
+			}
+			if b < 253 {
+				if err := p.skip(uint64(b)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case 4:
+		n, err := p.count()
+		if err != nil {
+			return err
+		}
+		if n > math.MaxUint64/2 {
+			return fmt.Errorf("%w: RDB hash of %d fields", ErrProtocolViolation, n)
+		}
+		return p.skipStrings(2 * n)
+	case 5:
+		n, err := p.count()
+		if err != nil {
+			return err
+		}
+		for ; n > 0; n-- {
+			if err := p.skipString(); err != nil {
+				return err
+			}
+			if err := p.skip(8); err != nil {
+				return err
+			}
+		}
+		return nil
+	case 7:
+		if _, err := p.count(); err != nil {
+			return err
+		}
+		return p.skipModule()
+	case 15, 19, 21:
+		return p.skipStream(typ)
+	case 18:
+		n, err := p.count()
+		if err != nil {
+			return err
+		}
+		for ; n > 0; n-- {
+			// The container and the listpack or the plain node.
+			if _, err := p.count(); err != nil {
+				return err
+			}
+			if err := p.skipString(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: unsupported RDB value type %d", ErrProtocolViolation, typ)
+}
+
+// skipModule skips the module value serialized by the module opcodes up
+// to the EOF opcode.
+func (p *rdbParser) skipModule() error {
+	for {
+		op, err := p.count()
+		if err != nil {
+			return err
+		}
+		switch op {
+		case 0:
+			return nil
+		case 1, 2:
+			_, err = p.count()
+		case 3:
+			err = p.skip(4)
+		case 4:
+			err = p.skip(8)
+		case 5:
+			err = p.skipString()
+		default:
+			err = fmt.Errorf("%w: bad RDB module opcode %d", ErrProtocolViolation, op)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// skipStream skips the stream of the type.
+func (p *rdbParser) skipStream(typ byte) error {
+	n, err := p.count()
+	if err != nil {
+		return err
+	}
+	// The listpacks keyed by the master IDs.
+	if n > math.MaxUint64/2 {
+		return fmt.Errorf("%w: RDB stream of %d listpacks", ErrProtocolViolation, n)
+	}
+	if err := p.skipStrings(2 * n); err != nil {
+		return err
+	}
+	// The length and the last ID, then the first ID, the max deleted ID
+	// and the entries added since the version 2.
+	ids := 3
+	if typ >= 19 {
+		ids += 5
+	}
+	if err := p.skipCounts(ids); err != nil {
+		return err
+	}
+	groups, err := p.count()
+	if err != nil {
+		return err
+	}
+	for ; groups > 0; groups-- {
+		if err := p.skipString(); err != nil {
+			return err
+		}
+		ids := 2
+		if typ >= 19 {
+			ids++
+		}
+		if err := p.skipCounts(ids); err != nil {
+			return err
+		}
+		pel, err := p.count()
+		if err != nil {
+			return err
+		}
+		for ; pel > 0; pel-- {
+			// The raw ID and the delivery time, and the delivery count.
+			if err := p.skip(16 + 8); err != nil {
+				return err
+			}
+			if _, err := p.count(); err != nil {
+				return err
+			}
+		}
+		consumers, err := p.count()
+		if err != nil {
+			return err
+		}
+		for ; consumers > 0; consumers-- {
+			if err := p.skipString(); err != nil {
+				return err
+			}
+			// The seen time, and the active time since the version 3.
+			times := uint64(8)
+			if typ >= 21 {
+				times += 8
+			}
+			if err := p.skip(times); err != nil {
+				return err
+			}
+			pel, err := p.count()
+			if err != nil {
+				return err
+			}
+			if pel > math.MaxUint64/16 {
+				return fmt.Errorf("%w: RDB consumer PEL of %d entries", ErrProtocolViolation, pel)
+			}
+			if err := p.skip(16 * pel); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// skipEntry skips the record of the dump: the auxiliary field, the database
+// selection or resize, the function, the module auxiliary data, or the
+// key-value entry with its expire time, idle time and frequency.
+func (p *rdbParser) skipEntry() (bool, error) {
+	for {
+		op, err := p.readByte()
+		if err != nil {
+			return false, err
+		}
+		switch op {
+		case rdbEOF:
+			// The checksum.
+			return true, p.skip(8)
+		case rdbAux:
+			return false, p.skipStrings(2)
+		case rdbSelectDB:
+			_, err := p.count()
+			return false, err
+		case rdbResizeDB:
+			return false, p.skipCounts(2)
+		case rdbSlotInfo:
+			return false, p.skipCounts(3)
+		case rdbFunction2:
+			return false, p.skipString()
+		case rdbModuleAux:
+			if err := p.skipCounts(3); err != nil {
+				return false, err
+			}
+			return false, p.skipModule()
+		case rdbExpireTimeMS:
+			err = p.skip(8)
+		case rdbExpireTime:
+			err = p.skip(4)
+		case rdbFreq:
+			err = p.skip(1)
+		case rdbIdle:
+			_, err = p.count()
+		default:
+			// The key-value entry.
+			if err := p.skipString(); err != nil {
+				return false, err
+			}
+			return false, p.skipValue(op)
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+}
+
+// ScanRDBEntries is a split function for a Protoscan that returns the
+// records of the Redis RDB dump as tokens: the "REDIS" magic and the
+// version, then each auxiliary field, database selection or resize, and
+// key-value entry with its expire time, idle time or frequency, and the
+// EOF opcode with the checksum as the FinalToken. The length encodings are
+// walked without decoding the values, hinting the exact count of the bytes
+// missing. The unknown opcodes and value types stop the scan with the
+// ErrProtocolViolation.
+func ScanRDBEntries(data []byte, atEOF bool) (int, int, []byte, error) {
+	if len(data) == 0 {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return 1, 0, nil, nil
+	}
+	if data[0] == 'R' {
+		if len(data) < 9 {
+			if atEOF {
+				return 0, 0, nil, io.ErrUnexpectedEOF
+			}
+			return 9 - len(data), 0, nil, nil
+		}
+		if string(data[:5]) != "REDIS" {
+			return 0, 0, nil, fmt.Errorf("%w: bad RDB magic %q", ErrProtocolViolation, data[:5])
+		}
+		return 0, 9, data[:9], nil
+	}
+	p := rdbParser{cursor: cursor{data: data, proto: "RDB"}}
+	eof, err := p.skipEntry()
+	if err == errShort {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return p.need, 0, nil, nil
+	}
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if eof {
+		return 0, p.pos, data[:p.pos], FinalToken
+	}
+	return 0, p.pos, data[:p.pos], nil
+}
@@ -0,0 +1,54 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanRDBEntries(t *testing.T) {
+	header := "REDIS0011"
+	aux := "\xfa\x09redis-ver\x057.2.4"
+	auxInt := "\xfa\x0aredis-bits\xc0\x40"
+	selectDB := "\xfe\x00\xfb\x02\x01"
+	str := "\x00\x03key\x05value"
+	long := "\x00\x01k\x41\x2c" + strings.Repeat("v", 300)
+	expire := "\xfc\x00\x01\x02\x03\x04\x05\x06\x07\x00\x01e\xc1\x39\x30"
+	lzf := "\x00\x01z\xc3\x04\x0a\x01abc"
+	list := "\x01\x01l\x02\x01a\x01b"
+	zset := "\x03\x01s\x02\x01a\x011\x01b\xfe"
+	hash := "\x04\x01h\x01\x01f\x01v"
+	zset2 := "\x05\x01t\x01\x01a\x00\x00\x00\x00\x00\x00\xf0\x3f"
+	quicklist2 := "\x12\x01q\x01\x02\x03abc"
+	module := "\x07\x01m\x80\x00\x00\x00\x01\x02\x05\x05\x01x\x04\x00\x00\x00\x00\x00\x00\x00\x00\x00"
+	stream := "\x15\x01x" +
+		"\x01\x02id\x02lp" + // Listpack.
+		"\x01\x05\x00\x05\x00\x00\x00\x01" + // Length, IDs and entries added.
+		"\x01\x01g\x05\x00\x01" + // Group, its last ID and entries read.
+		"\x01" + strings.Repeat("i", 16) + "\x00\x00\x00\x00\x00\x00\x00\x00\x01" + // PEL.
+		"\x01\x01c" + strings.Repeat("\x00", 16) + "\x01" + strings.Repeat("i", 16) // Consumer.
+	eof := "\xff\x01\x02\x03\x04\x05\x06\x07\x08"
+	entries := []string{header, aux, auxInt, selectDB, str, long, expire, lzf, list, zset, hash, zset2, quicklist2, module, stream, eof}
+	// The selectDB is the two records, the SELECTDB and the RESIZEDB.
+	tokens := append(append(append([]string(nil), entries[:3]...), "\xfe\x00", "\xfb\x02\x01"), entries[4:]...)
+	protoscantest.TestSplitFunc(t, protoscan.ScanRDBEntries, []protoscantest.Case{
+		{Name: "dump", Input: strings.Join(entries, "") + "trailing", Tokens: tokens},
+		{Name: "empty", Err: io.ErrUnexpectedEOF},
+		{Name: "truncated", Input: header + str[:6], Tokens: []string{header}, Err: io.ErrUnexpectedEOF},
+		{Name: "no eof", Input: header + str, Tokens: []string{header, str}, Err: io.ErrUnexpectedEOF},
+		{Name: "magic", Input: "RADIS0011", Err: protoscan.ErrProtocolViolation},
+		{Name: "type", Input: header + "\x06\x01k", Tokens: []string{header}, Err: protoscan.ErrProtocolViolation},
+	})
+}
+
+func FuzzScanRDBEntries(f *testing.F) {
+	f.Add([]byte("REDIS0011\xfa\x01a\x01b\x00\x01k\x01v\xff\x00\x00\x00\x00\x00\x00\x00\x00"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanRDBEntries))
+}
@@ -0,0 +1,79 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// rdwSize is the length of the Record Descriptor Word, and of the Segment
+// Descriptor Word of the spanned records.
+const rdwSize = 4
+
+// Segment control codes of the Segment Descriptor Word.
+const (
+	rdwComplete = 0 // Complete record.
+	rdwFirst    = 1 // First segment of the record.
+	rdwLast     = 2 // Last segment of the record.
+	rdwMiddle   = 3 // Middle segment of the record.
+)
+
+// ScanRDW returns a split function for a Protoscan that returns the data
+// of each IBM variable-length record as a token, without its Record
+// Descriptor Word: the 2-byte big-endian length of the record including
+// the descriptor, followed by 2 bytes which are zero for the records of
+// the VB format. If spanned, the records are of the VBS format, whose
+// descriptor holds the segment control code: the segments of the record
+// are reassembled into the token, copied. The length shorter than the
+// descriptor, the nonzero reserved bytes and the segments out of order
+// stop the scan with the ErrProtocolViolation.
+func ScanRDW(spanned bool) SplitFunc {
+	return func(data []byte, atEOF bool) (int, int, []byte, error) {
+		if len(data) == 0 && atEOF {
+			return 0, 0, nil, nil
+		}
+		pos, segments := 0, 0
+		for {
+			if len(data) < pos+rdwSize {
+				if atEOF {
+					return 0, 0, nil, io.ErrUnexpectedEOF
+				}
+				return pos + rdwSize - len(data), 0, nil, nil
+			}
+			rdw := data[pos : pos+rdwSize]
+			length := int(binary.BigEndian.Uint16(rdw))
+			code := rdw[2]
+			if length < rdwSize || rdw[3] != 0 || !spanned && code != 0 || code > rdwMiddle {
+				return 0, 0, nil, fmt.Errorf("%w: bad RDW % x", ErrProtocolViolation, rdw)
+			}
+			if (code == rdwComplete || code == rdwFirst) != (segments == 0) {
+				return 0, 0, nil, fmt.Errorf("%w: RDW segment %d out of order", ErrProtocolViolation, code)
+			}
+			n := pos + length
+			if len(data) < n {
+				if atEOF {
+					return 0, 0, nil, io.ErrUnexpectedEOF
+				}
+				return n - len(data), 0, nil, nil
+			}
+			segments++
+			switch code {
+			case rdwComplete:
+				return 0, n, data[rdwSize:n], nil
+			case rdwLast:
+				token := make([]byte, 0, n-segments*rdwSize)
+				for i := 0; i < n; {
+					l := int(binary.BigEndian.Uint16(data[i:]))
+					token = append(token, data[i+rdwSize:i+l]...)
+					i += l
+				}
+				return 0, n, token, nil
+			}
+			pos = n
+		}
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanRDW(t *testing.T) {
+	protoscantest.TestSplitFunc(t, protoscan.ScanRDW(false), []protoscantest.Case{
+		{Name: "empty", Input: "", Tokens: nil},
+		{
+			Name:   "records",
+			Input:  "\x00\x07\x00\x00abc" + "\x00\x04\x00\x00" + "\x00\x05\x00\x00d",
+			Tokens: []string{"abc", "", "d"},
+		},
+		{Name: "short RDW", Input: "\x00\x07\x00", Err: io.ErrUnexpectedEOF},
+		{Name: "short record", Input: "\x00\x07\x00\x00ab", Err: io.ErrUnexpectedEOF},
+		{Name: "short length", Input: "\x00\x03\x00\x00", Err: protoscan.ErrProtocolViolation},
+		{Name: "reserved", Input: "\x00\x05\x01\x00a", Err: protoscan.ErrProtocolViolation},
+	})
+	protoscantest.TestSplitFunc(t, protoscan.ScanRDW(true), []protoscantest.Case{
+		{
+			Name: "spanned",
+			Input: "\x00\x05\x00\x00a" +
+				"\x00\x06\x01\x00bc" + "\x00\x05\x03\x00d" + "\x00\x07\x02\x00efg" +
+				"\x00\x05\x01\x00h" + "\x00\x04\x02\x00" +
+				"\x00\x04\x01\x00" + "\x00\x04\x02\x00",
+			Tokens: []string{"a", "bcdefg", "h", ""},
+		},
+		{Name: "short segment", Input: "\x00\x06\x01\x00bc\x00\x05\x03\x00d", Err: io.ErrUnexpectedEOF},
+		{Name: "last first", Input: "\x00\x05\x02\x00a", Err: protoscan.ErrProtocolViolation},
+		{Name: "first twice", Input: "\x00\x05\x01\x00a\x00\x05\x01\x00b", Err: protoscan.ErrProtocolViolation},
+		{Name: "bad code", Input: "\x00\x05\x04\x00a", Err: protoscan.ErrProtocolViolation},
+	})
+}
+
+func FuzzScanRDW(f *testing.F) {
+	f.Add([]byte("\x00\x05\x00\x00a\x00\x06\x01\x00bc\x00\x05\x03\x00d\x00\x04\x02\x00"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanRDW(true)))
+}
@@ -0,0 +1,74 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"errors"
+	"log/slog"
+)
+
+// Classes of the errors of the split functions. A split function wraps its
+// error in one of the classes, for instance with
+//
+//	fmt.Errorf("%w: bad checksum", protoscan.ErrCorruptFrame)
+//
+// so that the Protoscan with the WithRecovery option may recover from it.
+var (
+	// ErrCorruptFrame is the class of the errors of the frames which are
+	// delimited, but their content is invalid. The split function returns
+	// the advance over the corrupt frame together with the error.
+	ErrCorruptFrame = errors.New("protoscan: corrupt frame")
+	// ErrNeedResync is the class of the errors of the data where no frame
+	// starts. The split function returns the advance over the data to
+	// skip, or zero to skip a byte.
+	ErrNeedResync = errors.New("protoscan: need resync")
+	// ErrProtocolViolation is the class of the errors which the scan of
+	// the data stream cannot recover from.
+	ErrProtocolViolation = errors.New("protoscan: protocol violation")
+)
+
+// Recoverable reports whether the error is of the class of the corrupt
+// frame or the need of resync.
+func Recoverable(err error) bool {
+	return errors.Is(err, ErrCorruptFrame) || errors.Is(err, ErrNeedResync)
+}
+
+// WithRecovery makes the Protoscan skip the data on the recoverable errors
+// of the split function instead of stopping, the advance returned with the
+// error is skipped, at least a byte. The fn, if not nil, is called with the
+// error and the skipped data before the data are skipped.
+func WithRecovery(fn func(err error, skipped []byte)) Option {
+	return func(s *Protoscan) {
+		s.recovery = true
+		s.onRecover = fn
+	}
+}
+
+// Resyncs returns the count of the recoveries from the errors of the split
+// function.
+func (s *Protoscan) Resyncs() int {
+	return s.resyncs
+}
+
+// recover skips the data on the recoverable error of the split function.
+// It reports whether the data are skipped.
+func (s *Protoscan) recover(err error, advance int) bool {
+	if !s.recovery || !Recoverable(err) {
+		return false
+	}
+	if advance <= 0 {
+		advance = 1
+	}
+	if s.start+advance > s.end {
+		return false
+	}
+	s.resyncs++
+	s.log("resync", slog.Int("skipped", advance), slog.Any("error", err))
+	if s.onRecover != nil {
+		s.onRecover(err, s.buffer[s.start:s.start+advance])
+	}
+	s.move(advance)
+	return true
+}
@@ -0,0 +1,73 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+)
+
+// splitChecked splits the frames of "<" and three bytes, where the frames
+// of the non-digits are corrupt and the digit 9 violates the protocol.
+func splitChecked(data []byte, atEOF bool) (int, int, []byte, error) {
+	if len(data) == 0 {
+		if atEOF {
+			return 0, 0, nil, nil
+		}
+		return 1, 0, nil, nil
+	}
+	if data[0] != '<' {
+		return 0, 0, nil, fmt.Errorf("%w: no frame at %q", protoscan.ErrNeedResync, data[0])
+	}
+	if len(data) < 4 {
+		return 4 - len(data), 0, nil, nil
+	}
+	for _, c := range data[1:4] {
+		if c == '9' {
+			return 0, 0, nil, fmt.Errorf("%w: nine", protoscan.ErrProtocolViolation)
+		}
+		if c < '0' || c > '9' {
+			return 0, 4, nil, fmt.Errorf("%w: %q", protoscan.ErrCorruptFrame, data[:4])
+		}
+	}
+	return 0, 4, data[1:4], nil
+}
+
+func TestRecovery(t *testing.T) {
+	var skipped []string
+	s := protoscan.New(
+		strings.NewReader("<123xy<abc<456<999"),
+		protoscan.WithSplit(splitChecked),
+		protoscan.WithRecovery(func(err error, data []byte) {
+			skipped = append(skipped, string(data))
+		}),
+	)
+	var tokens []string
+	for s.Scan() {
+		tokens = append(tokens, string(s.Token()))
+	}
+	if !errors.Is(s.Err(), protoscan.ErrProtocolViolation) {
+		t.Fatalf("expected ErrProtocolViolation; got %v", s.Err())
+	}
+	if got := strings.Join(tokens, "|"); got != "123|456" {
+		t.Fatalf("unexpected tokens %q", got)
+	}
+	if got := strings.Join(skipped, "|"); got != "x|y|<abc" || s.Resyncs() != 3 {
+		t.Fatalf("unexpected skipped data %q in %d resyncs", got, s.Resyncs())
+	}
+}
+
+func TestRecoverable(t *testing.T) {
+	s := protoscan.New(strings.NewReader("<12x"), protoscan.WithSplit(splitChecked))
+	for s.Scan() {
+	}
+	if !protoscan.Recoverable(s.Err()) {
+		t.Fatalf("expected recoverable error; got %v", s.Err())
+	}
+}
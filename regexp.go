@@ -0,0 +1,37 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import "regexp"
+
+// ScanRegexp returns a split function for a Protoscan that splits the
+// stream at the start of each match of the regular expression, such as
+// the timestamp prefixing the records of a log, so that each token starts
+// with its match, except the bytes before the first match. The match
+// reaching the end of the buffered data is not used before more data are
+// read, as it may be longer, so that the tokens do not depend on the
+// reads. The last token is returned at EOF even if no match follows it.
+// The regular expression should not match the empty string, and should
+// use the (?m) flag for its ^ to match at the start of the lines.
+func ScanRegexp(re *regexp.Regexp) SplitFunc {
+	return func(data []byte, atEOF bool) (int, int, []byte, error) {
+		if atEOF && len(data) == 0 {
+			return 0, 0, nil, nil
+		}
+		for _, m := range re.FindAllIndex(data, 2) {
+			if m[0] == 0 {
+				continue
+			}
+			if m[1] == len(data) && !atEOF {
+				break
+			}
+			return 0, m[0], data[:m[0]], nil
+		}
+		if atEOF {
+			return 0, len(data), data, nil
+		}
+		return 1, 0, nil, nil
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanRegexp(t *testing.T) {
+	split := protoscan.ScanRegexp(regexp.MustCompile(`(?m)^\d{4}-\d\d-\d\d `))
+	first := "2022-01-02 panic: boom\ngoroutine 1:\n\tmain.go:3\n"
+	second := "2022-01-02 done\n"
+	protoscantest.TestSplitFunc(t, split, []protoscantest.Case{
+		{Name: "empty", Input: "", Tokens: nil},
+		{
+			Name:   "records",
+			Input:  first + second + "2022-01-03 last",
+			Tokens: []string{first, second, "2022-01-03 last"},
+		},
+		{Name: "leading", Input: "boot\n" + second, Tokens: []string{"boot\n", second}},
+		{Name: "not at line start", Input: second + "x 2022-01-03 y\n", Tokens: []string{second + "x 2022-01-03 y\n"}},
+	})
+	// The match reaching the end of the data may be longer.
+	protoscantest.TestSplitFunc(t, protoscan.ScanRegexp(regexp.MustCompile(`#+`)), []protoscantest.Case{
+		{Name: "straddling", Input: "a###b##c", Tokens: []string{"a", "###b", "##c"}},
+	})
+}
+
+func FuzzScanRegexp(f *testing.F) {
+	f.Add([]byte("## a\n### b\n"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanRegexp(regexp.MustCompile(`(?m)^#+ `))))
+}
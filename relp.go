@@ -0,0 +1,88 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"fmt"
+	"io"
+)
+
+const (
+	relpMaxDigits  = 9  // Maximum digits of the TXNR and the DATALEN.
+	relpMaxCommand = 32 // Maximum length of the command.
+)
+
+// relpField returns the end of the field of the RELP header at the pos:
+// up to max digits, or letters if !digits, followed by the space, or by
+// the newline if nl. The end is -1 if the data end before it.
+func relpField(data []byte, pos, max int, digits, nl bool) (int, error) {
+	for i := pos; i < len(data); i++ {
+		c := data[i]
+		if i > pos && (c == ' ' || nl && c == '\n') {
+			return i, nil
+		}
+		ok := '0' <= c && c <= '9'
+		if !digits {
+			ok = 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+		}
+		if !ok || i-pos == max {
+			return 0, fmt.Errorf("%w: bad RELP header %q", ErrProtocolViolation, data[:i+1])
+		}
+	}
+	return -1, nil
+}
+
+// ScanRELP is a split function for a Protoscan that returns each frame of
+// the Reliable Event Logging Protocol as a token, without the trailing
+// newline: the transaction number, the command and the length of the data,
+// separated by the spaces, followed by the space and the data of the
+// length, which may hold the newlines, unless the length is 0. The
+// malformed headers and the data not followed by the newline stop the
+// scan with the ErrProtocolViolation.
+func ScanRELP(data []byte, atEOF bool) (int, int, []byte, error) {
+	if len(data) == 0 && atEOF {
+		return 0, 0, nil, nil
+	}
+	pos := 0
+	var length int
+	for _, f := range []struct {
+		max    int
+		digits bool
+	}{{relpMaxDigits, true}, {relpMaxCommand, false}, {relpMaxDigits, true}} {
+		end, err := relpField(data, pos, f.max, f.digits, f.max == relpMaxDigits && pos > 0)
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		if end < 0 {
+			if atEOF {
+				return 0, 0, nil, io.ErrUnexpectedEOF
+			}
+			return 1, 0, nil, nil
+		}
+		if pos > 0 && f.digits {
+			for _, c := range data[pos:end] {
+				length = 10*length + int(c-'0')
+			}
+		}
+		pos = end + 1
+	}
+	if data[pos-1] == '\n' {
+		if length != 0 {
+			return 0, 0, nil, fmt.Errorf("%w: RELP frame without its data", ErrProtocolViolation)
+		}
+		return 0, pos, data[:pos-1], nil
+	}
+	n := pos + length + 1
+	if n > len(data) {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return n - len(data), 0, nil, nil
+	}
+	if data[n-1] != '\n' {
+		return 0, 0, nil, fmt.Errorf("%w: RELP frame without its trailer", ErrProtocolViolation)
+	}
+	return 0, n, data[:n-1], nil
+}
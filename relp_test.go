@@ -0,0 +1,39 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanRELP(t *testing.T) {
+	open := "1 open 32 relp_version=0\nrelp_software=x\nc"
+	syslog := "2 syslog 14 <13>multi\nline"
+	protoscantest.TestSplitFunc(t, protoscan.ScanRELP, []protoscantest.Case{
+		{Name: "empty", Input: "", Tokens: nil},
+		{
+			Name:   "frames",
+			Input:  open + "\n" + syslog + "\n" + "3 close 0\n" + "0 serverclose 0\n",
+			Tokens: []string{open, syslog, "3 close 0", "0 serverclose 0"},
+		},
+		{Name: "short header", Input: "1 syslog 1", Err: io.ErrUnexpectedEOF},
+		{Name: "short data", Input: "1 syslog 10 abc", Err: io.ErrUnexpectedEOF},
+		{Name: "no trailer", Input: "1 syslog 3 abcd\n", Err: protoscan.ErrProtocolViolation},
+		{Name: "no data", Input: "1 syslog 3\n", Err: protoscan.ErrProtocolViolation},
+		{Name: "bad txnr", Input: "x syslog 3 abc\n", Err: protoscan.ErrProtocolViolation},
+		{Name: "long txnr", Input: "1234567890 syslog 3 abc\n", Err: protoscan.ErrProtocolViolation},
+		{Name: "bad command", Input: "1 sys-log 3 abc\n", Err: protoscan.ErrProtocolViolation},
+		{Name: "newline in header", Input: "1 syslog\n", Err: protoscan.ErrProtocolViolation},
+	})
+}
+
+func FuzzScanRELP(f *testing.F) {
+	f.Add([]byte("1 open 3 a\nb\n2 close 0\n"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanRELP))
+}
@@ -0,0 +1,20 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+// Remainder returns the data buffered but not consumed by the split function
+// when the scan stopped, for instance the incomplete frame at EOF or at the
+// I/O error. It returns nil while the scan goes on.
+func (s *Protoscan) Remainder() []byte {
+	return s.remainder
+}
+
+// setRemainder keeps the copy of the data not consumed when the scan stops.
+func (s *Protoscan) setRemainder() {
+	if !s.stopped {
+		s.remainder = append([]byte(nil), s.buffer[s.start:s.end]...)
+		s.stopped = true
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestRemainder(t *testing.T) {
+	errRead := errors.New("read")
+	for _, test := range []struct {
+		name  string
+		r     io.Reader
+		split protoscan.SplitFunc
+		want  string
+	}{
+		{
+			name:  "eof",
+			r:     strings.NewReader("<123<45"),
+			split: splitChecked,
+			want:  "<45",
+		},
+		{
+			name:  "read error",
+			r:     &protoscantest.ErrorReader{N: 6, Err: errRead, R: strings.NewReader("<123<456")},
+			split: splitChecked,
+			want:  "<4",
+		},
+		{
+			name:  "split error",
+			r:     strings.NewReader("<123<4x6<789"),
+			split: splitChecked,
+			want:  "<4x6",
+		},
+		{
+			name:  "consumed",
+			r:     strings.NewReader("<123"),
+			split: splitChecked,
+			want:  "",
+		},
+	} {
+		s := protoscan.New(test.r, protoscan.WithSplit(test.split))
+		if !s.Scan() || s.Remainder() != nil {
+			t.Fatalf("%s: expected token and no remainder; got %v, %q", test.name, s.Err(), s.Remainder())
+		}
+		for s.Scan() {
+		}
+		if got := string(s.Remainder()); got != test.want {
+			t.Errorf("%s: expected remainder %q; got %q", test.name, test.want, got)
+		}
+	}
+}
+
+// splitCounted splits the frames prefixed by the two-digit length.
+func splitCounted(data []byte, atEOF bool) (int, int, []byte, error) {
+	if len(data) < 2 {
+		if atEOF {
+			return 0, 0, nil, nil
+		}
+		return 2 - len(data), 0, nil, nil
+	}
+	n := int(data[0]-'0')*10 + int(data[1]-'0')
+	if len(data) < 2+n {
+		if atEOF {
+			return 0, 0, nil, nil
+		}
+		return 2 + n - len(data), 0, nil, nil
+	}
+	return 0, 2 + n, data[2 : 2+n], nil
+}
+
+func TestRemainderScanN(t *testing.T) {
+	s := protoscan.New(
+		&protoscantest.SlowReader{Max: 5, R: strings.NewReader("02ab03cde01f05xy")},
+		protoscan.WithSplit(splitCounted),
+	)
+	var got []string
+	for n := s.ScanN(2); n > 0; n = s.ScanN(2) {
+		if s.Remainder() != nil {
+			t.Fatalf("unexpected remainder %q after %q", s.Remainder(), got)
+		}
+		for _, token := range s.Batch() {
+			got = append(got, string(token))
+		}
+	}
+	if strings.Join(got, "|") != "ab|cde|f" {
+		t.Fatalf("unexpected tokens %q", got)
+	}
+	if got := string(s.Remainder()); got != "05xy" {
+		t.Fatalf("expected remainder %q; got %q", "05xy", got)
+	}
+}
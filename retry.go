@@ -0,0 +1,70 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// RetryPolicy defines the retries of the reads failed with the temporary
+// errors, such as the exceeded read deadlines of the network connections.
+type RetryPolicy struct {
+	Attempts int           // Maximum number of the successive retries.
+	Backoff  BackoffPolicy // Sleeps before the retries.
+}
+
+// WithRetry makes the Protoscan retry the reads failed with an error which
+// has the Timeout or Temporary method returning true, instead of stopping
+// the scan on the first such error. The errors of the context and the
+// errors after the deadline set by the WithTokenTimeout or the
+// WithTotalTimeout are not retried. The Total of the backoff policy is not
+// used. The retries are counted by the Stats and reported to the tracer set
+// by the WithTracer if it is a RetryTracer.
+func WithRetry(p RetryPolicy) Option {
+	return func(s *Protoscan) { s.retryPolicy = &p }
+}
+
+// Retries returns the count of the retried reads, as the Retries of the
+// Stats.
+func (s *Protoscan) Retries() int {
+	return s.stats.Retries
+}
+
+// retry sleeps before the retry of the read failed with the error. It
+// reports whether the read should be retried.
+func (s *Protoscan) retry(err error) bool {
+	if s.retryPolicy == nil || s.retried >= s.retryPolicy.Attempts || !isTemporary(err) {
+		return false
+	}
+	if deadline := s.deadline(); !deadline.IsZero() && !time.Now().Before(deadline) {
+		// The deadline of the token or the scan has passed.
+		return false
+	}
+	s.retried++
+	s.stats.Retries++
+	s.log("read retried", slog.Int("attempt", s.retried), slog.Any("error", err))
+	if rt, ok := s.tracer.(RetryTracer); ok {
+		rt.Retry(s.offset+int64(s.end), s.retried, err)
+	}
+	time.Sleep(s.retryPolicy.Backoff.Delay(s.retried))
+	return true
+}
+
+// isTemporary reports whether the error is a timeout or a temporary error.
+// The errors of the context are not temporary.
+func isTemporary(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var timeout interface{ Timeout() bool }
+	if errors.As(err, &timeout) && timeout.Timeout() {
+		return true
+	}
+	var temporary interface{ Temporary() bool }
+	return errors.As(err, &temporary) && temporary.Temporary()
+}
@@ -0,0 +1,118 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/protoscan/protoscan"
+)
+
+// timeoutReader fails each read with the exceeded deadline unless the
+// count of the failures hits every.
+type timeoutReader struct {
+	every, n int
+	r        io.Reader
+}
+
+func (r *timeoutReader) Read(p []byte) (int, error) {
+	r.n++
+	if r.n%r.every != 0 {
+		return 0, os.ErrDeadlineExceeded
+	}
+	return r.r.Read(p)
+}
+
+func TestRetry(t *testing.T) {
+	retry := protoscan.RetryPolicy{Attempts: 3, Backoff: protoscan.BackoffPolicy{Initial: time.Microsecond}}
+	s := protoscan.New(
+		&timeoutReader{every: 3, r: strings.NewReader("ab\ncd\n")},
+		protoscan.WithSplit(protoscan.ScanLines),
+		protoscan.WithMaxBuffer(smallMaxTokenSize),
+		protoscan.WithRetry(retry),
+	)
+	var lines []string
+	for s.Scan() {
+		lines = append(lines, string(s.Token()))
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	if got := strings.Join(lines, "|"); got != "ab|cd" {
+		t.Fatalf("unexpected lines %q", got)
+	}
+	if s.Retries() == 0 {
+		t.Fatal("expected retries")
+	}
+
+	s = protoscan.New(
+		&timeoutReader{every: 5, r: strings.NewReader("ab\ncd\n")},
+		protoscan.WithSplit(protoscan.ScanLines),
+		protoscan.WithRetry(retry),
+	)
+	for s.Scan() {
+	}
+	if !errors.Is(s.Err(), os.ErrDeadlineExceeded) || s.Retries() != 3 {
+		t.Fatalf("expected deadline exceeded after 3 retries; got %v after %d", s.Err(), s.Retries())
+	}
+}
+
+// retryTracer records the retries.
+type retryTracer struct {
+	eventTracer
+	retries []string
+}
+
+func (t *retryTracer) Retry(offset int64, attempt int, err error) {
+	t.retries = append(t.retries, fmt.Sprintf("%d#%d", offset, attempt))
+}
+
+func TestRetryReported(t *testing.T) {
+	tracer := new(retryTracer)
+	s := protoscan.New(
+		&timeoutReader{every: 3, r: strings.NewReader("ab\n")},
+		protoscan.WithSplit(protoscan.ScanLines),
+		protoscan.WithRetry(protoscan.RetryPolicy{Attempts: 3, Backoff: protoscan.BackoffPolicy{Initial: time.Microsecond}}),
+		protoscan.WithTracer(tracer),
+	)
+	for s.Scan() {
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	if s.Stats().Retries != s.Retries() || s.Retries() != len(tracer.retries) {
+		t.Fatalf("expected %d retries in the stats and the tracer; got %d and %q", s.Retries(), s.Stats().Retries, tracer.retries)
+	}
+	if got := strings.Join(tracer.retries[:4], " "); got != "0#1 0#2 1#1 1#2" {
+		t.Fatalf("unexpected retries %q", tracer.retries)
+	}
+}
+
+func TestRetryDeadline(t *testing.T) {
+	retry := protoscan.WithRetry(protoscan.RetryPolicy{Attempts: 3, Backoff: protoscan.BackoffPolicy{Initial: time.Microsecond}})
+	ctx, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+	for name, s := range map[string]*protoscan.Protoscan{
+		"context": protoscan.ScanContext(ctx, strings.NewReader("ab\n"), protoscan.ScanLines, retry),
+		"total": protoscan.New(
+			&timeoutReader{every: 100, r: strings.NewReader("ab\n")},
+			protoscan.WithTotalTimeout(time.Nanosecond),
+			retry,
+		),
+	} {
+		for s.Scan() {
+		}
+		if s.Err() == nil || s.Retries() != 0 {
+			t.Errorf("%s: expected the error without retries; got %v after %d", name, s.Err(), s.Retries())
+		}
+	}
+}
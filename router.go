@@ -0,0 +1,135 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import "sync"
+
+// Route defines the bounded queue of the tokens of a class for the Router.
+type Route struct {
+	Priority int        // Tokens of the routes of higher priority are delivered first.
+	Queue    int        // Size of the queue, 1 if 0 or less.
+	Policy   SlowPolicy // SlowBlock or SlowDrop when the queue is full, SlowDisconnect drops too.
+}
+
+// RouteStats holds the metrics of the queue of the route.
+type RouteStats struct {
+	Depth     int   // Count of the tokens in the queue.
+	Delivered int64 // Count of the tokens delivered by Next.
+	Dropped   int64 // Count of the tokens dropped on the full queue.
+}
+
+// Router scans the tokens and queues the copy of each token to the route
+// chosen by the classify function, so that the consumer calling the Next
+// receives the tokens of the routes of higher priority ahead of the others
+// when it falls behind the scan. For example, the ISO 8583 network
+// management messages may be routed ahead of the financial ones.
+type Router struct {
+	scan     *Protoscan
+	classify func(token []byte) int
+	routes   []Route
+	order    []int // Indexes of the routes by the descending priority.
+
+	mu       sync.Mutex
+	cond     sync.Cond
+	queues   [][][]byte
+	stats    []RouteStats
+	unrouted int64
+	done     bool
+}
+
+// NewRouter returns a new Router of the tokens of the Protoscan. The classify
+// returns the index of the route of the token, the tokens of the indexes out
+// of the routes are dropped.
+func NewRouter(s *Protoscan, classify func(token []byte) int, routes ...Route) *Router {
+	r := &Router{
+		scan:     s,
+		classify: classify,
+		routes:   append([]Route(nil), routes...),
+		queues:   make([][][]byte, len(routes)),
+		stats:    make([]RouteStats, len(routes)),
+	}
+	r.cond.L = &r.mu
+	for i := range r.routes {
+		r.routes[i].Queue = max(r.routes[i].Queue, 1)
+	}
+	for i := range routes {
+		j := len(r.order)
+		for j > 0 && routes[r.order[j-1]].Priority < routes[i].Priority {
+			j--
+		}
+		r.order = append(r.order[:j], append([]int{i}, r.order[j:]...)...)
+	}
+	return r
+}
+
+// Run scans and queues the tokens until the scan stops, then it returns the
+// error of the scan. The Next returns the rest of the queued tokens after.
+func (r *Router) Run() error {
+	for r.scan.Scan() {
+		i := r.classify(r.scan.Token())
+		token := append([]byte(nil), r.scan.Token()...)
+		r.mu.Lock()
+		if i < 0 || i >= len(r.routes) {
+			r.unrouted++
+			r.mu.Unlock()
+			continue
+		}
+		for r.routes[i].Policy == SlowBlock && len(r.queues[i]) >= r.routes[i].Queue {
+			r.cond.Wait()
+		}
+		if len(r.queues[i]) < r.routes[i].Queue {
+			r.queues[i] = append(r.queues[i], token)
+			r.stats[i].Depth++
+			r.cond.Broadcast()
+		} else {
+			r.stats[i].Dropped++
+		}
+		r.mu.Unlock()
+	}
+	r.mu.Lock()
+	r.done = true
+	r.cond.Broadcast()
+	r.mu.Unlock()
+	return r.scan.Err()
+}
+
+// Next waits for the queued token of the highest priority and returns it
+// with the index of its route. It returns false when the Run has returned
+// and all the queues are empty.
+func (r *Router) Next() ([]byte, int, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for {
+		for _, i := range r.order {
+			if len(r.queues[i]) > 0 {
+				token := r.queues[i][0]
+				r.queues[i][0] = nil
+				r.queues[i] = r.queues[i][1:]
+				r.stats[i].Depth--
+				r.stats[i].Delivered++
+				r.cond.Broadcast()
+				return token, i, true
+			}
+		}
+		if r.done {
+			return nil, 0, false
+		}
+		r.cond.Wait()
+	}
+}
+
+// Stats returns the metrics of the queues of the routes.
+func (r *Router) Stats() []RouteStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]RouteStats(nil), r.stats...)
+}
+
+// Unrouted returns the count of the tokens dropped as out of the routes.
+func (r *Router) Unrouted() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.unrouted
+}
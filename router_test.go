@@ -0,0 +1,97 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+)
+
+func TestRouter(t *testing.T) {
+	text := "0200\n0200\n0800\n0200\n0800\n9999\n0200\n"
+	classify := func(token []byte) int {
+		switch string(token) {
+		case "0800":
+			return 1
+		case "0200":
+			return 0
+		}
+		return -1
+	}
+	r := protoscan.NewRouter(
+		protoscan.New(strings.NewReader(text), protoscan.WithSplit(protoscan.ScanLines)),
+		classify,
+		protoscan.Route{Priority: 0, Queue: 3, Policy: protoscan.SlowDrop},
+		protoscan.Route{Priority: 10, Queue: 10, Policy: protoscan.SlowBlock},
+	)
+	// The consumer is saturated until the scan stops.
+	if err := r.Run(); err != nil {
+		t.Fatal(err)
+	}
+	stats := r.Stats()
+	if stats[0].Depth != 3 || stats[0].Dropped != 1 || stats[1].Depth != 2 || r.Unrouted() != 1 {
+		t.Fatalf("unexpected stats %+v, %d unrouted", stats, r.Unrouted())
+	}
+	var got []string
+	for {
+		token, _, ok := r.Next()
+		if !ok {
+			break
+		}
+		got = append(got, string(token))
+	}
+	if want := "0800 0800 0200 0200 0200"; strings.Join(got, " ") != want {
+		t.Fatalf("expected %s; got %s", want, got)
+	}
+	if stats := r.Stats(); stats[0].Delivered != 3 || stats[1].Delivered != 2 || stats[1].Depth != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+// Test that the blocking route waits for the consumer.
+func TestRouterBlock(t *testing.T) {
+	text := strings.Repeat("a\n", 100)
+	r := protoscan.NewRouter(
+		protoscan.New(strings.NewReader(text), protoscan.WithSplit(protoscan.ScanLines)),
+		func([]byte) int { return 0 },
+		protoscan.Route{Queue: 1, Policy: protoscan.SlowBlock},
+	)
+	errc := make(chan error)
+	go func() { errc <- r.Run() }()
+	var n int
+	for _, _, ok := r.Next(); ok; _, _, ok = r.Next() {
+		n++
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if n != 100 {
+		t.Fatalf("expected 100 tokens; got %d", n)
+	}
+}
+
+// Test that the zero value of the route queues a token rather than blocking
+// the Run forever.
+func TestRouterZeroRoute(t *testing.T) {
+	r := protoscan.NewRouter(
+		protoscan.New(strings.NewReader("a\nb\nc\n"), protoscan.WithSplit(protoscan.ScanLines)),
+		func([]byte) int { return 0 },
+		protoscan.Route{},
+	)
+	errc := make(chan error)
+	go func() { errc <- r.Run() }()
+	var got []string
+	for token, _, ok := r.Next(); ok; token, _, ok = r.Next() {
+		got = append(got, string(token))
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, "|") != "a|b|c" {
+		t.Fatalf("unexpected tokens %q", got)
+	}
+}
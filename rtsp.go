@@ -0,0 +1,72 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Kinds of the tokens of the ScanRTSPInterleaved, the first of the Indexes.
+const (
+	RTSPMessage     = 0 // RTSP request or response.
+	RTSPInterleaved = 1 // Interleaved binary data of the '$' frame.
+)
+
+// ScanRTSPInterleaved is the split function for the Protoscan with the
+// WithSplitContext option which returns each RTSP request or response,
+// with the body of its Content-Length, and each interleaved binary frame,
+// the '$', the 1-byte channel and the 2-byte length followed by the RTP or
+// the RTCP data, as a token. The Indexes of the token are its kind, the
+// RTSPMessage or the RTSPInterleaved, the channel of the binary frame or 0,
+// and the offset and the length of the body or the data in the token.
+func ScanRTSPInterleaved(ctx *SplitContext, data []byte, atEOF bool) (int, int, []byte, error) {
+	if len(data) == 0 {
+		if atEOF {
+			return 0, 0, nil, nil
+		}
+		return 1, 0, nil, nil
+	}
+	if data[0] == '$' {
+		if len(data) < 4 {
+			if atEOF {
+				return 0, 0, nil, io.ErrUnexpectedEOF
+			}
+			return 4 - len(data), 0, nil, nil
+		}
+		n := 4 + int(binary.BigEndian.Uint16(data[2:]))
+		if len(data) < n {
+			if atEOF {
+				return 0, 0, nil, io.ErrUnexpectedEOF
+			}
+			return n - len(data), 0, nil, nil
+		}
+		ctx.Indexes = append(ctx.Indexes, RTSPInterleaved, int(data[1]), 4, n-4)
+		return 0, n, data[:n], nil
+	}
+	end, err := headerEnd(data)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if end < 0 {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return 1, 0, nil, nil
+	}
+	length, err := contentLength(data[:end])
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	n := end + length
+	if len(data) < n {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return n - len(data), 0, nil, nil
+	}
+	ctx.Indexes = append(ctx.Indexes, RTSPMessage, 0, end, length)
+	return 0, n, data[:n], nil
+}
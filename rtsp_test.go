@@ -0,0 +1,78 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanRTSPInterleaved(t *testing.T) {
+	stream := "RTSP/1.0 200 OK\r\nCSeq: 3\r\ncontent-length: 4\r\n\r\nv=0\n" +
+		"$\x00\x00\x05rtp\r\n" +
+		"$\x01\x00\x00" +
+		"GET_PARAMETER rtsp://h/s RTSP/1.0\r\nCSeq: 4\r\n\r\n"
+	want := []string{
+		`0 0 "v=0\n"`,
+		`1 0 "rtp\r\n"`,
+		`1 1 ""`,
+		`0 0 ""`,
+	}
+	for _, f := range protoscantest.Fragmentations() {
+		s := protoscan.New(f.Reader([]byte(stream)), protoscan.WithSplitContext(protoscan.ScanRTSPInterleaved))
+		var got []string
+		var all string
+		for s.Scan() {
+			tok, ix := s.Token(), s.Indexes()
+			got = append(got, fmt.Sprintf("%d %d %q", ix[0], ix[1], tok[ix[2]:ix[2]+ix[3]]))
+			all += string(tok)
+		}
+		if s.Err() != nil {
+			t.Fatalf("%s: %v", f.Name, s.Err())
+		}
+		if strings.Join(got, "|") != strings.Join(want, "|") {
+			t.Fatalf("%s: expected tokens %q; got %q", f.Name, want, got)
+		}
+		if all != stream {
+			t.Fatalf("%s: tokens do not cover the stream", f.Name)
+		}
+	}
+}
+
+func TestScanRTSPInterleavedErrors(t *testing.T) {
+	for _, test := range []struct {
+		stream string
+		err    error
+	}{
+		{"$\x00\x00\x05rt", io.ErrUnexpectedEOF},
+		{"$\x00", io.ErrUnexpectedEOF},
+		{"RTSP/1.0 200 OK\r\nCSeq: 3\r\n", io.ErrUnexpectedEOF},
+		{"RTSP/1.0 200 OK\r\nContent-Length: 9\r\n\r\nv=0", io.ErrUnexpectedEOF},
+		{"RTSP/1.0 200 OK\r\nContent-Length: -1\r\n\r\n", protoscan.ErrProtocolViolation},
+		{"RTSP/1.0 200 OK\r\nX: " + strings.Repeat("x", 70000), protoscan.ErrProtocolViolation},
+	} {
+		s := protoscan.New(
+			strings.NewReader(test.stream),
+			protoscan.WithSplitContext(protoscan.ScanRTSPInterleaved),
+			protoscan.WithMaxBuffer(1<<17),
+		)
+		for s.Scan() {
+		}
+		if !errors.Is(s.Err(), test.err) {
+			t.Errorf("%.30q: expected error %v; got %v", test.stream, test.err, s.Err())
+		}
+	}
+}
+
+func FuzzScanRTSPInterleaved(f *testing.F) {
+	protoscantest.AddCorpus(f, protoscantest.RTSPCorpus())
+	f.Fuzz(protoscantest.FuzzContext(protoscan.ScanRTSPInterleaved))
+}
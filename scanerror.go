@@ -0,0 +1,114 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ScanError records an error returned by the split function together with
+// the data around the carriage at the moment of the error. Protoscan wraps
+// the errors of the split function into the ScanError if the WithErrorContext
+// option is set.
+type ScanError struct {
+	Err     error  // Error returned by the split function.
+	Offset  int64  // Offset of the carriage in the data stream.
+	Line    int    // Line of the carriage, counted from 1, 0 unless the WithLineCount is set.
+	Context []byte // Data around the carriage.
+	Cursor  int    // Position of the carriage in the Context.
+}
+
+func (e *ScanError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("protoscan: offset %d: %v", e.Offset, e.Err)
+	}
+	return fmt.Sprintf("protoscan: line %d, offset %d: %v", e.Line, e.Offset, e.Err)
+}
+
+func (e *ScanError) Unwrap() error { return e.Err }
+
+// Dump returns the hex and ASCII dump of the Context, the lines start with
+// the offsets in the data stream and the byte at the carriage is marked.
+func (e *ScanError) Dump() string {
+	var b strings.Builder
+	start := e.Offset - int64(e.Cursor)
+	for i := 0; i < len(e.Context); i += 16 {
+		line := e.Context[i:]
+		if len(line) > 16 {
+			line = line[:16]
+		}
+		fmt.Fprintf(&b, "%08x ", start+int64(i))
+		for j := 0; j < 16; j++ {
+			if j == 8 {
+				b.WriteByte(' ')
+			}
+			if j < len(line) {
+				fmt.Fprintf(&b, " %02x", line[j])
+			} else {
+				b.WriteString("   ")
+			}
+		}
+		b.WriteString("  |")
+		for _, c := range line {
+			if c < 32 || c > 126 {
+				c = '.'
+			}
+			b.WriteByte(c)
+		}
+		b.WriteString("|\n")
+		if i <= e.Cursor && e.Cursor < i+16 {
+			col := e.Cursor - i
+			pad := 9 + 3*col + 1
+			if col >= 8 {
+				pad++
+			}
+			b.WriteString(strings.Repeat(" ", pad))
+			b.WriteString("^^\n")
+		}
+	}
+	return b.String()
+}
+
+// WithErrorContext sets the number of bytes before and after the carriage
+// captured by the ScanError wrapping the errors of the split function.
+// The bytes before the carriage are captured as long as they are buffered.
+func WithErrorContext(n int) Option {
+	return func(s *Protoscan) { s.errorContext = n }
+}
+
+// scanError wraps the error of the split function into the ScanError.
+func (s *Protoscan) scanError(err error) error {
+	to := s.start + s.errorContext
+	if to > s.end {
+		to = s.end
+	}
+	context := append(s.behind, s.buffer[:to]...)
+	cursor := len(s.behind) + s.start
+	if cursor > s.errorContext {
+		context = context[cursor-s.errorContext:]
+		cursor = s.errorContext
+	}
+	var line int
+	if s.newline != nil {
+		line = s.lines + 1
+	}
+	return &ScanError{
+		Err:     err,
+		Offset:  s.offset + int64(s.start),
+		Line:    line,
+		Context: append([]byte(nil), context...),
+		Cursor:  cursor,
+	}
+}
+
+// keepBehind keeps the last of the n bytes which are about to be shifted
+// out of the buffer, so that the ScanError may capture them.
+func (s *Protoscan) keepBehind(n int) {
+	s.behind = append(s.behind, s.buffer[:n]...)
+	if n := len(s.behind) - s.errorContext; n > 0 {
+		s.behind = s.behind[:copy(s.behind, s.behind[n:])]
+	}
+}
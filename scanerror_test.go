@@ -0,0 +1,51 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+)
+
+// Test that the error of the split function carries the data around the carriage.
+func TestErrorContext(t *testing.T) {
+	split := func(data []byte, atEOF bool) (int, int, []byte, error) {
+		if len(data) > 0 && data[0] == 'X' {
+			return 0, 0, nil, testError
+		}
+		return protoscan.ScanBytes(data, atEOF)
+	}
+	text := strings.Repeat("abcdefgh", 4) + "X" + strings.Repeat("ijklmnop", 4)
+	s := protoscan.New(
+		strings.NewReader(text),
+		protoscan.WithSplit(split),
+		protoscan.WithErrorContext(20),
+		protoscan.WithMaxBuffer(64),
+	)
+	for s.Scan() {
+	}
+	if !errors.Is(s.Err(), testError) {
+		t.Fatalf("expected %v; got %v", testError, s.Err())
+	}
+	var scanErr *protoscan.ScanError
+	if !errors.As(s.Err(), &scanErr) {
+		t.Fatalf("expected ScanError; got %T", s.Err())
+	}
+	if scanErr.Offset != 32 {
+		t.Errorf("expected offset 32; got %d", scanErr.Offset)
+	}
+	// The bytes after the carriage are captured as far as they are buffered.
+	if scanErr.Cursor != 20 || !strings.HasPrefix(text[12:], string(scanErr.Context)) {
+		t.Errorf("unexpected context %q with cursor at %d", scanErr.Context, scanErr.Cursor)
+	}
+	const dump = "0000000c  65 66 67 68 61 62 63 64  65 66 67 68 61 62 63 64  |efghabcdefghabcd|\n" +
+		"0000001c  65 66 67 68 58"
+	if got := scanErr.Dump(); !strings.HasPrefix(got, dump) || !strings.Contains(got, "\n                      ^^\n") {
+		t.Errorf("unexpected dump:\n%s", got)
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// Framer is the signature of the function used to frame outbound messages.
+// It appends the wire representation of the message to dst and returns
+// the extended buffer.
+type Framer func(dst, msg []byte) ([]byte, error)
+
+// Session combines a Protoscan reading inbound tokens with a Framer writing
+// outbound messages over a single network connection.
+//
+// The Scan, Token and Err methods must be called from one goroutine only,
+// the Send method is safe for concurrent use. A Session closes the
+// connection on the first read or write error.
+type Session struct {
+	conn         net.Conn      // The connection provided by the client.
+	scan         *Protoscan    // The scanner of the inbound tokens.
+	frame        Framer        // The function to frame the outbound messages.
+	opts         []Option      // Options of the inbound scanner.
+	readTimeout  time.Duration // Maximum duration of a single read, zero means no limit.
+	writeTimeout time.Duration // Maximum duration of a single write, zero means no limit.
+	interval     time.Duration // Interval of the outbound silence after which the heartbeat is sent.
+	heartbeat    []byte        // Heartbeat message, framed by the Framer before sending.
+	mu           sync.Mutex    // Guards the fields below.
+	buffer       []byte        // Buffer of the framed outbound message.
+	lastWrite    time.Time     // Time of the last successful write.
+	err          error         // Sticky write error.
+	done         chan struct{} // Closed when the session is closed.
+	closing      sync.Once     // Closes the session once.
+	closeErr     error         // Error returned by closing of the connection.
+}
+
+// NewSession returns a new Session reading tokens separated by the split
+// function and writing messages framed by the frame function.
+func NewSession(conn net.Conn, split SplitFunc, frame Framer, opts ...SessionOption) *Session {
+	s := &Session{
+		conn:      conn,
+		frame:     frame,
+		lastWrite: time.Now(),
+		done:      make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.scan = ScanConn(conn, split, append([]Option{WithIdleTimeout(s.readTimeout)}, s.opts...)...)
+	s.scan.closer = s
+	if s.interval > 0 {
+		go s.heartbeats()
+	}
+	return s
+}
+
+// SessionOption changes session.
+type SessionOption func(*Session)
+
+// WithReadTimeout sets maximum duration of a single read from the connection.
+func WithReadTimeout(d time.Duration) SessionOption {
+	return func(s *Session) { s.readTimeout = d }
+}
+
+// WithWriteTimeout sets maximum duration of a single write to the connection.
+func WithWriteTimeout(d time.Duration) SessionOption {
+	return func(s *Session) { s.writeTimeout = d }
+}
+
+// WithHeartbeat sets the message sent after the interval of outbound silence.
+func WithHeartbeat(interval time.Duration, msg []byte) SessionOption {
+	return func(s *Session) { s.interval, s.heartbeat = interval, msg }
+}
+
+// WithScanner sets options of the inbound scanner.
+func WithScanner(opts ...Option) SessionOption {
+	return func(s *Session) { s.opts = append(s.opts, opts...) }
+}
+
+// Scan advances the session to the next inbound token, which will then be
+// available through the Token method. It closes the connection and returns
+// false when the scan stops with an error.
+func (s *Session) Scan() bool {
+	return s.scan.Scan()
+}
+
+// Token returns the last token generated by a call to Scan.
+func (s *Session) Token() []byte {
+	return s.scan.Token()
+}
+
+// Err returns the first non-EOF error that was encountered by the session
+// while reading or writing.
+func (s *Session) Err() error {
+	if err := s.scan.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Send frames the message and writes it to the connection.
+func (s *Session) Send(msg []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.send(msg)
+}
+
+// send writes the message, the caller must hold the mutex.
+func (s *Session) send(msg []byte) error {
+	if s.err != nil {
+		return s.err
+	}
+	buf, err := s.frame(s.buffer[:0], msg)
+	if err != nil {
+		return err
+	}
+	s.buffer = buf
+	if s.writeTimeout > 0 {
+		err = s.conn.SetWriteDeadline(time.Now().Add(s.writeTimeout))
+	}
+	if err == nil {
+		_, err = s.conn.Write(buf)
+	}
+	if err != nil {
+		s.err = err
+		s.Close()
+		return err
+	}
+	s.lastWrite = time.Now()
+	return nil
+}
+
+// heartbeats sends the heartbeat message whenever nothing else was sent
+// during the interval.
+func (s *Session) heartbeats() {
+	t := time.NewTicker(s.interval / 2)
+	defer t.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-t.C:
+			s.mu.Lock()
+			if now.Sub(s.lastWrite) >= s.interval && s.send(s.heartbeat) != nil {
+				s.mu.Unlock()
+				return
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// Close stops the heartbeats and closes the connection.
+func (s *Session) Close() error {
+	s.closing.Do(func() {
+		close(s.done)
+		s.closeErr = s.conn.Close()
+	})
+	return s.closeErr
+}
@@ -0,0 +1,84 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/protoscan/protoscan"
+)
+
+func lineFramer(dst, msg []byte) ([]byte, error) {
+	dst = append(dst, msg...)
+	return append(dst, '\n'), nil
+}
+
+// Test that the session reads inbound tokens and frames outbound messages.
+func TestSession(t *testing.T) {
+	client, server := net.Pipe()
+	s := protoscan.NewSession(server, protoscan.ScanLines, lineFramer)
+	defer s.Close()
+	go func() {
+		client.Write([]byte("ping\n"))
+	}()
+	if !s.Scan() {
+		t.Fatalf("scan failed: %v", s.Err())
+	}
+	if string(s.Token()) != "ping" {
+		t.Fatalf("unexpected token: %q", s.Token())
+	}
+	go s.Send([]byte("pong"))
+	line, err := bufio.NewReader(client).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "pong\n" {
+		t.Fatalf("unexpected message: %q", line)
+	}
+}
+
+// Test that the session sends heartbeats during outbound silence.
+func TestSessionHeartbeat(t *testing.T) {
+	client, server := net.Pipe()
+	s := protoscan.NewSession(server, protoscan.ScanLines, lineFramer,
+		protoscan.WithHeartbeat(10*time.Millisecond, []byte("0")),
+	)
+	defer s.Close()
+	r := bufio.NewReader(client)
+	for i := 0; i < 3; i++ {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line != "0\n" {
+			t.Fatalf("unexpected heartbeat: %q", line)
+		}
+	}
+}
+
+// Test that the session closes the connection on a read error.
+func TestSessionCloseOnError(t *testing.T) {
+	client, server := net.Pipe()
+	s := protoscan.NewSession(server, protoscan.ScanLines, lineFramer,
+		protoscan.WithReadTimeout(time.Millisecond),
+	)
+	if s.Scan() {
+		t.Fatalf("unexpected token: %q", s.Token())
+	}
+	var netErr net.Error
+	if !errors.As(s.Err(), &netErr) || !netErr.Timeout() {
+		t.Fatalf("expected timeout; got %v", s.Err())
+	}
+	if _, err := client.Write([]byte("late\n")); err == nil {
+		t.Fatal("connection is not closed")
+	}
+	if err := s.Send([]byte("late")); err == nil {
+		t.Fatal("send to the closed connection")
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import "io"
+
+// ScanSIP is a split function for a Protoscan that returns each SIP
+// request or response transported over a stream as a token, the start
+// line and the header fields followed by the body of the length of the
+// Content-Length field, or of its compact form "l", 0 if absent. The
+// RTSP messages are framed alike, without their interleaved binary
+// frames, see the ScanRTSPInterleaved. The CRLFs between the messages,
+// such as the keep-alive pings, are skipped. The bad Content-Length and
+// the header longer than 64KiB stop the scan with the
+// ErrProtocolViolation.
+func ScanSIP(data []byte, atEOF bool) (int, int, []byte, error) {
+	start := 0
+	for start < len(data) && (data[start] == '\r' || data[start] == '\n') {
+		start++
+	}
+	if start == len(data) {
+		if atEOF {
+			return 0, start, nil, nil
+		}
+		return 1, 0, nil, nil
+	}
+	msg := data[start:]
+	end, err := headerEnd(msg)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if end < 0 {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return 1, 0, nil, nil
+	}
+	length, err := contentLength(msg[:end], "Content-Length", "l")
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	n := end + length
+	if len(msg) < n {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return n - len(msg), 0, nil, nil
+	}
+	return 0, start + n, msg[:n], nil
+}
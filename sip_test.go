@@ -0,0 +1,36 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanSIP(t *testing.T) {
+	invite := "INVITE sip:bob@example.com SIP/2.0\r\nVia: SIP/2.0/TCP host\r\nContent-Length: 9\r\n\r\nv=0\r\no=\r\n"
+	ok := "SIP/2.0 200 OK\r\nl: 3\r\n\r\nabc"
+	bye := "BYE sip:bob@example.com SIP/2.0\r\n\r\n"
+	describe := "RTSP/1.0 200 OK\r\nCSeq: 2\r\ncontent-length: 4\r\n\r\nv=0\n"
+	protoscantest.TestSplitFunc(t, protoscan.ScanSIP, []protoscantest.Case{
+		{Name: "empty", Input: "", Tokens: nil},
+		{
+			Name:   "messages",
+			Input:  "\r\n\r\n" + invite + ok + "\r\n\r\n" + bye + describe + "\r\n",
+			Tokens: []string{invite, ok, bye, describe},
+		},
+		{Name: "short header", Input: "BYE sip:bob SIP/2.0\r\n", Err: io.ErrUnexpectedEOF},
+		{Name: "short body", Input: "SIP/2.0 200 OK\r\nl: 3\r\n\r\nab", Err: io.ErrUnexpectedEOF},
+		{Name: "bad length", Input: "SIP/2.0 200 OK\r\nContent-Length: -1\r\n\r\n", Err: protoscan.ErrProtocolViolation},
+	})
+}
+
+func FuzzScanSIP(f *testing.F) {
+	f.Add([]byte("\r\n\r\nBYE sip:a SIP/2.0\r\nl: 1\r\n\r\nxSIP/2.0 200 OK\r\n\r\n"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanSIP))
+}
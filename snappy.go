@@ -0,0 +1,184 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// snappyStreamID is the stream identifier chunk which starts the snappy
+// framing format.
+const snappyStreamID = "\xff\x06\x00\x00sNaPpY"
+
+// snappyMaxBlock is the maximum size of the uncompressed data of a chunk.
+const snappyMaxBlock = 64 << 10
+
+// errSnappyCorrupt reports the corrupt snappy data.
+var errSnappyCorrupt = fmt.Errorf("%w: corrupt snappy chunk", ErrProtocolViolation)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+type snappyCodec struct{}
+
+func (snappyCodec) Detect(header []byte) bool {
+	// The header of the stream identifier chunk and as much of its body as
+	// read.
+	n := min(len(header), len(snappyStreamID))
+	return n >= 4 && string(header[:n]) == snappyStreamID[:n]
+}
+
+func (snappyCodec) NewReader(r io.Reader) (io.Reader, error) {
+	return &snappyReader{r: r}, nil
+}
+
+// snappyReader reads the data decompressed from the snappy framing format.
+type snappyReader struct {
+	r      io.Reader
+	chunks int    // Count of the chunks read.
+	chunk  []byte // The body of the chunk.
+	out    []byte // The decompressed data of the chunk.
+	buf    []byte // The decompressed data not yet read.
+	err    error
+}
+
+func (z *snappyReader) Read(p []byte) (int, error) {
+	for len(z.buf) == 0 {
+		if z.err != nil {
+			return 0, z.err
+		}
+		z.err = z.next()
+	}
+	n := copy(p, z.buf)
+	z.buf = z.buf[n:]
+	return n, nil
+}
+
+// next reads the next chunk.
+func (z *snappyReader) next() error {
+	var h [4]byte
+	if _, err := io.ReadFull(z.r, h[:]); err != nil {
+		if err == io.EOF && z.chunks > 0 {
+			return err
+		}
+		return unexpectedEOF(err)
+	}
+	z.chunks++
+	typ, size := h[0], int(h[1])|int(h[2])<<8|int(h[3])<<16
+	if z.chunks == 1 && typ != 0xff {
+		return fmt.Errorf("%w: no snappy stream identifier", ErrProtocolViolation)
+	}
+	if cap(z.chunk) < size {
+		z.chunk = make([]byte, size)
+	}
+	z.chunk = z.chunk[:size]
+	if _, err := io.ReadFull(z.r, z.chunk); err != nil {
+		return unexpectedEOF(err)
+	}
+	switch {
+	case typ == 0xff:
+		if string(h[:])+string(z.chunk) != snappyStreamID {
+			return fmt.Errorf("%w: bad snappy stream identifier", ErrProtocolViolation)
+		}
+		return nil
+	case typ == 0x00 || typ == 0x01:
+		if size < 4 {
+			return errSnappyCorrupt
+		}
+		data := z.chunk[4:]
+		if typ == 0x00 {
+			var err error
+			if z.out, err = snappyDecode(z.out[:0], data); err != nil {
+				return err
+			}
+			data = z.out
+		}
+		if len(data) > snappyMaxBlock {
+			return errSnappyCorrupt
+		}
+		// The masked CRC-32C of the uncompressed data.
+		c := crc32.Checksum(data, castagnoli)
+		if (c>>15|c<<17)+0xa282ead8 != binary.LittleEndian.Uint32(z.chunk) {
+			return fmt.Errorf("%w: bad snappy checksum", ErrProtocolViolation)
+		}
+		z.buf = data
+		return nil
+	case typ < 0x80:
+		return fmt.Errorf("%w: unskippable snappy chunk %#x", ErrProtocolViolation, typ)
+	}
+	// Padding and the skippable chunks.
+	return nil
+}
+
+// snappyDecode appends to the dst the data decompressed from the snappy
+// block.
+func snappyDecode(dst, src []byte) ([]byte, error) {
+	n, k := binary.Uvarint(src)
+	if k <= 0 || n > snappyMaxBlock {
+		return dst, errSnappyCorrupt
+	}
+	start := len(dst)
+	for i := k; i < len(src); {
+		tag := src[i]
+		var length, offset int
+		switch tag & 3 {
+		case 0:
+			length = int(tag >> 2)
+			i++
+			if length >= 60 {
+				b := length - 59
+				if b > len(src)-i {
+					return dst, errSnappyCorrupt
+				}
+				length = 0
+				for j := b - 1; j >= 0; j-- {
+					length = length<<8 | int(src[i+j])
+				}
+				i += b
+			}
+			length++
+			if length > len(src)-i || len(dst)-start+length > int(n) {
+				return dst, errSnappyCorrupt
+			}
+			dst = append(dst, src[i:i+length]...)
+			i += length
+			continue
+		case 1:
+			if i+2 > len(src) {
+				return dst, errSnappyCorrupt
+			}
+			length = 4 + int(tag>>2&7)
+			offset = int(tag>>5)<<8 | int(src[i+1])
+			i += 2
+		case 2:
+			if i+3 > len(src) {
+				return dst, errSnappyCorrupt
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint16(src[i+1:]))
+			i += 3
+		default:
+			if i+5 > len(src) {
+				return dst, errSnappyCorrupt
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint32(src[i+1:]))
+			i += 5
+		}
+		if offset <= 0 || offset > len(dst)-start || len(dst)-start+length > int(n) {
+			return dst, errSnappyCorrupt
+		}
+		for k := len(dst) - offset; length > 0; length-- {
+			dst = append(dst, dst[k])
+			k++
+		}
+	}
+	if len(dst)-start != int(n) {
+		return dst, errSnappyCorrupt
+	}
+	return dst, nil
+}
@@ -0,0 +1,55 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// soupBinTCPTypes holds the packet types of the SoupBinTCP 4.0, sent by
+// the server then by the client.
+const soupBinTCPTypes = "+AJSHZ" + "LURO"
+
+// ScanSoupBinTCP is a split function for a Protoscan that returns each
+// Nasdaq SoupBinTCP packet as a token, including its 2-byte big-endian
+// length, which counts the 1-byte packet type and the payload, such as
+// the ITCH or the OUCH message of the sequenced and the unsequenced data
+// packets. The heartbeats may be dropped by the IsSoupBinTCPKeepalive.
+// The End of Session packet is delivered together with the FinalToken.
+// The unknown packet type is reported as the ErrCorruptFrame, advancing
+// over the packet, and the length 0 stops the scan with the
+// ErrProtocolViolation.
+func ScanSoupBinTCP(data []byte, atEOF bool) (int, int, []byte, error) {
+	if len(data) == 0 && atEOF {
+		return 0, 0, nil, nil
+	}
+	if len(data) < 3 {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return 3 - len(data), 0, nil, nil
+	}
+	length := int(binary.BigEndian.Uint16(data))
+	if length == 0 {
+		return 0, 0, nil, fmt.Errorf("%w: SoupBinTCP packet without its type", ErrProtocolViolation)
+	}
+	n := 2 + length
+	if len(data) < n {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return n - len(data), 0, nil, nil
+	}
+	switch typ := data[2]; {
+	case bytes.IndexByte([]byte(soupBinTCPTypes), typ) < 0:
+		return 0, n, nil, fmt.Errorf("%w: unknown SoupBinTCP packet type %q", ErrCorruptFrame, typ)
+	case typ == 'Z':
+		return 0, n, data[:n], FinalToken
+	}
+	return 0, n, data[:n], nil
+}
@@ -0,0 +1,37 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanSoupBinTCP(t *testing.T) {
+	accepted := "\x00\x1fA" + "SESSION001" + strings.Repeat(" ", 19) + "1"
+	itch := "\x00\x0cS" + "S\x00\x00\x00\x01\x00\x00\x00\x00\x00O"
+	protoscantest.TestSplitFunc(t, protoscan.ScanSoupBinTCP, []protoscantest.Case{
+		{Name: "empty", Input: "", Tokens: nil},
+		{
+			Name:   "packets",
+			Input:  accepted + itch + "\x00\x01H" + "\x00\x01Z" + "\x00\x01H",
+			Tokens: []string{accepted, itch, "\x00\x01H", "\x00\x01Z"},
+		},
+		{Name: "client", Input: "\x00\x04Uabc\x00\x01R\x00\x01O", Tokens: []string{"\x00\x04Uabc", "\x00\x01R", "\x00\x01O"}},
+		{Name: "short length", Input: "\x00", Err: io.ErrUnexpectedEOF},
+		{Name: "short packet", Input: "\x00\x04Uab", Err: io.ErrUnexpectedEOF},
+		{Name: "unknown type", Input: "\x00\x01X", Err: protoscan.ErrCorruptFrame},
+		{Name: "no type", Input: "\x00\x00H", Err: protoscan.ErrProtocolViolation},
+	})
+}
+
+func FuzzScanSoupBinTCP(f *testing.F) {
+	f.Add([]byte("\x00\x03Sab\x00\x01H\x00\x01Z"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanSoupBinTCP))
+}
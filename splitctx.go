@@ -0,0 +1,72 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+// SplitContext carries the state of the scan handed to the SplitCtxFunc,
+// so that the stateful split functions keep the state in the Protoscan
+// rather than in the captured variables of the closures.
+type SplitContext struct {
+	Offset   int64  // Offset of the data in the data stream.
+	PrevHint int    // Hint returned by the previous call to the split function.
+	Scratch  []byte // Buffer reused by the split function between the calls.
+
+	// Indexes describe the structure of the token returned by the split
+	// function, for instance the offsets of its fields, in the layout
+	// documented by the split function. The Protoscan empties them before
+	// each call to the split function.
+	Indexes []int
+
+	// Unterminated is set by the split function delivering the final token
+	// at EOF without its terminator, see the WithUnterminated. The
+	// Protoscan clears it before each call to the split function.
+	Unterminated bool
+
+	values map[interface{}]interface{}
+}
+
+// Value returns the value of the key, or nil.
+func (c *SplitContext) Value(key interface{}) interface{} {
+	return c.values[key]
+}
+
+// SetValue sets the value of the key, for instance the per-connection
+// parameters of the protocol negotiated by a handshake.
+func (c *SplitContext) SetValue(key, value interface{}) {
+	if c.values == nil {
+		c.values = make(map[interface{}]interface{})
+	}
+	c.values[key] = value
+}
+
+// SplitCtxFunc is the signature of the split function receiving the
+// context of the scan, otherwise it is the same as the SplitFunc.
+type SplitCtxFunc func(ctx *SplitContext, data []byte, atEOF bool) (hint int, advance int, token []byte, err error)
+
+// WithSplitContext sets the function to split the tokens receiving the
+// context of the scan.
+func WithSplitContext(split SplitCtxFunc) Option {
+	return func(s *Protoscan) {
+		s.split = func(data []byte, atEOF bool) (int, int, []byte, error) {
+			s.splitCtx.Offset = s.offset + int64(s.start)
+			s.splitCtx.PrevHint = s.lastHint
+			s.splitCtx.Indexes = s.splitCtx.Indexes[:0]
+			s.splitCtx.Unterminated = false
+			return split(&s.splitCtx, data, atEOF)
+		}
+	}
+}
+
+// SplitContext returns the context handed to the split function set by the
+// WithSplitContext option, so that the values may be set before the scan.
+func (s *Protoscan) SplitContext() *SplitContext {
+	return &s.splitCtx
+}
+
+// Indexes returns the Indexes of the SplitContext set by the split function
+// for the last token generated by a call to Scan. The underlying array may
+// be overwritten by a subsequent call to Scan.
+func (s *Protoscan) Indexes() []int {
+	return s.splitCtx.Indexes
+}
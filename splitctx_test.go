@@ -0,0 +1,50 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+type delimiterKey struct{}
+
+// splitDelimited splits the tokens by the delimiter set as the value of the
+// context, prefixing the tokens with their offsets in the scratch buffer.
+func splitDelimited(ctx *protoscan.SplitContext, data []byte, atEOF bool) (int, int, []byte, error) {
+	delim := ctx.Value(delimiterKey{}).(byte)
+	i := bytes.IndexByte(data, delim)
+	if i < 0 {
+		if atEOF {
+			return 0, len(data), nil, nil
+		}
+		return 1, 0, nil, nil
+	}
+	ctx.Scratch = fmt.Appendf(ctx.Scratch[:0], "%d:%s", ctx.Offset, data[:i])
+	return 0, i + 1, ctx.Scratch, nil
+}
+
+func TestSplitContext(t *testing.T) {
+	s := protoscan.New(
+		&protoscantest.SlowReader{Max: 2, R: strings.NewReader("ab;c;;def;g")},
+		protoscan.WithSplitContext(splitDelimited),
+	)
+	s.SplitContext().SetValue(delimiterKey{}, byte(';'))
+	var tokens []string
+	for s.Scan() {
+		tokens = append(tokens, string(s.Token()))
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	if got := strings.Join(tokens, " "); got != "0:ab 3:c 5: 6:def" {
+		t.Fatalf("unexpected tokens %q", got)
+	}
+}
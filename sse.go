@@ -0,0 +1,100 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import "bytes"
+
+// sseLine returns the end of the line of the event stream at the pos,
+// terminated by the CRLF, the LF or the CR, and the offset of the next
+// line, or -1 if the line is incomplete.
+func sseLine(data []byte, pos int, atEOF bool) (int, int) {
+	i := bytes.IndexAny(data[pos:], "\r\n")
+	if i < 0 {
+		if atEOF && pos < len(data) {
+			return len(data), len(data)
+		}
+		return -1, -1
+	}
+	end := pos + i
+	if data[end] == '\n' {
+		return end, end + 1
+	}
+	if end+1 == len(data) {
+		if atEOF {
+			return end, end + 1
+		}
+		return -1, -1
+	}
+	if data[end+1] == '\n' {
+		return end, end + 2
+	}
+	return end, end + 1
+}
+
+// sseData returns the value of the data field of the line, and whether
+// the line is the data field.
+func sseData(line []byte) ([]byte, bool) {
+	name, value, _ := bytes.Cut(line, []byte(":"))
+	if string(name) != "data" {
+		return nil, false
+	}
+	if len(value) > 0 && value[0] == ' ' {
+		value = value[1:]
+	}
+	return value, true
+}
+
+// ScanSSE returns a split function for a Protoscan that returns each event
+// of the Server-Sent Events stream, the text/event-stream, as a token: its
+// lines up to the blank line dispatching it, without the line terminator
+// of its last line. The blank lines between the events are skipped. If
+// coalesce, the token is the data of the event instead, the values of its
+// data fields joined by the newlines, copied if there are several, and
+// the events of the empty data are skipped, as by the EventSource.
+// The incomplete event at EOF is discarded.
+func ScanSSE(coalesce bool) SplitFunc {
+	return func(data []byte, atEOF bool) (int, int, []byte, error) {
+		var token []byte
+		start, last, fields := -1, 0, 0
+		for pos := 0; ; {
+			end, next := sseLine(data, pos, atEOF)
+			if end < 0 {
+				if atEOF {
+					return 0, len(data), nil, nil
+				}
+				return 1, 0, nil, nil
+			}
+			line := data[pos:end]
+			pos = next
+			if len(line) > 0 {
+				if start < 0 {
+					start = end - len(line)
+				}
+				last = end
+				if value, ok := sseData(line); ok && coalesce {
+					switch fields++; fields {
+					case 1:
+						token = value
+					case 2:
+						token = append(make([]byte, 0, len(token)+1+len(value)), token...)
+						fallthrough
+					default:
+						token = append(append(token, '\n'), value...)
+					}
+				}
+				continue
+			}
+			switch {
+			case start < 0:
+			case !coalesce:
+				return 0, next, data[start:last], nil
+			case len(token) > 0:
+				return 0, next, token, nil
+			default:
+				start, fields, token = -1, 0, nil
+			}
+		}
+	}
+}
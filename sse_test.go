@@ -0,0 +1,45 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanSSE(t *testing.T) {
+	stream := "\r\n: comment\nevent: add\ndata: 1\ndata:2\r\ndata\r\rid: 7\n\n\n" +
+		"data: {\"done\":true}\r\n\r\n" + "data: lost\n"
+	protoscantest.TestSplitFunc(t, protoscan.ScanSSE(false), []protoscantest.Case{
+		{Name: "empty", Input: "", Tokens: nil},
+		{
+			Name:   "events",
+			Input:  stream,
+			Tokens: []string{": comment\nevent: add\ndata: 1\ndata:2\r\ndata", "id: 7", "data: {\"done\":true}"},
+		},
+	})
+	protoscantest.TestSplitFunc(t, protoscan.ScanSSE(true), []protoscantest.Case{
+		{Name: "empty", Input: "", Tokens: nil},
+		{
+			Name:   "coalesced",
+			Input:  stream,
+			Tokens: []string{"1\n2\n", "{\"done\":true}"},
+		},
+		{Name: "empty data", Input: "data:\n\ndata\n\ndata: \n\n", Tokens: nil},
+		{Name: "not data", Input: "database: x\n\ndata: y\r", Tokens: nil},
+	})
+}
+
+func FuzzScanSSE(f *testing.F) {
+	f.Add([]byte("data: a\r\ndata: b\r\rid: 1\n\n"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanSSE(true)))
+}
+
+func FuzzScanSSERaw(f *testing.F) {
+	f.Add([]byte("data: a\r\ndata: b\r\rid: 1\n\n"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanSSE(false)))
+}
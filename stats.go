@@ -0,0 +1,63 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import "sort"
+
+// DefaultHistogramBounds are the upper bounds of the buckets of the
+// histogram of the token sizes used if no bounds are given.
+var DefaultHistogramBounds = []int{16, 64, 256, 1024, 4096, 16384, 65536}
+
+// Bucket counts the tokens of the size up to Max, inclusive, and larger
+// than the Max of the previous bucket.
+type Bucket struct {
+	Max   int
+	Count int
+}
+
+// Stats holds the statistics of the tokens delivered by Scan.
+type Stats struct {
+	Tokens    int      // Count of the tokens.
+	Bytes     int64    // Total size of the tokens.
+	Histogram []Bucket // Histogram of the sizes of the tokens, the Max of the last bucket is the maximum int.
+	Retries   int      // Count of the reads retried by the WithRetry.
+}
+
+// WithHistogram makes the Protoscan maintain the histogram of the sizes of
+// the tokens with the buckets of the upper bounds, so that the maximum size
+// of the buffer may be planned from the real distribution of the sizes.
+// No bounds mean the DefaultHistogramBounds.
+func WithHistogram(bounds ...int) Option {
+	return func(s *Protoscan) {
+		if len(bounds) == 0 {
+			bounds = DefaultHistogramBounds
+		}
+		bounds = append([]int(nil), bounds...)
+		sort.Ints(bounds)
+		s.histogram = make([]Bucket, len(bounds)+1)
+		for i, max := range bounds {
+			s.histogram[i].Max = max
+		}
+		s.histogram[len(bounds)].Max = int(^uint(0) >> 1)
+	}
+}
+
+// Stats returns the statistics of the tokens, the histogram is set only if
+// the WithHistogram option is.
+func (s *Protoscan) Stats() Stats {
+	stats := s.stats
+	stats.Histogram = append([]Bucket(nil), s.histogram...)
+	return stats
+}
+
+// count adds the delivered token to the statistics.
+func (s *Protoscan) count(token []byte) {
+	s.stats.Tokens++
+	s.stats.Bytes += int64(len(token))
+	if s.histogram != nil {
+		i := sort.Search(len(s.histogram), func(i int) bool { return len(token) <= s.histogram[i].Max })
+		s.histogram[i].Count++
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+)
+
+func TestStats(t *testing.T) {
+	s := protoscan.New(
+		strings.NewReader("a\nbcd\n\nefghijklmn\nopqr\n"),
+		protoscan.WithSplit(protoscan.ScanLines),
+		protoscan.WithHistogram(4, 1),
+	)
+	for s.Scan() {
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	want := protoscan.Stats{
+		Tokens: 5,
+		Bytes:  18,
+		Histogram: []protoscan.Bucket{
+			{Max: 1, Count: 2},
+			{Max: 4, Count: 2},
+			{Max: int(^uint(0) >> 1), Count: 1},
+		},
+	}
+	if got := s.Stats(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v; got %v", want, got)
+	}
+}
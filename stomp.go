@@ -0,0 +1,65 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// ScanSTOMP is a split function for a Protoscan that returns each STOMP
+// frame as a token, the command, the headers and the body, without the
+// terminating NUL. The body of the frame with the content-length header is
+// of the length, so that it may hold the NUL, otherwise it ends at the
+// first NUL. The end of lines between the frames, the heart-beats, are
+// skipped. The body of the content-length not followed by the NUL stops
+// the scan with the ErrProtocolViolation.
+func ScanSTOMP(data []byte, atEOF bool) (int, int, []byte, error) {
+	switch {
+	case len(data) == 0:
+		if atEOF {
+			return 0, 0, nil, nil
+		}
+		return 1, 0, nil, nil
+	case data[0] == '\n':
+		return 0, 1, nil, nil
+	case data[0] == '\r' && len(data) > 1 && data[1] == '\n':
+		return 0, 2, nil, nil
+	}
+	end, err := headerEnd(data)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if end < 0 {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return 1, 0, nil, nil
+	}
+	n := -1
+	if _, ok := headerValue(data[:end], "content-length"); ok {
+		length, err := contentLength(data[:end], "content-length")
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		n = end + length + 1
+		if len(data) >= n && data[n-1] != 0 {
+			return 0, 0, nil, fmt.Errorf("%w: STOMP body not terminated by NUL", ErrProtocolViolation)
+		}
+	} else if i := bytes.IndexByte(data[end:], 0); i >= 0 {
+		n = end + i + 1
+	}
+	if n < 0 || len(data) < n {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		if n < 0 {
+			return 1, 0, nil, nil
+		}
+		return n - len(data), 0, nil, nil
+	}
+	return 0, n, data[:n-1], nil
+}
@@ -0,0 +1,37 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanSTOMP(t *testing.T) {
+	connect := "CONNECT\naccept-version:1.2\nhost:example.org\n\n"
+	send := "SEND\r\ndestination:/queue/a\r\ncontent-length:5\r\n\r\na\x00b\x00c"
+	message := "MESSAGE\ndestination:/queue/a\n\nhello"
+	protoscantest.TestSplitFunc(t, protoscan.ScanSTOMP, []protoscantest.Case{
+		{Name: "empty", Input: "", Tokens: nil},
+		{
+			Name:   "frames",
+			Input:  connect + "\x00\n" + send + "\x00\r\n\n" + message + "\x00",
+			Tokens: []string{connect, send, message},
+		},
+		{Name: "short header", Input: "SEND\ndestination:/queue/a\n", Err: io.ErrUnexpectedEOF},
+		{Name: "short body", Input: "SEND\n\nabc", Err: io.ErrUnexpectedEOF},
+		{Name: "short content-length", Input: "SEND\ncontent-length:5\n\na\x00b", Err: io.ErrUnexpectedEOF},
+		{Name: "no NUL", Input: "SEND\ncontent-length:1\n\nab\x00", Err: protoscan.ErrProtocolViolation},
+		{Name: "bad content-length", Input: "SEND\ncontent-length:x\n\n\x00", Err: protoscan.ErrProtocolViolation},
+	})
+}
+
+func FuzzScanSTOMP(f *testing.F) {
+	f.Add([]byte("SEND\ncontent-length:3\n\na\x00b\x00\nMESSAGE\n\nc\x00"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanSTOMP))
+}
@@ -0,0 +1,40 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"fmt"
+	"io"
+)
+
+// WithStrictEOF makes the Protoscan stop with the *PartialFrameError when
+// the data stream ends while the split function has not consumed all the
+// data, for instance while it hints the rest of a length-prefixed frame,
+// since the truncated stream of some protocols is a fault.
+func WithStrictEOF() Option {
+	return func(s *Protoscan) { s.strictEOF = true }
+}
+
+// PartialFrameError records the end of the data stream in the middle of the
+// frame. It wraps the io.ErrUnexpectedEOF.
+type PartialFrameError struct {
+	Offset int64  // Offset of the frame in the data stream.
+	Frame  []byte // Data of the frame read before the end.
+}
+
+func (e *PartialFrameError) Error() string {
+	return fmt.Sprintf("protoscan: unexpected EOF in frame of %d bytes at offset %d", len(e.Frame), e.Offset)
+}
+
+func (e *PartialFrameError) Unwrap() error { return io.ErrUnexpectedEOF }
+
+// partialFrame returns the error of the data not consumed at the end of
+// the data stream.
+func (s *Protoscan) partialFrame() error {
+	return &PartialFrameError{
+		Offset: s.offset + int64(s.start),
+		Frame:  append([]byte(nil), s.buffer[s.start:s.end]...),
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+)
+
+func TestStrictEOF(t *testing.T) {
+	for _, test := range []struct {
+		input string
+		frame string
+	}{
+		{input: "<123<456"},
+		{input: "<123<45", frame: "<45"},
+	} {
+		s := protoscan.New(strings.NewReader(test.input), protoscan.WithSplit(splitChecked), protoscan.WithStrictEOF())
+		for s.Scan() {
+		}
+		if test.frame == "" {
+			if s.Err() != nil {
+				t.Errorf("%q: unexpected error %v", test.input, s.Err())
+			}
+			continue
+		}
+		var partialErr *protoscan.PartialFrameError
+		if !errors.As(s.Err(), &partialErr) || !errors.Is(s.Err(), io.ErrUnexpectedEOF) {
+			t.Fatalf("%q: expected PartialFrameError; got %v", test.input, s.Err())
+		}
+		if string(partialErr.Frame) != test.frame || partialErr.Offset != 4 {
+			t.Errorf("%q: unexpected frame %q at %d", test.input, partialErr.Frame, partialErr.Offset)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// syslogMaxDigits is the maximum count of the digits of the MSG-LEN of the
+// octet-counting framing.
+const syslogMaxDigits = 9
+
+// ScanSyslog is a split function for a Protoscan that returns each syslog
+// message transported over TCP as a token, as framed by RFC 6587. The
+// message starting by its PRI, '<', is framed by the non-transparent
+// framing, terminated by the newline which is dropped with the carriage
+// return preceding it; the last message may be unterminated. Otherwise,
+// the message is framed by the octet counting, prefixed by its length in
+// decimal and the space, which are dropped. The frames are checked one by
+// one, so that the senders may mix the framings. The other prefixes stop
+// the scan with the ErrProtocolViolation.
+func ScanSyslog(data []byte, atEOF bool) (int, int, []byte, error) {
+	if len(data) == 0 {
+		if atEOF {
+			return 0, 0, nil, nil
+		}
+		return 1, 0, nil, nil
+	}
+	if data[0] == '<' {
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			return 0, i + 1, dropCR(data[:i]), nil
+		}
+		if atEOF {
+			return 0, len(data), dropCR(data), nil
+		}
+		return 1, 0, nil, nil
+	}
+	length := 0
+	i := 0
+	for ; i < len(data) && data[i] != ' '; i++ {
+		c := data[i]
+		if c < '0' || c > '9' || i == 0 && c == '0' || i == syslogMaxDigits {
+			return 0, 0, nil, fmt.Errorf("%w: bad syslog frame %q", ErrProtocolViolation, data[:i+1])
+		}
+		length = 10*length + int(c-'0')
+	}
+	if i == len(data) {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return 1, 0, nil, nil
+	}
+	if i == 0 {
+		return 0, 0, nil, fmt.Errorf("%w: bad syslog frame %q", ErrProtocolViolation, data[:1])
+	}
+	n := i + 1 + length
+	if n > len(data) {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return n - len(data), 0, nil, nil
+	}
+	return 0, n, data[i+1 : n], nil
+}
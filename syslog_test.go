@@ -0,0 +1,46 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanSyslog(t *testing.T) {
+	msg := "<34>1 2003-10-11T22:14:15.003Z host su - ID47 - multi\nline"
+	protoscantest.TestSplitFunc(t, protoscan.ScanSyslog, []protoscantest.Case{
+		{Name: "empty", Input: "", Tokens: nil},
+		{
+			Name:   "octet counting",
+			Input:  "58 " + msg + "4 <1>x",
+			Tokens: []string{msg, "<1>x"},
+		},
+		{
+			Name:   "non-transparent",
+			Input:  "<13>a\n<14>b\r\n<15>c",
+			Tokens: []string{"<13>a", "<14>b", "<15>c"},
+		},
+		{
+			Name:   "mixed",
+			Input:  "<13>a\n5 <14>b<15>c\n",
+			Tokens: []string{"<13>a", "<14>b", "<15>c"},
+		},
+		{Name: "short length", Input: "12", Err: io.ErrUnexpectedEOF},
+		{Name: "short message", Input: "12 <13>", Err: io.ErrUnexpectedEOF},
+		{Name: "bad length", Input: "1x <13>a", Err: protoscan.ErrProtocolViolation},
+		{Name: "leading zero", Input: "05 <13>a", Err: protoscan.ErrProtocolViolation},
+		{Name: "no length", Input: " <13>a", Err: protoscan.ErrProtocolViolation},
+		{Name: "long length", Input: "1234567890 <13>a", Err: protoscan.ErrProtocolViolation},
+	})
+}
+
+func FuzzScanSyslog(f *testing.F) {
+	f.Add([]byte("5 <13>a<14>b\n"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanSyslog))
+}
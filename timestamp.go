@@ -0,0 +1,89 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrTimestampUnsupported is returned by the TimestampConn if the connection
+// does not support the kernel receive timestamps.
+var ErrTimestampUnsupported = errors.New("protoscan: receive timestamps unsupported")
+
+// TimestampedReader is implemented by the readers which know the time at
+// which the data was received, such as the connections returned by the
+// TimestampConn. Protoscan reads from the TimestampedReader instead of
+// calling Read and attaches the timestamp of the last read of the frame to
+// the TokenInfo of the token. The zero timestamp means the time is unknown,
+// in which case the time of return from the read is used. The timestamps
+// are passed through the WithDecompression, WithCharsetDetection and
+// WithTransform options and the readers of the NewMulti.
+type TimestampedReader interface {
+	ReadTimestamped(p []byte) (n int, ts time.Time, err error)
+}
+
+// ReadTimestamped reads from the connection passing its timestamps through.
+func (r *connReader) ReadTimestamped(p []byte) (int, time.Time, error) {
+	if err := r.setDeadline(); err != nil {
+		return 0, time.Time{}, err
+	}
+	var n int
+	var ts time.Time
+	var err error
+	if tr, ok := r.conn.(TimestampedReader); ok {
+		n, ts, err = tr.ReadTimestamped(p)
+	} else {
+		n, err = r.conn.Read(p)
+	}
+	return n, ts, r.classify(err)
+}
+
+// ReadTimestamped reads the transformed data passing through the timestamp
+// of the last read of the raw data.
+func (r *transformReader) ReadTimestamped(p []byte) (int, time.Time, error) {
+	n, err := r.Read(p)
+	return n, r.time, err
+}
+
+// ReadTimestamped reads the decompressed data passing through the timestamp
+// of the last read of the compressed data.
+func (d *decompressReader) ReadTimestamped(p []byte) (int, time.Time, error) {
+	n, err := d.Read(p)
+	return n, d.stamp.time, err
+}
+
+// ReadTimestamped reads the data transformed to UTF-8 passing through the
+// timestamp of the last read of the raw data.
+func (c *charsetReader) ReadTimestamped(p []byte) (int, time.Time, error) {
+	n, err := c.Read(p)
+	return n, c.stamp.time, err
+}
+
+// ReadTimestamped reads from the current reader passing its timestamps
+// through.
+func (r *multiReader) ReadTimestamped(p []byte) (int, time.Time, error) {
+	return r.read(p)
+}
+
+// stampReader records the timestamp of the last read from the reader, the
+// zero time unless the reader is a TimestampedReader.
+type stampReader struct {
+	reader io.Reader
+	time   time.Time
+}
+
+func (r *stampReader) Read(p []byte) (int, error) {
+	tr, ok := r.reader.(TimestampedReader)
+	if !ok {
+		return r.reader.Read(p)
+	}
+	n, ts, err := tr.ReadTimestamped(p)
+	if n > 0 {
+		r.time = ts
+	}
+	return n, err
+}
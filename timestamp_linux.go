@@ -0,0 +1,90 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"io"
+	"net"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// TimestampConn enables the kernel receive timestamps (SO_TIMESTAMPNS) of
+// the connection and returns the connection implementing TimestampedReader.
+// The connection must implement the syscall.Conn, as the *net.TCPConn does.
+func TimestampConn(conn net.Conn) (net.Conn, error) {
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return nil, ErrTimestampUnsupported
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	var serr error
+	err = raw.Control(func(fd uintptr) {
+		serr = syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_TIMESTAMPNS, 1)
+	})
+	if err == nil {
+		err = serr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &timestampConn{
+		Conn: conn,
+		raw:  raw,
+		oob:  make([]byte, syscall.CmsgSpace(int(unsafe.Sizeof(syscall.Timespec{})))),
+	}, nil
+}
+
+// timestampConn reads the connection along with the kernel timestamps.
+type timestampConn struct {
+	net.Conn
+	raw syscall.RawConn
+	oob []byte
+}
+
+func (c *timestampConn) Read(p []byte) (int, error) {
+	n, _, err := c.ReadTimestamped(p)
+	return n, err
+}
+
+func (c *timestampConn) ReadTimestamped(p []byte) (int, time.Time, error) {
+	var n, oobn int
+	var err error
+	rerr := c.raw.Read(func(fd uintptr) bool {
+		n, oobn, _, _, err = syscall.Recvmsg(int(fd), p, c.oob, 0)
+		return err != syscall.EAGAIN
+	})
+	if rerr != nil {
+		return 0, time.Time{}, rerr
+	}
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	if n == 0 && len(p) > 0 {
+		return 0, time.Time{}, io.EOF
+	}
+	return n, parseTimestamp(c.oob[:oobn]), nil
+}
+
+// parseTimestamp returns the timestamp of the control messages,
+// or the zero time if none.
+func parseTimestamp(oob []byte) time.Time {
+	msgs, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil {
+		return time.Time{}
+	}
+	for _, m := range msgs {
+		if m.Header.Level == syscall.SOL_SOCKET && m.Header.Type == syscall.SCM_TIMESTAMPNS &&
+			len(m.Data) >= int(unsafe.Sizeof(syscall.Timespec{})) {
+			ts := (*syscall.Timespec)(unsafe.Pointer(&m.Data[0]))
+			return time.Unix(ts.Unix())
+		}
+	}
+	return time.Time{}
+}
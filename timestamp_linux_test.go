@@ -0,0 +1,54 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/protoscan/protoscan"
+)
+
+func TestTimestampConn(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	go func() {
+		c, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			return
+		}
+		c.Write([]byte("abc\n"))
+		c.Close()
+	}()
+	c, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := protoscan.TimestampConn(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := time.Now().Add(-time.Minute)
+	s := protoscan.ScanConn(conn, protoscan.ScanLines)
+	if !s.Scan() {
+		t.Fatal(s.Err())
+	}
+	if string(s.Token()) != "abc" {
+		t.Fatalf("unexpected token: %q", s.Token())
+	}
+	if ts := s.TokenInfo().Time; ts.Before(before) || ts.After(time.Now()) {
+		t.Fatalf("unexpected timestamp: %v", ts)
+	}
+	if s.Scan() {
+		t.Fatalf("unexpected token: %q", s.Token())
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+}
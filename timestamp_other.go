@@ -0,0 +1,15 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+
+package protoscan
+
+import "net"
+
+// TimestampConn returns the ErrTimestampUnsupported, the kernel receive
+// timestamps are only supported on Linux.
+func TimestampConn(conn net.Conn) (net.Conn, error) {
+	return nil, ErrTimestampUnsupported
+}
@@ -0,0 +1,95 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/protoscan/protoscan"
+)
+
+// stampedReader stamps each read with the next second since the epoch
+// and records the offsets just past the bytes of the reads.
+type stampedReader struct {
+	r    io.Reader
+	ends []int64
+}
+
+func (sr *stampedReader) Read(p []byte) (int, error) {
+	panic("Read is called instead of ReadTimestamped")
+}
+
+func (sr *stampedReader) ReadTimestamped(p []byte) (int, time.Time, error) {
+	if len(p) > 2 {
+		p = p[:2]
+	}
+	n, err := sr.r.Read(p)
+	var end int64
+	if len(sr.ends) > 0 {
+		end = sr.ends[len(sr.ends)-1]
+	}
+	sr.ends = append(sr.ends, end+int64(n))
+	return n, time.Unix(int64(len(sr.ends)), 0), err
+}
+
+func TestTimestampedReader(t *testing.T) {
+	sr := &stampedReader{r: strings.NewReader("a\nbcd\nefghi\n")}
+	s := protoscan.New(sr, protoscan.WithSplit(protoscan.ScanLines))
+	var i int
+	for i = 0; s.Scan(); i++ {
+		// The stamp is the one of the read of the last byte of the frame.
+		var want int64
+		for want = 1; sr.ends[want-1] < s.TokenInfo().End; want++ {
+		}
+		if got := s.TokenInfo().Time.Unix(); got != want {
+			t.Errorf("%d: expected stamp %d got %d", i, want, got)
+		}
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	if i != 3 {
+		t.Fatalf("expected 3 tokens; got %d", i)
+	}
+}
+
+// Test that the readers wrapping the reader pass its timestamps through.
+func TestTimestampedReaderWrapped(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		new  func(r io.Reader) *protoscan.Protoscan
+	}{
+		{"decompression", func(r io.Reader) *protoscan.Protoscan {
+			return protoscan.New(r, protoscan.WithSplit(protoscan.ScanLines), protoscan.WithDecompression(protoscan.Gzip))
+		}},
+		{"charset", func(r io.Reader) *protoscan.Protoscan {
+			return protoscan.New(r, protoscan.WithSplit(protoscan.ScanLines), protoscan.WithCharsetDetection())
+		}},
+		{"multi", func(r io.Reader) *protoscan.Protoscan {
+			return protoscan.NewMulti([]io.Reader{r}, nil, protoscan.WithSplit(protoscan.ScanLines))
+		}},
+	} {
+		sr := &stampedReader{r: strings.NewReader("a\nbcd\nefghi\n")}
+		s := test.new(sr)
+		var i int
+		var last int64
+		for i = 0; s.Scan(); i++ {
+			got := s.TokenInfo().Time.Unix()
+			if got < last || got > int64(len(sr.ends)) {
+				t.Errorf("%s: %d: unexpected stamp %d of %d reads", test.name, i, got, len(sr.ends))
+			}
+			last = got
+		}
+		if s.Err() != nil {
+			t.Fatalf("%s: %v", test.name, s.Err())
+		}
+		if i != 3 {
+			t.Fatalf("%s: expected 3 tokens; got %d", test.name, i)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+// Tracer receives the events of the scanning of the individual frames, so
+// that the frames may be traced, for instance, as spans or span events of
+// the OpenTelemetry without the package depending on the tracing library.
+//
+// StartFrame is called when the Protoscan starts looking for the next token
+// at the offset of the data stream. EndFrame is called for each token split,
+// including the dropped ones, with the metadata of the token. Error is
+// called once when the scan stops with an error other than io.EOF.
+type Tracer interface {
+	StartFrame(offset int64)
+	EndFrame(info TokenInfo, token []byte)
+	Error(offset int64, err error)
+}
+
+// RetryTracer is implemented by the Tracer which receives the reads retried
+// by the WithRetry: Retry is called before each retry with the offset of the
+// data stream, the count of the successive retries and the error of the
+// read.
+type RetryTracer interface {
+	Retry(offset int64, attempt int, err error)
+}
+
+// WithTracer sets the tracer of the frames.
+func WithTracer(t Tracer) Option {
+	return func(s *Protoscan) { s.tracer = t }
+}
@@ -0,0 +1,63 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+)
+
+// eventTracer records the events of the frames.
+type eventTracer []string
+
+func (t *eventTracer) StartFrame(offset int64) {
+	*t = append(*t, fmt.Sprintf("start %d", offset))
+}
+
+func (t *eventTracer) EndFrame(info protoscan.TokenInfo, token []byte) {
+	*t = append(*t, fmt.Sprintf("end %d-%d %q", info.Start, info.End, token))
+}
+
+func (t *eventTracer) Error(offset int64, err error) {
+	*t = append(*t, fmt.Sprintf("error %d %v", offset, err))
+}
+
+func TestTracer(t *testing.T) {
+	errBad := errors.New("bad")
+	split := func(data []byte, atEOF bool) (int, int, []byte, error) {
+		hint, advance, token, err := protoscan.ScanLines(data, atEOF)
+		if string(token) == "bad" {
+			return 0, 0, nil, errBad
+		}
+		return hint, advance, token, err
+	}
+	var tracer eventTracer
+	s := protoscan.New(
+		strings.NewReader("ab\nskip\ncd\nbad\n"),
+		protoscan.WithSplit(split),
+		protoscan.WithDrop(func(token []byte) bool { return string(token) == "skip" }),
+		protoscan.WithTracer(&tracer),
+	)
+	for s.Scan() {
+	}
+	s.Scan()
+	want := []string{
+		`start 0`,
+		`end 0-3 "ab"`,
+		`start 3`,
+		`end 3-8 "skip"`,
+		`start 8`,
+		`end 8-11 "cd"`,
+		`start 11`,
+		`error 11 bad`,
+	}
+	if got := strings.Join(tracer, "\n"); got != strings.Join(want, "\n") {
+		t.Fatalf("unexpected events:\n%s", got)
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Transformer transforms bytes of the data stream before they are handed to
+// the split function. The method set is the same as the one of the
+// golang.org/x/text/transform.Transformer, so transformers of the x/text
+// packages (charmap, japanese, unicode and so on) may be used directly. The
+// converse does not hold: the transformers of this package report the short
+// buffers with the ErrShortDst and the ErrShortSrc, not with the errors of
+// the x/text/transform package, so they are not usable with its Chain or
+// NewReader.
+//
+// Transform writes to dst the transformed bytes read from src, and returns
+// the number of dst bytes written and src bytes read. The atEOF argument
+// tells whether src represents the last bytes of the input. A transformer
+// reports a need of a longer src or dst by returning an error together
+// with the progress made so far.
+type Transformer interface {
+	Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error)
+	Reset()
+}
+
+// Errors returned by the transformers of this package on the short buffers,
+// distinct from the ones of the x/text/transform package.
+var (
+	ErrShortDst = errors.New("protoscan: short destination buffer")
+	ErrShortSrc = errors.New("protoscan: short source buffer")
+)
+
+var errInconsistentByteCount = errors.New("protoscan: Transform returned success with unread source")
+
+// WithTransform sets the transformer of the raw data stream. The hints and
+// the maximum size of the buffer are applied to the transformed bytes.
+func WithTransform(t Transformer) Option {
+	return func(s *Protoscan) { s.transformer = t }
+}
+
+// transformBufSize is the size of the buffers of the transforming reader.
+const transformBufSize = 4096
+
+// transformReader reads the transformed data stream. Unlike the reader of
+// the x/text/transform package it does not rely on particular error values,
+// so that any error without progress on a non-full source buffer is treated
+// as a request of a longer source.
+type transformReader struct {
+	reader      io.Reader   // The reader of the raw data.
+	transformer Transformer // The transformer of the raw data.
+	err         error       // Sticky io.EOF of the reader or error of the transformer.
+	readErr     error       // Other error of the reader, returned once after the data read.
+	done        bool        // Whether the transformation is complete.
+	dst         []byte      // Buffer of the transformed data.
+	dst0, dst1  int         // Transformed data not yet read is dst[dst0:dst1].
+	src         []byte      // Buffer of the raw data.
+	src0, src1  int         // Raw data not yet transformed is src[src0:src1].
+	time        time.Time   // Timestamp of the last read of the raw data.
+}
+
+func newTransformReader(r io.Reader, t Transformer) *transformReader {
+	t.Reset()
+	return &transformReader{
+		reader:      r,
+		transformer: t,
+		dst:         make([]byte, transformBufSize),
+		src:         make([]byte, transformBufSize),
+	}
+}
+
+func (r *transformReader) Read(p []byte) (int, error) {
+	for {
+		// Copy out any previously transformed bytes.
+		if r.dst0 != r.dst1 {
+			n := copy(p, r.dst[r.dst0:r.dst1])
+			r.dst0 += n
+			if r.dst0 == r.dst1 && r.done {
+				return n, r.err
+			}
+			return n, nil
+		} else if r.done {
+			return 0, r.err
+		}
+		// Transform the buffered source, or flush the transformer at EOF.
+		if r.src0 != r.src1 || r.err != nil {
+			var nSrc int
+			var err error
+			r.dst0 = 0
+			r.dst1, nSrc, err = r.transformer.Transform(r.dst, r.src[r.src0:r.src1], r.err == io.EOF)
+			r.src0 += nSrc
+			switch {
+			case err == nil:
+				if r.src0 != r.src1 {
+					r.err = errInconsistentByteCount
+				}
+				r.done = r.err != nil
+				continue
+			case r.dst1 != 0 || nSrc != 0:
+				continue
+			case r.src1-r.src0 != len(r.src) && r.err == nil:
+				// Read more source below.
+			default:
+				r.done = true
+				if r.err == nil || r.err == io.EOF {
+					r.err = err
+				}
+				continue
+			}
+		}
+		if r.readErr != nil {
+			err := r.readErr
+			r.readErr = nil
+			return 0, err
+		}
+		// Move the remaining source to the beginning of the buffer and read more.
+		if r.src0 != 0 {
+			r.src0, r.src1 = 0, copy(r.src, r.src[r.src0:r.src1])
+		}
+		var n int
+		var err error
+		if tr, ok := r.reader.(TimestampedReader); ok {
+			n, r.time, err = tr.ReadTimestamped(r.src[r.src1:])
+		} else {
+			n, err = r.reader.Read(r.src[r.src1:])
+		}
+		if n < 0 || len(r.src)-r.src1 < n {
+			return 0, fmt.Errorf("%w: %d of %d", ErrBadReadCount, n, len(r.src)-r.src1)
+		}
+		r.src1 += n
+		if err != nil && err != io.EOF {
+			// Pass the error through without ending the transformation, so
+			// that the Protoscan may retry the read.
+			r.readErr = err
+			continue
+		}
+		r.err = err
+		if n == 0 && err == nil {
+			// Let the Protoscan count the empty read.
+			return 0, nil
+		}
+	}
+}
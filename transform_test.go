@@ -0,0 +1,142 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+// xorTransformer deobfuscates the data XORed with the key.
+type xorTransformer byte
+
+func (x xorTransformer) Transform(dst, src []byte, atEOF bool) (int, int, error) {
+	n := len(src)
+	if n > len(dst) {
+		n = len(dst)
+	}
+	for i := 0; i < n; i++ {
+		dst[i] = src[i] ^ byte(x)
+	}
+	if n < len(src) {
+		return n, n, protoscan.ErrShortDst
+	}
+	return n, n, nil
+}
+
+func (xorTransformer) Reset() {}
+
+// pairTransformer keeps the first byte of each pair of bytes, so that it
+// needs more source whenever an odd byte is left.
+type pairTransformer struct{}
+
+func (pairTransformer) Transform(dst, src []byte, atEOF bool) (int, int, error) {
+	nDst, nSrc := 0, 0
+	for ; nSrc+1 < len(src); nSrc += 2 {
+		if nDst == len(dst) {
+			return nDst, nSrc, protoscan.ErrShortDst
+		}
+		dst[nDst] = src[nSrc]
+		nDst++
+	}
+	if nSrc < len(src) && !atEOF {
+		return nDst, nSrc, protoscan.ErrShortSrc
+	}
+	if nSrc < len(src) {
+		// Drop the odd byte at EOF.
+		nSrc++
+	}
+	return nDst, nSrc, nil
+}
+
+func (pairTransformer) Reset() {}
+
+func TestTransform(t *testing.T) {
+	text := []byte("abc def\nghi\n")
+	obfuscated := make([]byte, len(text))
+	for i := range text {
+		obfuscated[i] = text[i] ^ 0x5a
+	}
+	s := protoscan.New(
+		&protoscantest.SlowReader{Max: 3, R: bytes.NewReader(obfuscated)},
+		protoscan.WithSplit(protoscan.ScanLines),
+		protoscan.WithTransform(xorTransformer(0x5a)),
+	)
+	var lines []string
+	for s.Scan() {
+		lines = append(lines, string(s.Token()))
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	if got := strings.Join(lines, "|"); got != "abc def|ghi" {
+		t.Fatalf("unexpected lines: %q", got)
+	}
+}
+
+// Test that a transformer requesting more source is fed byte by byte.
+func TestTransformShortSrc(t *testing.T) {
+	s := protoscan.New(
+		&protoscantest.SlowReader{Max: 1, R: strings.NewReader("aabbcc\n\nddx")},
+		protoscan.WithSplit(protoscan.ScanLines),
+		protoscan.WithTransform(pairTransformer{}),
+	)
+	var lines []string
+	for s.Scan() {
+		lines = append(lines, string(s.Token()))
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	if got := strings.Join(lines, "|"); got != "abc|d" {
+		t.Fatalf("unexpected lines: %q", got)
+	}
+}
+
+// Test that the transformed data larger than the transform buffer is read.
+func TestTransformLarge(t *testing.T) {
+	text := strings.Repeat("x", 3*protoscan.MaxBuffer/2)
+	s := protoscan.New(
+		strings.NewReader(text+"\n"),
+		protoscan.WithSplit(protoscan.ScanLines),
+		protoscan.WithTransform(xorTransformer(0)),
+		protoscan.WithMaxBuffer(2*protoscan.MaxBuffer),
+	)
+	if !s.Scan() {
+		t.Fatal(s.Err())
+	}
+	if string(s.Token()) != text {
+		t.Fatalf("unexpected token of length %d", len(s.Token()))
+	}
+}
+
+// Test that the temporary errors of the reader are passed through the
+// transformation, so that the reads are retried.
+func TestTransformRetry(t *testing.T) {
+	s := protoscan.New(
+		&timeoutReader{every: 3, r: strings.NewReader("aabb\nncdd\n\n")},
+		protoscan.WithSplit(protoscan.ScanLines),
+		protoscan.WithTransform(pairTransformer{}),
+		protoscan.WithRetry(protoscan.RetryPolicy{Attempts: 3, Backoff: protoscan.BackoffPolicy{Initial: time.Microsecond}}),
+	)
+	var lines []string
+	for s.Scan() {
+		lines = append(lines, string(s.Token()))
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	if got := strings.Join(lines, "|"); got != "ab|cd" {
+		t.Fatalf("unexpected lines %q", got)
+	}
+	if s.Retries() == 0 {
+		t.Fatal("expected retries")
+	}
+}
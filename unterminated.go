@@ -0,0 +1,50 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import "io"
+
+// UnterminatedMode defines the handling of the unterminated final token, that
+// is the token the split function delivers at EOF only because of the EOF,
+// such as the last line without a newline delivered by ScanLines.
+type UnterminatedMode int
+
+// Modes of the handling of the unterminated final token.
+const (
+	UnterminatedToken UnterminatedMode = iota // Deliver as a token.
+	UnterminatedFlag                          // Deliver as a token flagged by the Unterminated of the TokenInfo.
+	UnterminatedGap                           // Do not deliver, leave the data to the Remainder.
+)
+
+// WithUnterminated sets the handling of the unterminated final token. The
+// token delivered at EOF is unterminated if the split function set by the
+// WithSplitContext sets the Unterminated of the SplitContext, as the split
+// functions wrapped by the SplitUnterminated do.
+func WithUnterminated(mode UnterminatedMode) Option {
+	return func(s *Protoscan) { s.unterminatedMode = mode }
+}
+
+// SplitUnterminated returns the split function setting the Unterminated of
+// the SplitContext on the final token delivered by the split function at
+// EOF, if the split function does not deliver it from the same data without
+// EOF. The split function is called twice on the final token, so it must be
+// stateless, as the ScanLines, the ScanRawLines, the ScanWords and the split
+// functions of the ScanDelimiter are.
+func SplitUnterminated(split SplitFunc) SplitCtxFunc {
+	return func(ctx *SplitContext, data []byte, atEOF bool) (int, int, []byte, error) {
+		hint, advance, token, err := split(data, atEOF)
+		if atEOF && token != nil && err == nil {
+			_, _, t, e := split(data[:advance], false)
+			ctx.Unterminated = t == nil && e == nil
+		}
+		return hint, advance, token, err
+	}
+}
+
+// unterminated reports whether the token returned by the split function is
+// the unterminated final token.
+func (s *Protoscan) unterminated() bool {
+	return s.unterminatedMode != UnterminatedToken && s.err == io.EOF && s.splitCtx.Unterminated
+}
@@ -0,0 +1,97 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+)
+
+func TestUnterminated(t *testing.T) {
+	for _, test := range []struct {
+		mode      protoscan.UnterminatedMode
+		input     string
+		tokens    string
+		remainder string
+	}{
+		{mode: protoscan.UnterminatedToken, input: "ab\ncd", tokens: "ab|cd"},
+		{mode: protoscan.UnterminatedFlag, input: "ab\ncd", tokens: "ab|cd!"},
+		{mode: protoscan.UnterminatedFlag, input: "ab\ncd\n", tokens: "ab|cd"},
+		{mode: protoscan.UnterminatedGap, input: "ab\ncd", tokens: "ab", remainder: "cd"},
+		{mode: protoscan.UnterminatedGap, input: "ab\ncd\n", tokens: "ab|cd"},
+	} {
+		s := protoscan.New(
+			strings.NewReader(test.input),
+			protoscan.WithSplitContext(protoscan.SplitUnterminated(protoscan.ScanLines)),
+			protoscan.WithUnterminated(test.mode),
+		)
+		var tokens []string
+		for s.Scan() {
+			token := string(s.Token())
+			if s.TokenInfo().Unterminated {
+				token += "!"
+			}
+			tokens = append(tokens, token)
+		}
+		if s.Err() != nil {
+			t.Fatal(s.Err())
+		}
+		if got := strings.Join(tokens, "|"); got != test.tokens || string(s.Remainder()) != test.remainder {
+			t.Errorf("mode %d, %q: unexpected tokens %q, remainder %q", test.mode, test.input, got, s.Remainder())
+		}
+	}
+}
+
+// Test that the unterminated token is told by the split function, without
+// splitting its data again, which would corrupt the state of the split
+// function.
+func TestUnterminatedNoSplitAgain(t *testing.T) {
+	var eof bool
+	split := func(ctx *protoscan.SplitContext, data []byte, atEOF bool) (int, int, []byte, error) {
+		if eof && !atEOF {
+			t.Fatalf("split of %q without EOF after EOF", data)
+		}
+		eof = atEOF
+		hint, advance, token, err := protoscan.ScanLines(data, atEOF)
+		if token != nil {
+			ctx.Indexes = append(ctx.Indexes, len(token))
+			ctx.Unterminated = atEOF && data[advance-1] != '\n'
+		}
+		return hint, advance, token, err
+	}
+	s := protoscan.New(
+		strings.NewReader("ab\ncde"),
+		protoscan.WithSplitContext(split),
+		protoscan.WithUnterminated(protoscan.UnterminatedFlag),
+	)
+	var tokens []string
+	for s.Scan() {
+		tokens = append(tokens, fmt.Sprint(string(s.Token()), s.Indexes(), s.TokenInfo().Unterminated))
+	}
+	if got := strings.Join(tokens, "|"); got != "ab[2] false|cde[3] true" {
+		t.Fatalf("unexpected tokens %q", got)
+	}
+}
+
+func TestSplitUnterminated(t *testing.T) {
+	split := protoscan.SplitUnterminated(protoscan.ScanWords)
+	for _, test := range []struct {
+		data         string
+		unterminated bool
+	}{
+		{"ab", true},
+		{"ab ", false},
+		{" ab", true},
+	} {
+		var ctx protoscan.SplitContext
+		hint, advance, token, err := split(&ctx, []byte(test.data), true)
+		if hint != 0 || advance != len(test.data) || string(token) != "ab" || err != nil || ctx.Unterminated != test.unterminated {
+			t.Errorf("%q: unexpected split %d %d %q %v, unterminated %t", test.data, hint, advance, token, err, ctx.Unterminated)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// ScanVarintDelimited returns the split function for a Protoscan that
+// returns each payload prefixed by its length in the protobuf base 128
+// varint encoding, as written by the protodelim package or the
+// writeDelimitedTo of the other protobuf implementations. Once the varint
+// is decoded, it hints the exact count of the bytes of the payload
+// missing. The varint longer than 10 bytes stops the scan with the
+// ErrProtocolViolation, the payload longer than the max, if positive, with
+// the ErrTooLong.
+func ScanVarintDelimited(max int) SplitFunc {
+	return func(data []byte, atEOF bool) (int, int, []byte, error) {
+		if len(data) == 0 && atEOF {
+			return 0, 0, nil, nil
+		}
+		length, size := binary.Uvarint(data)
+		if size < 0 {
+			return 0, 0, nil, fmt.Errorf("%w: varint overflows 64 bits", ErrProtocolViolation)
+		}
+		if size == 0 {
+			if atEOF {
+				return 0, 0, nil, io.ErrUnexpectedEOF
+			}
+			return 1, 0, nil, nil
+		}
+		limit := uint64(math.MaxInt - size)
+		if max > 0 {
+			limit = uint64(max)
+		}
+		if length > limit {
+			return 0, 0, nil, fmt.Errorf("%w: payload of %d bytes exceeds maximum of %d", ErrTooLong, length, limit)
+		}
+		n := size + int(length)
+		if len(data) < n {
+			if atEOF {
+				return 0, 0, nil, io.ErrUnexpectedEOF
+			}
+			return n - len(data), 0, nil, nil
+		}
+		return 0, n, data[size:n], nil
+	}
+}
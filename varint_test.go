@@ -0,0 +1,34 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+func TestScanVarintDelimited(t *testing.T) {
+	long := strings.Repeat("x", 300)
+	protoscantest.TestSplitFunc(t, protoscan.ScanVarintDelimited(0), []protoscantest.Case{
+		{Name: "empty"},
+		{Name: "1-byte", Input: "\x03abc\x00\x01d", Tokens: []string{"abc", "", "d"}},
+		{Name: "2-byte", Input: "\xac\x02" + long, Tokens: []string{long}},
+		{Name: "truncated varint", Input: "\x01a\x80", Tokens: []string{"a"}, Err: io.ErrUnexpectedEOF},
+		{Name: "truncated payload", Input: "\x05abc", Err: io.ErrUnexpectedEOF},
+		{Name: "overflow", Input: strings.Repeat("\xff", 10) + "\x01", Err: protoscan.ErrProtocolViolation},
+	})
+	protoscantest.TestSplitFunc(t, protoscan.ScanVarintDelimited(2), []protoscantest.Case{
+		{Name: "max", Input: "\x02ab\x03abc", Tokens: []string{"ab"}, Err: protoscan.ErrTooLong},
+	})
+}
+
+func FuzzScanVarintDelimited(f *testing.F) {
+	f.Add([]byte("\x03abc\xac\x02x"))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanVarintDelimited(1 << 10)))
+}
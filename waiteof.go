@@ -0,0 +1,92 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// WithWaitEOF makes the Protoscan, on io.EOF returned by the reader, poll
+// the reader every poll interval for the new data instead of stopping, so
+// that the incomplete frames at the end of a growing file are completed
+// when more data arrives. The scan stops with io.EOF if no data arrives
+// within the timeout, zero timeout means waiting forever. The reader must
+// be readable after io.EOF, as the *os.File of a regular file is.
+//
+// On Linux the file is watched with inotify, so the wait ends as soon as
+// the file is modified and the poll interval only bounds it. The wait ends
+// on the cancellation of the context of the ScanContext, and on the
+// deadline when the WithAbort is set, in which case the scan stops with the
+// ErrAborted wrapping io.EOF.
+func WithWaitEOF(poll, timeout time.Duration) Option {
+	return func(s *Protoscan) {
+		s.eofPoll = poll
+		s.eofTimeout = timeout
+	}
+}
+
+// waitEOF waits for the poll interval after io.EOF returned by the reader.
+// It reports whether the reader should be read again.
+func (s *Protoscan) waitEOF(err error) bool {
+	if err != io.EOF || s.eofPoll <= 0 {
+		return false
+	}
+	if s.eofTimeout > 0 && s.eofWaited >= s.eofTimeout {
+		return false
+	}
+	start := time.Now()
+	err = s.wait(s.eofPoll)
+	s.eofWaited += time.Since(start)
+	if err != nil {
+		s.setErr(err)
+		return false
+	}
+	return true
+}
+
+// wait waits for the duration, the modification of the file read or the
+// cancellation, whichever comes first. It returns the error stopping the
+// scan on the cancellation or the deadline.
+func (s *Protoscan) wait(d time.Duration) error {
+	var deadline time.Time
+	if s.abort != nil {
+		deadline = s.deadline()
+	}
+	if !deadline.IsZero() {
+		d = min(d, time.Until(deadline))
+	}
+	if f := readFile(s.reader); f == nil || !waitFile(s.ctx, f, d) {
+		var done <-chan struct{}
+		if s.ctx != nil {
+			done = s.ctx.Done()
+		}
+		timer := time.NewTimer(d)
+		select {
+		case <-timer.C:
+		case <-done:
+			timer.Stop()
+		}
+	}
+	if s.ctx != nil && s.ctx.Err() != nil {
+		return s.ctx.Err()
+	}
+	if !deadline.IsZero() && !time.Now().Before(deadline) {
+		return fmt.Errorf("%w: %w", ErrAborted, io.EOF)
+	}
+	return nil
+}
+
+// readFile returns the file read by the reader, directly or through the
+// ContextReader, nil otherwise.
+func readFile(r io.Reader) *os.File {
+	if cr, ok := r.(*contextReader); ok {
+		r = cr.reader
+	}
+	f, _ := r.(*os.File)
+	return f
+}
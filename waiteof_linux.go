@@ -0,0 +1,47 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"context"
+	"io"
+	"os"
+	"syscall"
+	"time"
+)
+
+// waitFile waits up to the duration for the modification of the file with
+// inotify, or for the cancellation of the context, if not nil. It reports
+// false if the file cannot be watched, the caller then sleeps instead.
+func waitFile(ctx context.Context, f *os.File, d time.Duration) bool {
+	fd, err := syscall.InotifyInit1(syscall.IN_NONBLOCK | syscall.IN_CLOEXEC)
+	if err != nil {
+		return false
+	}
+	// The non-blocking descriptor is handled by the runtime poller, so
+	// that the read honours the deadline.
+	w := os.NewFile(uintptr(fd), "inotify")
+	defer w.Close()
+	const mask = syscall.IN_MODIFY | syscall.IN_ATTRIB | syscall.IN_CLOSE_WRITE | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+	if _, err := syscall.InotifyAddWatch(fd, f.Name(), mask); err != nil {
+		return false
+	}
+	if err := w.SetReadDeadline(time.Now().Add(d)); err != nil {
+		return false
+	}
+	// The data written before the watch is added raises no event.
+	if pos, err := f.Seek(0, io.SeekCurrent); err == nil {
+		if fi, err := f.Stat(); err == nil && fi.Size() != pos {
+			return true
+		}
+	}
+	if ctx != nil {
+		stop := context.AfterFunc(ctx, func() { w.SetReadDeadline(time.Now()) })
+		defer stop()
+	}
+	var buf [syscall.SizeofInotifyEvent + syscall.NAME_MAX + 1]byte
+	w.Read(buf[:])
+	return true
+}
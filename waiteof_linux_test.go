@@ -0,0 +1,50 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/protoscan/protoscan"
+)
+
+func TestWaitEOFNotify(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "log")
+	w, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	r, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if _, err := w.WriteString("ab"); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		w.WriteString("c\n")
+	}()
+	s := protoscan.New(
+		r,
+		protoscan.WithSplit(protoscan.ScanLines),
+		protoscan.WithWaitEOF(time.Hour, 0),
+	)
+	start := time.Now()
+	if !s.Scan() {
+		t.Fatal(s.Err())
+	}
+	if string(s.Token()) != "abc" {
+		t.Fatalf("unexpected token %q", s.Token())
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("the modification is not noticed, took %v", d)
+	}
+}
@@ -0,0 +1,19 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+
+package protoscan
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// waitFile reports false, the file is polled on the systems other than
+// Linux.
+func waitFile(ctx context.Context, f *os.File, d time.Duration) bool {
+	return false
+}
@@ -0,0 +1,110 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/protoscan/protoscan"
+)
+
+func TestWaitEOF(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "log")
+	w, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	r, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if _, err := w.WriteString("ab"); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		w.WriteString("c\nd")
+	}()
+	s := protoscan.New(
+		r,
+		protoscan.WithSplit(protoscan.ScanLines),
+		protoscan.WithWaitEOF(time.Millisecond, 200*time.Millisecond),
+	)
+	var lines []string
+	for s.Scan() {
+		lines = append(lines, string(s.Token()))
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	if got := strings.Join(lines, "|"); got != "abc|d" {
+		t.Fatalf("unexpected lines %q", got)
+	}
+}
+
+func TestWaitEOFCanceled(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "log")
+	if err := os.WriteFile(name, []byte("a\nb"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	s := protoscan.ScanContext(ctx, r, protoscan.ScanLines, protoscan.WithWaitEOF(time.Hour, 0))
+	if !s.Scan() || string(s.Token()) != "a" {
+		t.Fatalf("unexpected token %q: %v", s.Token(), s.Err())
+	}
+	time.AfterFunc(20*time.Millisecond, cancel)
+	start := time.Now()
+	if s.Scan() {
+		t.Fatalf("unexpected token %q", s.Token())
+	}
+	if !errors.Is(s.Err(), context.Canceled) {
+		t.Fatalf("unexpected error %v", s.Err())
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("the wait is not canceled, took %v", d)
+	}
+}
+
+func TestWaitEOFAbort(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "log")
+	if err := os.WriteFile(name, []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	s := protoscan.New(
+		r,
+		protoscan.WithSplit(protoscan.ScanLines),
+		protoscan.WithWaitEOF(time.Hour, 0),
+		protoscan.WithTotalTimeout(20*time.Millisecond),
+		protoscan.WithAbort(func() {}),
+	)
+	start := time.Now()
+	if s.Scan() {
+		t.Fatalf("unexpected token %q", s.Token())
+	}
+	if !errors.Is(s.Err(), protoscan.ErrAborted) {
+		t.Fatalf("unexpected error %v", s.Err())
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("the wait is not cut at the deadline, took %v", d)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Flags of the Zabbix protocol header.
+const (
+	zabbixCompressed = 0x02 // Compressed payload.
+	zabbixLarge      = 0x04 // Large packet, of the 8-byte lengths.
+)
+
+// zabbixMaxUncompressed is the maximum uncompressed size of the Zabbix
+// payload, bounding the allocation for the decompression.
+const zabbixMaxUncompressed = 64 << 20
+
+// ScanZabbix is a split function for a Protoscan that returns the JSON
+// payload of each Zabbix sender or agent packet as a token. The packet
+// starts by the "ZBXD" and the flags byte, followed by the little-endian
+// payload length and the reserved length, 4 bytes each, or 8 bytes each if
+// the large packet flag is set. The compressed payload is decompressed, its
+// uncompressed size is the reserved length, into the token allocated for the
+// packet. The packet not starting by the "ZBXD" is reported as the
+// ErrNeedResync, the corrupt compressed payload as the ErrCorruptFrame.
+func ScanZabbix(data []byte, atEOF bool) (int, int, []byte, error) {
+	if len(data) == 0 {
+		if atEOF {
+			return 0, 0, nil, nil
+		}
+		return 1, 0, nil, nil
+	}
+	if m := data[:min(len(data), 4)]; !bytes.HasPrefix([]byte("ZBXD"), m) {
+		return 0, 1, nil, fmt.Errorf("%w: bad Zabbix header %q", ErrNeedResync, m)
+	}
+	if len(data) < 5 {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return 5 - len(data), 0, nil, nil
+	}
+	flags := data[4]
+	size := 4
+	if flags&zabbixLarge != 0 {
+		size = 8
+	}
+	header := 5 + 2*size
+	if len(data) < header {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return header - len(data), 0, nil, nil
+	}
+	length, reserved := uint64(binary.LittleEndian.Uint32(data[5:])), uint64(binary.LittleEndian.Uint32(data[9:]))
+	if size == 8 {
+		length, reserved = binary.LittleEndian.Uint64(data[5:]), binary.LittleEndian.Uint64(data[13:])
+	}
+	if length > uint64(math.MaxInt-header) {
+		return 0, 0, nil, fmt.Errorf("%w: Zabbix payload of %d bytes", ErrTooLong, length)
+	}
+	n := header + int(length)
+	if len(data) < n {
+		if atEOF {
+			return 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return n - len(data), 0, nil, nil
+	}
+	payload := data[header:n]
+	if flags&zabbixCompressed == 0 {
+		return 0, n, payload, nil
+	}
+	if reserved > zabbixMaxUncompressed {
+		return 0, n, nil, fmt.Errorf("%w: Zabbix uncompressed size %d exceeds maximum of %d", ErrCorruptFrame, reserved, zabbixMaxUncompressed)
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return 0, n, nil, fmt.Errorf("%w: Zabbix payload: %v", ErrCorruptFrame, err)
+	}
+	token := make([]byte, reserved)
+	if _, err := io.ReadFull(zr, token); err != nil {
+		return 0, n, nil, fmt.Errorf("%w: Zabbix payload: %v", ErrCorruptFrame, err)
+	}
+	if k, err := zr.Read(make([]byte, 1)); k > 0 || err != io.EOF {
+		return 0, n, nil, fmt.Errorf("%w: Zabbix payload not of %d bytes", ErrCorruptFrame, reserved)
+	}
+	return 0, n, token, nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoscan_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/protoscan/protoscan"
+	"github.com/protoscan/protoscan/protoscantest"
+)
+
+// zabbix returns the Zabbix packet of the payload.
+func zabbix(flags byte, payload string) string {
+	data := []byte(payload)
+	reserved := 0
+	if flags&0x02 != 0 {
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		zw.Write(data)
+		zw.Close()
+		data, reserved = buf.Bytes(), len(payload)
+	}
+	p := append([]byte("ZBXD"), flags)
+	if flags&0x04 != 0 {
+		p = binary.LittleEndian.AppendUint64(p, uint64(len(data)))
+		p = binary.LittleEndian.AppendUint64(p, uint64(reserved))
+	} else {
+		p = binary.LittleEndian.AppendUint32(p, uint32(len(data)))
+		p = binary.LittleEndian.AppendUint32(p, uint32(reserved))
+	}
+	return string(append(p, data...))
+}
+
+func TestScanZabbix(t *testing.T) {
+	req := `{"request":"sender data","data":[{"host":"h","key":"k","value":"1"}]}`
+	resp := `{"response":"success","info":"processed: 1"}`
+	corrupt := zabbix(0x03, req)
+	corrupt = corrupt[:len(corrupt)-2] + "xx"
+	protoscantest.TestSplitFunc(t, protoscan.ScanZabbix, []protoscantest.Case{
+		{Name: "empty"},
+		{Name: "packets", Input: zabbix(0x01, req) + zabbix(0x01, resp), Tokens: []string{req, resp}},
+		{Name: "compressed", Input: zabbix(0x03, req) + zabbix(0x01, ""), Tokens: []string{req, ""}},
+		{Name: "large", Input: zabbix(0x05, resp) + zabbix(0x07, req), Tokens: []string{resp, req}},
+		{Name: "truncated", Input: zabbix(0x01, req)[:20], Err: io.ErrUnexpectedEOF},
+		{Name: "bad header", Input: "ZBX!\x01", Err: protoscan.ErrNeedResync},
+		{Name: "corrupt", Input: corrupt, Err: protoscan.ErrCorruptFrame},
+	})
+	protoscantest.TestSplitFunc(t, protoscan.ScanZabbix, []protoscantest.Case{
+		{Name: "recovery", Input: "ZB" + corrupt + zabbix(0x01, resp), Tokens: []string{resp}},
+	}, protoscan.WithRecovery(nil))
+}
+
+func FuzzScanZabbix(f *testing.F) {
+	f.Add([]byte(zabbix(0x01, "{}") + zabbix(0x03, "{}")))
+	f.Fuzz(protoscantest.Fuzz(protoscan.ScanZabbix))
+}